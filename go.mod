@@ -4,7 +4,6 @@ go 1.24.6
 
 require (
 	github.com/apache/iotdb-client-go/v2 v2.0.3-1
+	github.com/apache/thrift v0.15.0
 	github.com/go-echarts/go-echarts/v2 v2.4.0
 )
-
-require github.com/apache/thrift v0.15.0 // indirect
@@ -1,13 +1,23 @@
 package cli
 
 import (
+	"bdgp2025/src/db_interface"
 	"bdgp2025/src/handlers"
 	"bdgp2025/src/utils"
+	"bdgp2025/src/utils/filter"
+	"bdgp2025/src/utils/kde"
+	"bdgp2025/src/utils/rolling"
+	"bdgp2025/src/utils/stats"
+	"bdgp2025/src/utils/xcorr"
+	"context"
 	"flag"
 	"fmt"
 	"log"
+	"os"
+	"os/signal"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/apache/iotdb-client-go/v2/client"
 )
@@ -20,7 +30,28 @@ func Main() {
 	statisticCalc := flag.Bool("stat", false, "Calculate statistics (shorthand)")
 	statisticGraph := flag.Bool("graph", false, "Generate statistic graph (shorthand)")
 	correlationCalc := flag.Bool("corr", false, "Calculate correlation coefficients (shorthand)")
+	correlationMethod := flag.String("corr-method", stats.CorrelationPearson, "Methods of -corr: pearson, spearman, kendall, a comma-separated list or all")
+	partialCorrelation := flag.Bool("partial", false, "Add the partial correlation matrix to -corr, controlling for all other columns unless -controls is given")
+	controls := flag.String("controls", "", "Comma-separated measurements held fixed by the partial correlations of -corr, implies -partial")
 	conditionAnalysis := flag.Bool("condition", false, "Analyze engine conditions (shorthand)")
+	distributionFit := flag.Bool("fit", false, "Fit candidate distributions to every measurement and rank them")
+	kdeMeasurement := flag.String("kde", "", "Estimate the density of the given measurement and print it as JSON")
+	kernel := flag.String("kernel", kde.KernelGaussian, "Kernel of -kde: gaussian or epanechnikov")
+	bandwidth := flag.String("bandwidth", kde.BandwidthSilverman, "Bandwidth of -kde: silverman, scott or a positive number")
+	rollingMeasurement := flag.String("rolling", "", "Compute statistics of the given measurement over rolling windows")
+	window := flag.String("window", "100", "Window of -rolling: a row count such as 500 or a duration such as 60s")
+	step := flag.String("step", "", "Step between -rolling windows, the window itself when empty (tumbling)")
+	percentiles := flag.String("percentiles", "", "Comma-separated percentiles of -rolling and -quantiles, e.g. 5,50,95")
+	quantiles := flag.String("quantiles", "", "Compute exact percentiles of the given comma-separated measurements, or all, grouped by -group-by if given")
+	quantileMethod := flag.String("quantile-method", "7", "Hyndman-Fan type of -quantiles from 1 to 9; 7 matches numpy")
+	relevanceTarget := flag.String("relevance", "", "Rank the numeric measurements by their association with the given categorical column, e.g. engine_condition")
+	crossCorrelation := flag.String("xcorr", "", "Cross-correlate two comma-separated measurements over lags, e.g. fuel_pressure,engine_rpm")
+	maxLag := flag.Int("max-lag", xcorr.DefaultMaxLag, "Largest lag of -xcorr in rows")
+	listDevices := flag.Bool("devices", false, "List devices with their row counts and time ranges")
+	listTimeseries := flag.Bool("timeseries", false, "List the timeseries of the device given by -device-id")
+	groupBy := flag.String("group-by", "", "Compute detailed statistics per value of the given column, e.g. engine_condition")
+	binWidth := flag.Float64("bin", 0, "Group a numeric -group-by column into bands of this width, e.g. 500")
+	filterFlag := flag.String("filter", "", "Restrict analyses to rows matching an expression, e.g. \"engine_rpm > 1000 and coolant_temp < 90\"")
 
 	flag.Parse()
 
@@ -33,18 +64,38 @@ func Main() {
 	// Convert to IoTDB config
 	iotdbConfig := configWithSources.ToIoTDBConfig()
 
-	config := &client.Config{
-		Host:     iotdbConfig.Host,
-		Port:     iotdbConfig.Port,
-		UserName: iotdbConfig.User,
-		Password: iotdbConfig.Password,
+	filterExpr, err := filter.Parse(*filterFlag)
+	if err != nil {
+		log.Fatal(err)
 	}
+
 	timeout := iotdbConfig.Timeout
-	session := client.NewSession(config)
-	if err := session.Open(false, 0); err != nil {
+	pool, err := db_interface.NewSessionPool(db_interface.PoolConfig{
+		Host:                iotdbConfig.Host,
+		Port:                iotdbConfig.Port,
+		User:                iotdbConfig.User,
+		Password:            iotdbConfig.Password,
+		Size:                iotdbConfig.PoolSize,
+		MaxRetries:          iotdbConfig.MaxRetries,
+		HealthCheckInterval: -1, // 命令行只执行一次操作，无需后台健康检查
+	})
+	if err != nil {
 		log.Fatal(err)
 	}
-	defer session.Close()
+	defer pool.Close()
+	scan := db_interface.ScanOptions{Pool: pool, Partitions: iotdbConfig.ScanPartitions, QuantileEpsilon: iotdbConfig.QuantileEpsilon}
+	bootstrap := stats.DefaultBootstrapConfig()
+	bootstrap.Resamples = iotdbConfig.BootstrapResamples
+	bootstrap.Seed = uint64(iotdbConfig.BootstrapSeed)
+
+	// Ctrl-C stops a running analysis instead of leaving the scan running on the server
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	if iotdbConfig.RequestTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(iotdbConfig.RequestTimeout)*time.Millisecond)
+		defer cancel()
+	}
 
 	// Check if import-csv flag is provided
 	if *importCSV != "" || *importCSVLong != "" {
@@ -59,56 +110,263 @@ func Main() {
 			log.Fatalf("Error: File '%s' is not a CSV file (extension: %s)", csvFile, ext)
 		}
 
-		handleCSVImport(csvFile, session, *deviceId)
+		handleCSVImport(ctx, csvFile, pool, *deviceId)
 	} else if *statisticCalc {
 		// Execute statistic calculation
-		handleStatisticCalc(session, *deviceId, timeout)
+		handleStatisticCalc(ctx, pool, *deviceId, filterExpr, timeout, scan)
 	} else if *statisticGraph {
 		// Execute statistic graph generation
-		handleStatisticGraph(session, *deviceId, timeout)
+		handleStatisticGraph(ctx, pool, *deviceId, filterExpr, timeout)
 	} else if *correlationCalc {
 		// Execute correlation calculation
-		handleCorrelationCalc(session, *deviceId, timeout)
+		methods, err := stats.ParseCorrelationMethods(*correlationMethod)
+		if err != nil {
+			log.Fatal(err)
+		}
+		correlationOptions := db_interface.CorrelationOptions{Methods: methods, Partial: *partialCorrelation}
+		if *controls != "" {
+			correlationOptions.Controls = strings.Split(*controls, ",")
+		}
+		handleCorrelationCalc(ctx, pool, *deviceId, filterExpr, timeout, scan, bootstrap, correlationOptions)
 	} else if *conditionAnalysis {
 		// Execute condition analysis
-		handleConditionAnalysis(session, *deviceId, timeout)
+		handleConditionAnalysis(ctx, pool, *deviceId, filterExpr, timeout, scan, bootstrap)
+	} else if *quantiles != "" {
+		// Execute percentile calculation
+		percentileQuery := db_interface.PercentileQuery{GroupBy: *groupBy}
+		if *quantiles != "all" {
+			for _, measurement := range strings.Split(*quantiles, ",") {
+				percentileQuery.Measurements = append(percentileQuery.Measurements, strings.TrimSpace(measurement))
+			}
+		}
+		if *percentiles != "" {
+			if percentileQuery.Percentiles, err = stats.ParsePercentiles(*percentiles); err != nil {
+				log.Fatal(err)
+			}
+		}
+		if percentileQuery.Method, err = stats.ParseQuantileMethod(*quantileMethod); err != nil {
+			log.Fatal(err)
+		}
+		handlePercentiles(ctx, pool, *deviceId, percentileQuery, filterExpr, timeout)
+	} else if *relevanceTarget != "" {
+		// Execute feature relevance ranking
+		handleRelevance(ctx, pool, *deviceId, *relevanceTarget, filterExpr, timeout)
+	} else if *groupBy != "" {
+		// Execute group analysis
+		handleGroupAnalysis(ctx, pool, *deviceId, filterExpr, db_interface.GroupBy{Column: *groupBy, BinWidth: *binWidth}, timeout, scan)
+	} else if *distributionFit {
+		// Execute distribution fitting
+		handleDistributionFit(ctx, pool, *deviceId, filterExpr, timeout, scan)
+	} else if *kdeMeasurement != "" {
+		// Execute kernel density estimation
+		kdeConfig := kde.DefaultConfig()
+		kdeConfig.Kernel = *kernel
+		if err := kdeConfig.SetBandwidth(*bandwidth); err != nil {
+			log.Fatal(err)
+		}
+		if err := kdeConfig.Validate(); err != nil {
+			log.Fatal(err)
+		}
+		handleKDE(ctx, pool, *deviceId, *kdeMeasurement, filterExpr, kdeConfig, timeout)
+	} else if *rollingMeasurement != "" {
+		// Execute rolling-window statistics
+		rollingConfig, err := rolling.ParseConfig(*window, *step, *percentiles)
+		if err != nil {
+			log.Fatal(err)
+		}
+		handleRolling(ctx, pool, *deviceId, *rollingMeasurement, filterExpr, rollingConfig, timeout)
+	} else if *crossCorrelation != "" {
+		// Execute lagged cross-correlation
+		measurements := strings.Split(*crossCorrelation, ",")
+		if len(measurements) != 2 {
+			log.Fatalf("-xcorr needs two comma-separated measurements, got %q", *crossCorrelation)
+		}
+		if *maxLag < 0 {
+			log.Fatalf("-max-lag must not be negative, got %d", *maxLag)
+		}
+		handleCrossCorrelation(ctx, pool, *deviceId, strings.TrimSpace(measurements[0]), strings.TrimSpace(measurements[1]), *maxLag, filterExpr, timeout)
+	} else if *listDevices {
+		// Execute device discovery
+		handleDeviceList(ctx, pool, timeout)
+	} else if *listTimeseries {
+		// Execute timeseries discovery
+		handleTimeseriesList(ctx, pool, *deviceId, timeout)
 	}
 }
 
 // handleCSVImport 处理CSV文件导入功能
-func handleCSVImport(csvFile string, session client.Session, deviceId string) {
-	err := handlers.HandleCSVImport(csvFile, session, deviceId)
+func handleCSVImport(ctx context.Context, csvFile string, pool *db_interface.SessionPool, deviceId string) {
+	err := pool.WithSession(ctx, func(session client.Session) error {
+		return handlers.HandleCSVImport(ctx, csvFile, session, deviceId)
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+func handleStatisticCalc(ctx context.Context, pool *db_interface.SessionPool, deviceId string, filterExpr *filter.Expression, timeout int64, scan db_interface.ScanOptions) {
+	var result string
+	err := pool.WithRetry(ctx, func(session client.Session) error {
+		var errHandle error
+		result, errHandle = handlers.HandleStatisticCalc(ctx, session, deviceId, filterExpr, timeout, scan)
+		return errHandle
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Print(result)
+}
+
+func handleCorrelationCalc(ctx context.Context, pool *db_interface.SessionPool, deviceId string, filterExpr *filter.Expression, timeout int64, scan db_interface.ScanOptions, bootstrap stats.BootstrapConfig, options db_interface.CorrelationOptions) {
+	var result string
+	err := pool.WithRetry(ctx, func(session client.Session) error {
+		var errHandle error
+		result, errHandle = handlers.HandleCorrelationCalc(ctx, session, deviceId, filterExpr, timeout, scan, bootstrap, options, handlers.FormatText)
+		return errHandle
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Print(result)
+}
+
+func handleStatisticGraph(ctx context.Context, pool *db_interface.SessionPool, deviceId string, filterExpr *filter.Expression, timeout int64) {
+	var result string
+	err := pool.WithRetry(ctx, func(session client.Session) error {
+		var errHandle error
+		result, errHandle = handlers.HandleStatisticGraph(ctx, session, deviceId, filterExpr, timeout)
+		return errHandle
+	})
 	if err != nil {
 		log.Fatal(err)
 	}
+	fmt.Print(result)
 }
 
-func handleStatisticCalc(session client.Session, deviceId string, timeout int64) {
-	result, err := handlers.HandleStatisticCalc(session, deviceId, timeout)
+func handleConditionAnalysis(ctx context.Context, pool *db_interface.SessionPool, deviceId string, filterExpr *filter.Expression, timeout int64, scan db_interface.ScanOptions, bootstrap stats.BootstrapConfig) {
+	var result string
+	err := pool.WithRetry(ctx, func(session client.Session) error {
+		var errHandle error
+		result, errHandle = handlers.HandleConditionAnalysis(ctx, session, deviceId, filterExpr, timeout, scan, bootstrap, handlers.FormatText)
+		return errHandle
+	})
 	if err != nil {
 		log.Fatal(err)
 	}
 	fmt.Print(result)
 }
 
-func handleCorrelationCalc(session client.Session, deviceId string, timeout int64) {
-	result, err := handlers.HandleCorrelationCalc(session, deviceId, timeout)
+func handleGroupAnalysis(ctx context.Context, pool *db_interface.SessionPool, deviceId string, filterExpr *filter.Expression, groupBy db_interface.GroupBy, timeout int64, scan db_interface.ScanOptions) {
+	var result string
+	err := pool.WithRetry(ctx, func(session client.Session) error {
+		var errHandle error
+		result, errHandle = handlers.HandleGroupAnalysis(ctx, session, deviceId, filterExpr, groupBy, timeout, scan, handlers.FormatText)
+		return errHandle
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Print(result)
+}
+
+func handleDistributionFit(ctx context.Context, pool *db_interface.SessionPool, deviceId string, filterExpr *filter.Expression, timeout int64, scan db_interface.ScanOptions) {
+	var result string
+	err := pool.WithRetry(ctx, func(session client.Session) error {
+		var errHandle error
+		result, errHandle = handlers.HandleDistributionFit(ctx, session, deviceId, filterExpr, timeout, scan, handlers.FormatText)
+		return errHandle
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Print(result)
+}
+
+func handleKDE(ctx context.Context, pool *db_interface.SessionPool, deviceId string, measurement string, filterExpr *filter.Expression, kdeConfig kde.Config, timeout int64) {
+	var result string
+	err := pool.WithRetry(ctx, func(session client.Session) error {
+		var errHandle error
+		result, errHandle = handlers.HandleKDE(ctx, session, deviceId, measurement, filterExpr, kdeConfig, timeout)
+		return errHandle
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(result)
+}
+
+func handleRolling(ctx context.Context, pool *db_interface.SessionPool, deviceId string, measurement string, filterExpr *filter.Expression, rollingConfig rolling.Config, timeout int64) {
+	var result string
+	err := pool.WithRetry(ctx, func(session client.Session) error {
+		var errHandle error
+		result, errHandle = handlers.HandleRolling(ctx, session, deviceId, measurement, filterExpr, rollingConfig, timeout, handlers.FormatText)
+		return errHandle
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(result)
+}
+
+func handlePercentiles(ctx context.Context, pool *db_interface.SessionPool, deviceId string, query db_interface.PercentileQuery, filterExpr *filter.Expression, timeout int64) {
+	var result string
+	err := pool.WithRetry(ctx, func(session client.Session) error {
+		var errHandle error
+		result, errHandle = handlers.HandlePercentiles(ctx, session, deviceId, query, filterExpr, timeout, handlers.FormatText)
+		return errHandle
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(result)
+}
+
+func handleRelevance(ctx context.Context, pool *db_interface.SessionPool, deviceId string, target string, filterExpr *filter.Expression, timeout int64) {
+	var result string
+	err := pool.WithRetry(ctx, func(session client.Session) error {
+		var errHandle error
+		result, errHandle = handlers.HandleRelevance(ctx, session, deviceId, target, filterExpr, timeout, handlers.FormatText)
+		return errHandle
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(result)
+}
+
+func handleCrossCorrelation(ctx context.Context, pool *db_interface.SessionPool, deviceId string, x string, y string, maxLag int, filterExpr *filter.Expression, timeout int64) {
+	var result string
+	err := pool.WithRetry(ctx, func(session client.Session) error {
+		var errHandle error
+		result, errHandle = handlers.HandleCrossCorrelation(ctx, session, deviceId, x, y, maxLag, filterExpr, timeout, handlers.FormatText)
+		return errHandle
+	})
 	if err != nil {
 		log.Fatal(err)
 	}
 	fmt.Print(result)
 }
 
-func handleStatisticGraph(session client.Session, deviceId string, timeout int64) {
-	result, err := handlers.HandleStatisticGraph(session, deviceId, timeout)
+func handleDeviceList(ctx context.Context, pool *db_interface.SessionPool, timeout int64) {
+	var result string
+	err := pool.WithRetry(ctx, func(session client.Session) error {
+		var errHandle error
+		result, errHandle = handlers.HandleDeviceList(ctx, session, timeout, handlers.FormatText)
+		return errHandle
+	})
 	if err != nil {
 		log.Fatal(err)
 	}
 	fmt.Print(result)
 }
 
-func handleConditionAnalysis(session client.Session, deviceId string, timeout int64) {
-	result, err := handlers.HandleConditionAnalysis(session, deviceId, timeout)
+func handleTimeseriesList(ctx context.Context, pool *db_interface.SessionPool, deviceId string, timeout int64) {
+	var result string
+	err := pool.WithRetry(ctx, func(session client.Session) error {
+		var errHandle error
+		result, errHandle = handlers.HandleTimeseriesList(ctx, session, deviceId, timeout, handlers.FormatText)
+		return errHandle
+	})
 	if err != nil {
 		log.Fatal(err)
 	}
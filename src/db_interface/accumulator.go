@@ -0,0 +1,307 @@
+package db_interface
+
+import (
+	"bdgp2025/src/utils/sketch"
+	"bdgp2025/src/utils/stats"
+	"math"
+)
+
+// normalitySampleSize bounds the values kept per column for the normality tests that need
+// the empirical distribution itself. Columns with fewer rows are tested on all of them.
+const normalitySampleSize = 5000
+
+const (
+	frequentValueCapacity = 64 // Space-Saving counters per column
+	topValueCount         = 5  // Most frequent values reported per column
+	entropyBins           = 32 // Equal-width bins of the entropy, which is at most 5 bits
+)
+
+// ColumnSummary holds the descriptive statistics of one column. Variance and StdDev use the
+// sample (n-1) denominator; Skewness and Kurtosis are the population moment ratios g1 and g2,
+// with Kurtosis reported as excess kurtosis. Quartiles come from a KLL sketch. Distinct
+// counts above 16384 are HyperLogLog estimates, the mode and top values come from a
+// Space-Saving sketch and the entropy from the binned uniform sample.
+type ColumnSummary struct {
+	Count         int64                  `json:"count"` // Non-null values
+	NullCount     int64                  `json:"null_count"`
+	DistinctCount int64                  `json:"distinct_count"`
+	DistinctExact bool                   `json:"distinct_exact"`
+	Mode          float64                `json:"mode"`
+	ModeCount     int64                  `json:"mode_count"` // Upper bound, exact with at most 64 distinct values
+	TopValues     []sketch.FrequentValue `json:"top_values"`
+	Entropy       float64                `json:"entropy"` // Bits over 32 equal-width bins
+	Sum           float64                `json:"sum"`
+	Mean          float64                `json:"mean"`
+	Variance      float64                `json:"variance"`
+	StdDev        float64                `json:"std_dev"`
+	Min           float64                `json:"min"`
+	Max           float64                `json:"max"`
+	Median        float64                `json:"median"`
+	Q1            float64                `json:"q1"`
+	Q3            float64                `json:"q3"`
+	IQR           float64                `json:"iqr"`
+	Skewness      float64                `json:"skewness"`
+	Kurtosis      float64                `json:"kurtosis"`
+}
+
+// Moments accumulates count, mean, min, max and the central moment sums M2, M3 and M4 of a
+// stream of values. Two Moments built over disjoint parts of the data merge into exactly the
+// Moments of the whole, using the pairwise formulas of Chan et al. and Pébay. The zero value
+// is ready to use.
+type Moments struct {
+	Count int64
+	Mean  float64
+	M2    float64 // Sums of powers of deviations from the mean
+	M3    float64
+	M4    float64
+	Min   float64
+	Max   float64
+}
+
+// Add folds one value into m
+func (m *Moments) Add(x float64) {
+	if m.Count == 0 {
+		m.Min, m.Max = x, x
+	} else {
+		m.Min = math.Min(m.Min, x)
+		m.Max = math.Max(m.Max, x)
+	}
+
+	// Use extended Welford Algorithm to calculate M2, M3 and M4
+	// 使用扩展的 Welford 算法来计算高阶中心距
+	m.Count++
+	n := float64(m.Count)
+	delta := x - m.Mean
+	deltaN := delta / n
+	deltaN2 := deltaN * deltaN
+	term1 := delta * deltaN * (n - 1)
+	m.Mean += deltaN
+	m.M4 += term1*deltaN2*(n*n-3*n+3) + 6*deltaN2*m.M2 - 4*deltaN*m.M3
+	m.M3 += term1*deltaN*(n-2) - 3*deltaN*m.M2
+	m.M2 += term1
+}
+
+// Merge folds other into m as if every value of other had been added to m
+func (m *Moments) Merge(other Moments) {
+	if other.Count == 0 {
+		return
+	}
+	if m.Count == 0 {
+		*m = other
+		return
+	}
+
+	na, nb := float64(m.Count), float64(other.Count)
+	n := na + nb
+	delta := other.Mean - m.Mean
+	delta2 := delta * delta
+
+	m4 := m.M4 + other.M4 + delta2*delta2*na*nb*(na*na-na*nb+nb*nb)/(n*n*n) +
+		6*delta2*(na*na*other.M2+nb*nb*m.M2)/(n*n) + 4*delta*(na*other.M3-nb*m.M3)/n
+	m3 := m.M3 + other.M3 + delta2*delta*na*nb*(na-nb)/(n*n) + 3*delta*(na*other.M2-nb*m.M2)/n
+	m2 := m.M2 + other.M2 + delta2*na*nb/n
+
+	m.Count += other.Count
+	m.Mean += delta * nb / n
+	m.M2, m.M3, m.M4 = m2, m3, m4
+	m.Min = math.Min(m.Min, other.Min)
+	m.Max = math.Max(m.Max, other.Max)
+}
+
+// Variance returns the sample variance, or 0 with fewer than two values
+func (m Moments) Variance() float64 {
+	if m.Count < 2 {
+		return 0
+	}
+	return m.M2 / float64(m.Count-1)
+}
+
+// Skewness returns the moment ratio g1, or 0 when it is undefined
+func (m Moments) Skewness() float64 {
+	// Skewness: sqrt(n) * M3 / (M2^(3/2))
+	if m.Count < 3 || m.M2 == 0 {
+		return 0
+	}
+	return math.Sqrt(float64(m.Count)) * m.M3 / math.Pow(m.M2, 1.5)
+}
+
+// Kurtosis returns the excess kurtosis g2, or 0 when it is undefined
+func (m Moments) Kurtosis() float64 {
+	// Kurtosis: n * M4 / M2^2 - 3
+	if m.Count < 3 || m.M2 == 0 {
+		return 0
+	}
+	return float64(m.Count)*m.M4/(m.M2*m.M2) - 3.0
+}
+
+// CoMoments accumulates the co-moment of paired values, the basis of covariance and Pearson
+// correlation. Like Moments it merges exactly and its zero value is ready to use.
+type CoMoments struct {
+	Count int64
+	MeanX float64
+	MeanY float64
+	M2X   float64
+	M2Y   float64
+	CXY   float64 // Sum of (x - MeanX) * (y - MeanY)
+}
+
+// Add folds one pair into c
+func (c *CoMoments) Add(x float64, y float64) {
+	c.Count++
+	n := float64(c.Count)
+	dx := x - c.MeanX
+	dy := y - c.MeanY
+	c.MeanX += dx / n
+	c.MeanY += dy / n
+	c.M2X += dx * (x - c.MeanX)
+	c.M2Y += dy * (y - c.MeanY)
+	c.CXY += dx * (y - c.MeanY)
+}
+
+// Merge folds other into c as if every pair of other had been added to c
+func (c *CoMoments) Merge(other CoMoments) {
+	if other.Count == 0 {
+		return
+	}
+	if c.Count == 0 {
+		*c = other
+		return
+	}
+
+	na, nb := float64(c.Count), float64(other.Count)
+	n := na + nb
+	dx := other.MeanX - c.MeanX
+	dy := other.MeanY - c.MeanY
+	c.M2X += other.M2X + dx*dx*na*nb/n
+	c.M2Y += other.M2Y + dy*dy*na*nb/n
+	c.CXY += other.CXY + dx*dy*na*nb/n
+	c.MeanX += dx * nb / n
+	c.MeanY += dy * nb / n
+	c.Count += other.Count
+}
+
+// Covariance returns the sample covariance, or 0 with fewer than two pairs
+func (c CoMoments) Covariance() float64 {
+	if c.Count < 2 {
+		return 0
+	}
+	return c.CXY / float64(c.Count-1)
+}
+
+// Correlation returns Pearson's r, or 0 when either side is constant
+func (c CoMoments) Correlation() float64 {
+	if c.M2X == 0 || c.M2Y == 0 {
+		return 0
+	}
+	return c.CXY / math.Sqrt(c.M2X*c.M2Y)
+}
+
+// ColumnAccumulator collects the statistics of ColumnSummary in a single pass over a column.
+// Every analysis that reports per-column statistics feeds one of these, and accumulators of
+// separately scanned partitions are combined with Merge.
+type ColumnAccumulator struct {
+	moments   Moments
+	nulls     int64
+	sum       float64
+	quantiles *sketch.KLL
+	sample    *sketch.Reservoir[float64]
+	distinct  *sketch.Distinct
+	frequent  *sketch.SpaceSaving
+}
+
+// NewColumnAccumulator creates an empty accumulator whose quantile sketch is sized by quantiles
+func NewColumnAccumulator(quantiles sketch.Config) *ColumnAccumulator {
+	return &ColumnAccumulator{
+		quantiles: sketch.NewKLL(quantiles),
+		sample:    sketch.NewReservoir[float64](normalitySampleSize),
+		distinct:  sketch.NewDistinct(),
+		frequent:  sketch.NewSpaceSaving(frequentValueCapacity),
+	}
+}
+
+// Add folds one value into the accumulator. NaN is counted as a null.
+func (a *ColumnAccumulator) Add(x float64) {
+	if math.IsNaN(x) {
+		a.nulls++
+		return
+	}
+	a.moments.Add(x)
+	a.sum += x
+	a.quantiles.Add(x)
+	a.sample.Add(x)
+	a.distinct.Add(x)
+	a.frequent.Add(x)
+}
+
+// AddNull records a row in which the column has no value
+func (a *ColumnAccumulator) AddNull() {
+	a.nulls++
+}
+
+// Merge folds other into a. The moments combine exactly; quartiles, distinct counts and
+// frequent values stay within the error bounds of the merged sketches.
+func (a *ColumnAccumulator) Merge(other *ColumnAccumulator) {
+	a.moments.Merge(other.moments)
+	a.nulls += other.nulls
+	a.sum += other.sum
+	a.quantiles.Merge(other.quantiles)
+	a.sample.Merge(other.sample)
+	a.distinct.Merge(other.distinct)
+	a.frequent.Merge(other.frequent)
+}
+
+// Moments returns the moments of the non-null values
+func (a *ColumnAccumulator) Moments() Moments {
+	return a.moments
+}
+
+// Quantiles returns the sketch behind the quartiles, for arbitrary percentiles
+func (a *ColumnAccumulator) Quantiles() *sketch.KLL {
+	return a.quantiles
+}
+
+// Sample returns the uniform sample of at most normalitySampleSize values of the column
+func (a *ColumnAccumulator) Sample() []float64 {
+	return a.sample.Values()
+}
+
+// Normality runs the normality tests of stats.TestNormality on the column
+func (a *ColumnAccumulator) Normality() stats.NormalityReport {
+	if a.moments.Count > 0 && a.moments.M2 == 0 {
+		return stats.NormalityReport{Tests: []stats.TestResult{}, Verdict: "constant, all values are equal"}
+	}
+	return stats.TestNormality(a.moments.Count, a.moments.Skewness(), a.moments.Kurtosis(), a.sample.Values())
+}
+
+// Summary finalizes the statistics. Values that need more data than was seen are left at 0.
+func (a *ColumnAccumulator) Summary() ColumnSummary {
+	summary := ColumnSummary{
+		Count:     a.moments.Count,
+		NullCount: a.nulls,
+		Sum:       a.sum,
+		TopValues: a.frequent.Top(topValueCount),
+	}
+	summary.DistinctCount, summary.DistinctExact = a.distinct.Count()
+	if a.moments.Count == 0 {
+		return summary
+	}
+
+	summary.Mode = summary.TopValues[0].Value
+	summary.ModeCount = summary.TopValues[0].Count
+	summary.Entropy = stats.BinnedEntropy(a.sample.Values(), entropyBins)
+
+	summary.Mean = a.moments.Mean
+	summary.Min = a.moments.Min
+	summary.Max = a.moments.Max
+	summary.Variance = a.moments.Variance()
+	summary.StdDev = math.Sqrt(summary.Variance)
+	summary.Skewness = a.moments.Skewness()
+	summary.Kurtosis = a.moments.Kurtosis()
+
+	quartiles := a.quantiles.Quantiles([]float64{0.25, 0.5, 0.75})
+	summary.Q1 = quartiles[0]
+	summary.Median = quartiles[1]
+	summary.Q3 = quartiles[2]
+	summary.IQR = summary.Q3 - summary.Q1
+	return summary
+}
@@ -0,0 +1,71 @@
+package db_interface
+
+import (
+	"bdgp2025/src/utils/stats"
+	"sort"
+)
+
+// correlationSampleSize bounds the rows kept for bootstrapping correlations. Like the column
+// samples, the bootstrap resamples as many rows as were scanned from this sample.
+const correlationSampleSize = 5000
+
+// ColumnIntervals holds bootstrap confidence intervals of the summary statistics of a column
+type ColumnIntervals struct {
+	Mean   stats.BootstrapInterval `json:"mean"`
+	Median stats.BootstrapInterval `json:"median"`
+	StdDev stats.BootstrapInterval `json:"std_dev"`
+}
+
+// bootstrapColumn computes the intervals of a column from its uniform sample of n values.
+// It reports false when bootstrapping is disabled or the sample is too small.
+func bootstrapColumn(sample []float64, n int64, config stats.BootstrapConfig) (ColumnIntervals, bool) {
+	sorted := append([]float64(nil), sample...)
+	sort.Float64s(sorted)
+	intervals := stats.Bootstrap(len(sorted), n, []stats.Statistic{
+		stats.MeanStatistic(sorted),
+		stats.MedianStatistic(sorted),
+		stats.StdDevStatistic(sorted),
+	}, config)
+	if intervals == nil {
+		return ColumnIntervals{}, false
+	}
+	return ColumnIntervals{Mean: intervals[0], Median: intervals[1], StdDev: intervals[2]}, true
+}
+
+// bootstrapCorrelation computes the intervals of every pairwise correlation from a uniform
+// sample of n rows, in which NaN marks a null. The result is symmetric and nil when
+// bootstrapping is disabled; the diagonal is left at its zero value.
+func bootstrapCorrelation(rows [][]float64, n int64, columns int, config stats.BootstrapConfig) [][]stats.BootstrapInterval {
+	series := make([][]float64, columns)
+	for i := range series {
+		series[i] = make([]float64, len(rows))
+		for r, row := range rows {
+			series[i][r] = row[i]
+		}
+	}
+
+	var statistics []stats.Statistic
+	for i := 0; i < columns; i++ {
+		for j := i + 1; j < columns; j++ {
+			statistics = append(statistics, stats.CorrelationStatistic(series[i], series[j]))
+		}
+	}
+	intervals := stats.Bootstrap(len(rows), n, statistics, config)
+	if intervals == nil {
+		return nil
+	}
+
+	matrix := make([][]stats.BootstrapInterval, columns)
+	for i := range matrix {
+		matrix[i] = make([]stats.BootstrapInterval, columns)
+	}
+	k := 0
+	for i := 0; i < columns; i++ {
+		for j := i + 1; j < columns; j++ {
+			matrix[i][j] = intervals[k]
+			matrix[j][i] = intervals[k]
+			k++
+		}
+	}
+	return matrix
+}
@@ -1,20 +1,20 @@
 package db_interface
 
 import (
-	"log"
-
 	"github.com/apache/iotdb-client-go/v2/client"
 	"github.com/apache/iotdb-client-go/v2/common"
 )
 
-func CheckError(status *common.TSStatus, err error) {
+// CheckError converts the (status, error) pair returned by session calls into a single typed error.
+func CheckError(status *common.TSStatus, err error) error {
 	if err != nil {
-		log.Fatal(err)
+		return classifyError(err)
 	}
 
 	if status != nil {
 		if err = client.VerifySuccess(status); err != nil {
-			log.Println(err)
+			return classifyError(err)
 		}
 	}
+	return nil
 }
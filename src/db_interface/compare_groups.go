@@ -0,0 +1,146 @@
+package db_interface
+
+import (
+	"bdgp2025/src/utils/stats"
+	"math"
+	"sort"
+)
+
+// ComparisonAlpha is the false discovery rate at which a difference counts as significant
+const ComparisonAlpha = 0.05
+
+// AdjustedTest is a test with its p-value adjusted for multiple testing
+type AdjustedTest struct {
+	stats.TestResult
+	AdjustedP float64 `json:"adjusted_p"` // Benjamini-Hochberg across the measurements
+}
+
+// Significant reports whether the test rejects at ComparisonAlpha after adjustment
+func (t AdjustedTest) Significant() bool {
+	return t.AdjustedP < ComparisonAlpha
+}
+
+// PairEffectSizes holds the effect sizes of group A against group B
+type PairEffectSizes struct {
+	GroupA string             `json:"group_a"`
+	GroupB string             `json:"group_b"`
+	Sizes  []stats.EffectSize `json:"sizes"`
+}
+
+// MeasurementComparison holds the tests of one measurement across the groups and the effect
+// sizes of every pair of groups. Score measures how well the measurement separates the
+// groups, independently of the sample size: the Kolmogorov-Smirnov distance for two groups,
+// ε² = H / (n - 1) of Kruskal-Wallis for more.
+type MeasurementComparison struct {
+	Column      int               `json:"-"` // Index into ColumnNames
+	Name        string            `json:"column"`
+	Tests       []AdjustedTest    `json:"tests"`
+	EffectSizes []PairEffectSizes `json:"effect_sizes"`
+	Score       float64           `json:"score"`
+}
+
+// MinAdjustedP returns the smallest adjusted p-value of the tests, 1 without tests
+func (m MeasurementComparison) MinAdjustedP() float64 {
+	p := 1.0
+	for _, test := range m.Tests {
+		p = math.Min(p, test.AdjustedP)
+	}
+	return p
+}
+
+// GroupComparison tests every numeric measurement for differences between groups. Two
+// groups are compared with Welch's t, Mann-Whitney U and two-sample Kolmogorov-Smirnov, more
+// with one-way ANOVA and Kruskal-Wallis. The t and F tests use the exact moments of every
+// row; the rank and distribution tests run on the uniform column samples. P-values are
+// adjusted per test across the measurements.
+type GroupComparison struct {
+	Groups       []string                `json:"groups"`
+	Measurements []MeasurementComparison `json:"measurements"`
+	Ranking      []int                   `json:"ranking"` // Indices into Measurements, most discriminating first
+}
+
+// compareGroups runs the tests of GroupComparison over the statistics of each group
+func compareGroups(groups []string, statistics []DetailedStatisticsResult, columnNames []string, numericColumns []int) GroupComparison {
+	comparison := GroupComparison{Groups: groups, Measurements: make([]MeasurementComparison, 0, len(numericColumns))}
+	for _, i := range numericColumns {
+		summaries := make([]stats.GroupSummary, len(statistics))
+		samples := make([][]float64, len(statistics))
+		for g, s := range statistics {
+			summaries[g] = stats.GroupSummary{Count: s.ColumnCount(i), Mean: s.Mean[i], Variance: s.Variance[i]}
+			samples[g] = s.Samples[i]
+		}
+
+		measurement := MeasurementComparison{Column: i, Name: columnNames[i], Tests: []AdjustedTest{}}
+		add := func(test stats.TestResult, ok bool) {
+			if ok {
+				measurement.Tests = append(measurement.Tests, AdjustedTest{TestResult: test})
+			}
+		}
+		if len(statistics) == 2 {
+			add(stats.WelchTTest(summaries[0], summaries[1]))
+			add(stats.MannWhitneyU(samples[0], samples[1]))
+			ks, ok := stats.KolmogorovSmirnov2(samples[0], samples[1])
+			add(ks, ok)
+			measurement.Score = ks.Statistic
+		} else if len(statistics) > 2 {
+			add(stats.OneWayANOVA(summaries))
+			kw, ok := stats.KruskalWallis(samples)
+			add(kw, ok)
+			if ok && kw.N > 1 {
+				measurement.Score = math.Max(kw.Statistic, 0) / float64(kw.N-1)
+			}
+		}
+
+		// Significance alone says little with thousands of rows, effect sizes say how much
+		measurement.EffectSizes = []PairEffectSizes{}
+		for a := range statistics {
+			for b := a + 1; b < len(statistics); b++ {
+				measurement.EffectSizes = append(measurement.EffectSizes, PairEffectSizes{
+					GroupA: groups[a],
+					GroupB: groups[b],
+					Sizes:  stats.EffectSizes(summaries[a], summaries[b], samples[a], samples[b]),
+				})
+			}
+		}
+		comparison.Measurements = append(comparison.Measurements, measurement)
+	}
+
+	// Each test forms its own family across the measurements
+	family := make(map[string][]*AdjustedTest)
+	var names []string
+	for m := range comparison.Measurements {
+		for t := range comparison.Measurements[m].Tests {
+			test := &comparison.Measurements[m].Tests[t]
+			if _, exists := family[test.Name]; !exists {
+				names = append(names, test.Name)
+			}
+			family[test.Name] = append(family[test.Name], test)
+		}
+	}
+	for _, name := range names {
+		tests := family[name]
+		pValues := make([]float64, len(tests))
+		for k, test := range tests {
+			pValues[k] = test.PValue
+		}
+		for k, adjusted := range stats.BenjaminiHochberg(pValues) {
+			tests[k].AdjustedP = adjusted
+		}
+	}
+
+	// Significance first; with thousands of rows most differences are significant, so the
+	// score then orders them by how far apart the groups are
+	comparison.Ranking = make([]int, len(comparison.Measurements))
+	for m := range comparison.Ranking {
+		comparison.Ranking[m] = m
+	}
+	sort.SliceStable(comparison.Ranking, func(a, b int) bool {
+		ma, mb := comparison.Measurements[comparison.Ranking[a]], comparison.Measurements[comparison.Ranking[b]]
+		sa, sb := ma.MinAdjustedP() < ComparisonAlpha, mb.MinAdjustedP() < ComparisonAlpha
+		if sa != sb {
+			return sa
+		}
+		return ma.Score > mb.Score
+	})
+	return comparison
+}
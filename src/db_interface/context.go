@@ -0,0 +1,45 @@
+package db_interface
+
+import (
+	"context"
+	"time"
+
+	"github.com/apache/iotdb-client-go/v2/client"
+)
+
+// checkContext reports whether ctx was cancelled or ran past its deadline. Row loops call it
+// once per row, so an abandoned request stops scanning and its dataset is closed early.
+func checkContext(ctx context.Context, op string, deviceId string) error {
+	select {
+	case <-ctx.Done():
+		return wrapError(op, deviceId, ctx.Err())
+	default:
+		return nil
+	}
+}
+
+// queryTimeout shortens the IoTDB query timeout so that a query never outlives ctx.
+func queryTimeout(ctx context.Context, timeout int64) int64 {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return timeout
+	}
+	remaining := time.Until(deadline).Milliseconds()
+	if remaining < 1 {
+		remaining = 1
+	}
+	if timeout <= 0 || remaining < timeout {
+		return remaining
+	}
+	return timeout
+}
+
+// executeQuery runs sql unless ctx is already done. The client cannot interrupt a running
+// statement, so the query timeout is capped by the deadline of ctx instead.
+func executeQuery(ctx context.Context, session client.Session, sql string, timeout int64) (*client.SessionDataSet, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	timeout = queryTimeout(ctx, timeout)
+	return session.ExecuteQueryStatement(sql, &timeout)
+}
@@ -0,0 +1,150 @@
+package db_interface
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/apache/iotdb-client-go/v2/client"
+	"github.com/apache/thrift/lib/go/thrift"
+)
+
+// Errors returned by this package wrap one of these sentinels whenever the cause is known,
+// so callers can branch on them with errors.Is.
+var (
+	ErrConnectionLost      = errors.New("connection to IoTDB lost")
+	ErrDeviceNotFound      = errors.New("device not found")
+	ErrMeasurementNotFound = errors.New("measurement not found")
+	ErrTypeUnsupported     = errors.New("data type not supported")
+	ErrTimeout             = errors.New("IoTDB request timed out")
+	ErrInvalidQuery        = errors.New("invalid query")
+	ErrCanceled            = errors.New("request canceled")
+)
+
+// IoTDB status codes that map onto the sentinels above
+const (
+	statusPathNotExist  int32 = 508
+	statusSQLParseError int32 = 700
+	statusSemanticError int32 = 701
+)
+
+// StatusError is a non-success status reported by IoTDB.
+type StatusError struct {
+	Code    int32
+	Message string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("IoTDB status %d: %s", e.Code, e.Message)
+}
+
+// Is lets errors.Is match a StatusError against the sentinel describing the same failure.
+func (e *StatusError) Is(target error) bool {
+	switch target {
+	case ErrDeviceNotFound:
+		return e.Code == statusPathNotExist
+	case ErrInvalidQuery:
+		return e.Code == statusSQLParseError || e.Code == statusSemanticError
+	case ErrTimeout:
+		message := strings.ToLower(e.Message)
+		return strings.Contains(message, "timeout") || strings.Contains(message, "time out")
+	}
+	return false
+}
+
+// QueryError records the operation and device a failure belongs to.
+type QueryError struct {
+	Op     string
+	Device string
+	Err    error
+}
+
+func (e *QueryError) Error() string {
+	if e.Device == "" {
+		return e.Op + ": " + e.Err.Error()
+	}
+	return e.Op + " " + e.Device + ": " + e.Err.Error()
+}
+
+func (e *QueryError) Unwrap() error {
+	return e.Err
+}
+
+// wrapError classifies err and annotates it with the failing operation.
+func wrapError(op string, deviceId string, err error) error {
+	if err == nil {
+		return nil
+	}
+	var queryErr *QueryError
+	if errors.As(err, &queryErr) {
+		return err
+	}
+	return &QueryError{Op: op, Device: deviceId, Err: classifyError(err)}
+}
+
+func invalidQueryf(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", ErrInvalidQuery, fmt.Sprintf(format, args...))
+}
+
+func unsupportedTypef(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", ErrTypeUnsupported, fmt.Sprintf(format, args...))
+}
+
+// client.VerifySuccess formats status failures as plain strings, so the code is recovered from the text
+var statusMessagePattern = regexp.MustCompile(`^error code: (\d+)(?:, message: (.*))?$`)
+
+func classifyError(err error) error {
+	for _, sentinel := range []error{ErrConnectionLost, ErrDeviceNotFound, ErrMeasurementNotFound, ErrTypeUnsupported, ErrTimeout, ErrInvalidQuery, ErrCanceled} {
+		if errors.Is(err, sentinel) {
+			return err
+		}
+	}
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return err
+	}
+
+	if errors.Is(err, context.Canceled) {
+		return fmt.Errorf("%w: %w", ErrCanceled, err)
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return fmt.Errorf("%w: %w", ErrTimeout, err)
+	}
+
+	if match := statusMessagePattern.FindStringSubmatch(err.Error()); match != nil {
+		code, _ := strconv.ParseInt(match[1], 10, 32)
+		return &StatusError{Code: int32(code), Message: match[2]}
+	}
+	var batchErr *client.BatchError
+	if errors.As(err, &batchErr) {
+		return &StatusError{Code: client.MultipleError, Message: batchErr.Error()}
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return fmt.Errorf("%w: %w", ErrTimeout, err)
+	}
+	var transportErr thrift.TTransportException
+	if errors.As(err, &transportErr) {
+		if transportErr.TypeId() == thrift.TIMED_OUT {
+			return fmt.Errorf("%w: %w", ErrTimeout, err)
+		}
+		return fmt.Errorf("%w: %w", ErrConnectionLost, err)
+	}
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.EPIPE) {
+		return fmt.Errorf("%w: %w", ErrConnectionLost, err)
+	}
+	var opErr *net.OpError
+	if errors.As(err, &opErr) {
+		return fmt.Errorf("%w: %w", ErrConnectionLost, err)
+	}
+
+	return err
+}
@@ -0,0 +1,207 @@
+package db_interface
+
+import (
+	"bdgp2025/src/utils/filter"
+	"bdgp2025/src/utils/sketch"
+	"bdgp2025/src/utils/stats"
+	"context"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/apache/iotdb-client-go/v2/client"
+)
+
+const (
+	associationSampleSize = 10000 // Rows kept for the mutual information estimators
+	maxTargetClasses      = 100   // A target with more distinct values is not categorical
+	mutualInformationBins = 16
+	ksgNeighbours         = 3
+)
+
+// PointBiserial is the correlation of a measurement with a binary target coded 0 for the
+// first class and 1 for the second
+type PointBiserial struct {
+	R      float64 `json:"r"`
+	PValue float64 `json:"p_value"`
+}
+
+// FeatureRelevance holds the association of one measurement with the target. The ANOVA F
+// and point-biserial correlation use the exact moments of every row, the mutual
+// information estimates (in bits) a uniform sample of rows.
+type FeatureRelevance struct {
+	Measurement   string            `json:"measurement"`
+	N             int64             `json:"n"` // Rows in which both the measurement and the target are present
+	ANOVA         *stats.TestResult `json:"anova,omitempty"`
+	PointBiserial *PointBiserial    `json:"point_biserial,omitempty"` // Only for a target with two classes
+	MIBinned      float64           `json:"mi_binned"`
+	MIKSG         float64           `json:"mi_ksg"`
+}
+
+// AssociationResult ranks the numeric measurements by their relevance to a categorical
+// target, by the KSG mutual information, which captures any kind of dependence, and then by
+// the F ratio
+type AssociationResult struct {
+	Target      string             `json:"target"`
+	Classes     []string           `json:"classes"`      // Sorted by value
+	ClassCounts []int64            `json:"class_counts"` // Rows per class
+	SampleSize  int                `json:"sample_size"`  // Rows behind the mutual information
+	Features    []FeatureRelevance `json:"features"`     // Most relevant first
+}
+
+// associationRow is a sampled row: the class index in the order classes were first seen,
+// and the values of the compared columns with NaN for nulls
+type associationRow struct {
+	class  int
+	values []float64
+}
+
+// GetAssociationResult measures how every numeric measurement of deviceId relates to the
+// categorical column target. Rows in which the target is null are left out.
+func GetAssociationResult(ctx context.Context, session client.Session, deviceId string, target string, filterExpr *filter.Expression, timeout int64) (AssociationResult, error) {
+	columnNames, columnTypes, errMetadata := FetchMetadata(ctx, session, deviceId, timeout)
+	if errMetadata != nil {
+		return AssociationResult{}, errMetadata
+	}
+	targetIndex, errFind := findMeasurement(columnNames, deviceId, target)
+	if errFind != nil {
+		return AssociationResult{}, errFind
+	}
+	var columns []int
+	for _, i := range NumericColumnIndices(columnTypes) {
+		if i != targetIndex {
+			columns = append(columns, i)
+		}
+	}
+
+	sql, errBuild := SelectQuery{Device: deviceId, Where: filterExpr}.Build()
+	if errBuild != nil {
+		return AssociationResult{}, errBuild
+	}
+	ds, err := executeQuery(ctx, session, sql, timeout)
+	if err != nil {
+		return AssociationResult{}, wrapError("query", deviceId, err)
+	}
+	defer ds.Close()
+
+	classOf := make(map[string]int)
+	var keys []groupKey
+	var moments [][]Moments // Per class, then per compared column
+	var classCounts []int64
+	rows := sketch.NewReservoir[associationRow](associationSampleSize)
+	var next bool
+	var errNext error
+	for next, errNext = ds.Next(); errNext == nil && next; next, errNext = ds.Next() {
+		if errCtx := checkContext(ctx, "scan", deviceId); errCtx != nil {
+			return AssociationResult{}, errCtx
+		}
+		key, err := readGroupKey(ds, columnTypes[targetIndex], int32(targetIndex+1), 0) // For Get***ByIndex(), index 1 is timestamp
+		if err != nil {
+			return AssociationResult{}, wrapError("read "+columnNames[targetIndex]+" of", deviceId, err)
+		}
+		if key.label == NullGroup {
+			continue // Rows without a target cannot be attributed to a class
+		}
+		class, exists := classOf[key.label]
+		if !exists {
+			if len(keys) == maxTargetClasses {
+				return AssociationResult{}, invalidQueryf("%s has more than %d distinct values, the target must be categorical", target, maxTargetClasses)
+			}
+			class = len(keys)
+			classOf[key.label] = class
+			keys = append(keys, key)
+			moments = append(moments, make([]Moments, len(columns)))
+			classCounts = append(classCounts, 0)
+		}
+		classCounts[class]++
+
+		row := associationRow{class: class, values: make([]float64, len(columns))}
+		for k, i := range columns {
+			data, isNull, err := fetchNullableData(ds, columnTypes[i], int32(i+1)) // For Get***ByIndex(), index 1 is timestamp
+			if err != nil {
+				return AssociationResult{}, wrapError("read "+columnNames[i]+" of", deviceId, err)
+			}
+			row.values[k] = math.NaN()
+			if !isNull {
+				row.values[k] = data
+				moments[class][k].Add(data)
+			}
+		}
+		rows.Add(row)
+	}
+	if errNext != nil {
+		return AssociationResult{}, wrapError("scan", deviceId, errNext)
+	}
+	if len(keys) < 2 {
+		return AssociationResult{}, invalidQueryf("%s needs at least two classes, found %d", target, len(keys))
+	}
+
+	// Renumber the classes in sorted order
+	firstSeen := make([]groupKey, len(keys))
+	copy(firstSeen, keys)
+	sortGroupKeys(keys)
+	sortedIndex := make([]int, len(keys))
+	for k, key := range keys {
+		sortedIndex[classOf[key.label]] = k
+	}
+
+	device, errPath := ParseDevicePath(deviceId)
+	if errPath != nil {
+		return AssociationResult{}, errPath
+	}
+	result := AssociationResult{
+		Target:      target,
+		Classes:     make([]string, len(keys)),
+		ClassCounts: make([]int64, len(keys)),
+		SampleSize:  len(rows.Values()),
+		Features:    make([]FeatureRelevance, 0, len(columns)),
+	}
+	for c, key := range firstSeen {
+		result.Classes[sortedIndex[c]] = key.label
+		result.ClassCounts[sortedIndex[c]] = classCounts[c]
+	}
+
+	for k, i := range columns {
+		feature := FeatureRelevance{Measurement: strings.TrimPrefix(columnNames[i], device.String()+".")}
+		summaries := make([]stats.GroupSummary, len(keys))
+		var total Moments
+		for c := range firstSeen {
+			m := moments[c][k]
+			summaries[sortedIndex[c]] = stats.GroupSummary{Count: m.Count, Mean: m.Mean, Variance: m.Variance()}
+			total.Merge(m)
+		}
+		feature.N = total.Count
+		if anova, ok := stats.OneWayANOVA(summaries); ok {
+			feature.ANOVA = &anova
+		}
+		if len(keys) == 2 && total.Count > 2 && total.M2 > 0 && summaries[0].Count > 0 && summaries[1].Count > 0 {
+			// r = (M1 - M0) / s · √(n0 n1) / n with the population standard deviation s
+			n := float64(total.Count)
+			s := math.Sqrt(total.M2 / n)
+			r := (summaries[1].Mean - summaries[0].Mean) / s * math.Sqrt(float64(summaries[0].Count)*float64(summaries[1].Count)) / n
+			test := stats.TestCorrelation(stats.CorrelationPearson, r, total.Count, CorrelationConfidence)
+			feature.PointBiserial = &PointBiserial{R: r, PValue: test.PValue}
+		}
+
+		values := make([]float64, 0, len(rows.Values()))
+		labels := make([]int, 0, len(rows.Values()))
+		for _, row := range rows.Values() {
+			if !math.IsNaN(row.values[k]) {
+				values = append(values, row.values[k])
+				labels = append(labels, sortedIndex[row.class])
+			}
+		}
+		feature.MIBinned = stats.BinnedMutualInformation(values, labels, len(keys), mutualInformationBins)
+		feature.MIKSG = stats.KSGMutualInformation(values, labels, ksgNeighbours)
+		result.Features = append(result.Features, feature)
+	}
+
+	sort.SliceStable(result.Features, func(a, b int) bool {
+		fa, fb := result.Features[a], result.Features[b]
+		if fa.MIKSG != fb.MIKSG {
+			return fa.MIKSG > fb.MIKSG
+		}
+		return fa.ANOVA != nil && (fb.ANOVA == nil || fa.ANOVA.Statistic > fb.ANOVA.Statistic)
+	})
+	return result, nil
+}
@@ -1,109 +1,409 @@
 package db_interface
 
 import (
-	"log"
+	"bdgp2025/src/utils/filter"
+	"bdgp2025/src/utils/sketch"
+	"bdgp2025/src/utils/stats"
+	"context"
 	"math"
+	"slices"
 
 	"github.com/apache/iotdb-client-go/v2/client"
 )
 
+// CorrelationOptions selects the correlations GetCorrelationResult computes
+type CorrelationOptions struct {
+	Methods []string // See stats.CorrelationMethods; empty means Pearson only
+	Partial bool     // Also compute the partial correlation matrix, implied by Controls
+	// Controls names the measurements held fixed by the partial correlations; when empty
+	// every pair is conditioned on all other columns
+	Controls []string
+}
+
 type CorrelationResult struct {
-	PearsonCorrelation [][]float64
+	Columns             []string                    // Full names of the numeric columns the matrices are indexed by
+	Methods             []string                    // The methods computed, in the order of stats.CorrelationMethods
+	PearsonCorrelation  [][]float64                 // Indexed like Columns
+	SpearmanCorrelation [][]float64                 // Indexed like PearsonCorrelation, nil unless requested
+	KendallCorrelation  [][]float64                 // Tau-b, indexed like PearsonCorrelation, nil unless requested
+	Intervals           [][]stats.BootstrapInterval // Bootstrap intervals of Pearson's r indexed like PearsonCorrelation, nil when disabled
+	Counts              [][]int64                   // Rows in which both columns are present, indexed like PearsonCorrelation
+	// Tests holds the significance of every coefficient per method, indexed like
+	// PearsonCorrelation. P-values are Benjamini-Hochberg adjusted across the pairs.
+	Tests map[string][][]stats.CorrelationTest
+	// PartialCorrelation holds the Pearson correlation of every pair with Controls, or all
+	// other columns, held fixed, indexed like PearsonCorrelation. Rows and columns of the
+	// controls and of constant columns are 0. Nil unless requested.
+	PartialCorrelation [][]float64
+	Controls           []string                  // Full names of the controls, empty when conditioning on all other columns
+	PartialTests       [][]stats.CorrelationTest // Significance of PartialCorrelation with the degrees of freedom reduced by the controls
+}
+
+// CorrelationConfidence is the level of the Fisher-z intervals of CorrelationResult.Tests
+const CorrelationConfidence = 0.95
+
+// Matrix returns the correlation matrix of method, nil when it was not computed
+func (r CorrelationResult) Matrix(method string) [][]float64 {
+	switch method {
+	case stats.CorrelationPearson:
+		return r.PearsonCorrelation
+	case stats.CorrelationSpearman:
+		return r.SpearmanCorrelation
+	case stats.CorrelationKendall:
+		return r.KendallCorrelation
+	}
+	return nil
+}
+
+// correlationAccumulator holds the co-moments of every pair of columns. Only the upper
+// triangle is filled; a pair skips the rows in which either of its columns is null.
+// A uniform sample of whole rows is kept for bootstrapping, and every value when a rank
+// correlation needs them.
+type correlationAccumulator struct {
+	pairs   [][]CoMoments
+	moments []Moments                    // Non-null values per column
+	rows    *sketch.Reservoir[[]float64] // NaN marks a null
+	columns [][]float64                  // Every value per column, NaN marks a null; nil unless ranks are needed
+}
+
+func newCorrelationAccumulator(n int, keepValues bool) *correlationAccumulator {
+	acc := &correlationAccumulator{
+		pairs:   make([][]CoMoments, n),
+		moments: make([]Moments, n),
+		rows:    sketch.NewReservoir[[]float64](correlationSampleSize),
+	}
+	for i := range acc.pairs {
+		acc.pairs[i] = make([]CoMoments, n)
+	}
+	if keepValues {
+		acc.columns = make([][]float64, n)
+	}
+	return acc
+}
+
+func (acc *correlationAccumulator) add(values []float64, present []bool) {
+	row := make([]float64, len(values))
+	for i := range values {
+		row[i] = math.NaN()
+		if !present[i] {
+			continue
+		}
+		row[i] = values[i]
+		acc.moments[i].Add(values[i])
+		for j := i + 1; j < len(values); j++ {
+			if present[j] {
+				acc.pairs[i][j].Add(values[i], values[j])
+			}
+		}
+	}
+	acc.rows.Add(row)
+	for i := range acc.columns {
+		acc.columns[i] = append(acc.columns[i], row[i])
+	}
 }
 
-func GetCorrelationResult(session client.Session, deviceId string, timeout int64) (result CorrelationResult, errRnt error) {
-	columnNames, columnTypes, errMetadata := FetchMetadata(session, deviceId, timeout)
+func (acc *correlationAccumulator) merge(other *correlationAccumulator) {
+	for i := range acc.pairs {
+		acc.moments[i].Merge(other.moments[i])
+		for j := i + 1; j < len(acc.pairs); j++ {
+			acc.pairs[i][j].Merge(other.pairs[i][j])
+		}
+	}
+	acc.rows.Merge(other.rows)
+	for i := range acc.columns {
+		acc.columns[i] = append(acc.columns[i], other.columns[i]...)
+	}
+}
+
+// GetCorrelationResult computes the Pearson correlation of every pair of numeric columns of deviceId
+// and, unless bootstrap.Resamples is 0, bootstrap confidence intervals for each of them.
+// Spearman's rho and Kendall's tau-b are computed from every value when options ask for them,
+// which holds the whole device in memory for the duration of the call. The partial correlation
+// matrix comes from the inverse of the pairwise covariance matrix when options ask for it.
+func GetCorrelationResult(ctx context.Context, session client.Session, deviceId string, filterExpr *filter.Expression, timeout int64, scan ScanOptions, bootstrap stats.BootstrapConfig, options CorrelationOptions) (result CorrelationResult, errRnt error) {
+	methods := options.Methods
+	if len(methods) == 0 {
+		methods = []string{stats.CorrelationPearson}
+	}
+	keepValues := false
+	for _, method := range methods {
+		switch method {
+		case stats.CorrelationPearson:
+		case stats.CorrelationSpearman, stats.CorrelationKendall:
+			keepValues = true
+		default:
+			return CorrelationResult{}, invalidQueryf("unknown correlation method %q", method)
+		}
+	}
+
+	columnNames, columnTypes, errMetadata := FetchMetadata(ctx, session, deviceId, timeout)
 	if errMetadata != nil {
-		log.Fatal(errMetadata)
 		return CorrelationResult{}, errMetadata
 	}
+	columns := NumericColumnIndices(columnTypes)
+	n := len(columns)
+	result.Columns = make([]string, n)
+	for k, i := range columns {
+		result.Columns[k] = columnNames[i]
+	}
 
-	columnLength := int32(len(columnNames))
-	n := columnLength - 1 // Exclude timestamp column
+	var controls []int // Indexed like PearsonCorrelation
+	for _, name := range options.Controls {
+		i, errFind := findMeasurement(columnNames, deviceId, name)
+		if errFind != nil {
+			return CorrelationResult{}, errFind
+		}
+		k := slices.Index(columns, i)
+		if k < 0 {
+			return CorrelationResult{}, unsupportedTypef("%s is %s, a control must be numeric", columnNames[i], columnTypes[i])
+		}
+		if !slices.Contains(controls, k) {
+			controls = append(controls, k)
+			result.Controls = append(result.Controls, columnNames[i])
+		}
+	}
+	if len(controls) > 0 && len(controls) > n-2 {
+		return CorrelationResult{}, invalidQueryf("%d controls leave no pair of columns to correlate", len(controls))
+	}
 
+	partitions, errPlan := planPartitions(ctx, session, deviceId, scan.Partitions, timeout)
+	if errPlan != nil {
+		return CorrelationResult{}, errPlan
+	}
+	partials := make([]*correlationAccumulator, len(partitions))
+	errScan := scanPartitions(ctx, session, scan, partitions, func(ctx context.Context, session client.Session, index int, partition timePartition) error {
+		query := SelectQuery{Device: deviceId, Where: filterExpr, StartTime: partition.start, EndTime: partition.end}
+		acc, err := scanCorrelationPartition(ctx, session, query, timeout, columnNames, columnTypes, columns, keepValues)
+		partials[index] = acc
+		return err
+	})
+	if errScan != nil {
+		return CorrelationResult{}, errScan
+	}
+	acc := partials[0]
+	for _, partial := range partials[1:] {
+		acc.merge(partial)
+	}
+
+	// Calculate Pearson correlation coefficients
 	result.PearsonCorrelation = make([][]float64, n)
 	for i := range result.PearsonCorrelation {
 		result.PearsonCorrelation[i] = make([]float64, n)
+		result.PearsonCorrelation[i][i] = 1.0
 	}
-
-	sql := "select * from " + deviceId
-	if ds, err := session.ExecuteQueryStatement(sql, &timeout); err == nil {
-		defer ds.Close()
-
-		// Initialize accumulators for each column pair
-		sumX := make([]float64, n)
-		sumY := make([]float64, n)
-		sumXY := make([][]float64, n)
-		sumX2 := make([]float64, n)
-		sumY2 := make([]float64, n)
-		count := 0
-
-		for i := range sumXY {
-			sumXY[i] = make([]float64, n)
+	for i := 0; i < n; i++ {
+		for j := i + 1; j < n; j++ {
+			r := acc.pairs[i][j].Correlation()
+			result.PearsonCorrelation[i][j] = r
+			result.PearsonCorrelation[j][i] = r
 		}
+	}
+	result.Methods = methods
+	if slices.Contains(methods, stats.CorrelationPearson) {
+		result.Intervals = bootstrapCorrelation(acc.rows.Values(), acc.rows.Count(), n, bootstrap)
+	}
+	if slices.Contains(methods, stats.CorrelationSpearman) {
+		if result.SpearmanCorrelation, errRnt = pairwiseCorrelation(ctx, deviceId, acc.columns, stats.Spearman); errRnt != nil {
+			return CorrelationResult{}, errRnt
+		}
+	}
+	if slices.Contains(methods, stats.CorrelationKendall) {
+		if result.KendallCorrelation, errRnt = pairwiseCorrelation(ctx, deviceId, acc.columns, stats.KendallTauB); errRnt != nil {
+			return CorrelationResult{}, errRnt
+		}
+	}
 
-		// Process each row
-		for next, err := ds.Next(); err == nil && next; next, err = ds.Next() {
-			count++
-			values := make([]float64, n)
-
-			// Read values for all columns (excluding timestamp)
-			for i := int32(1); i < columnLength; i++ {
-				index := i + 1
-				columnType := columnTypes[i]
-				data, err := fetchDataByColumnType(ds, columnType, index)
-				if err != nil {
-					log.Fatal(err)
-				}
-				values[i-1] = data
+	result.Counts = make([][]int64, n)
+	for i := range result.Counts {
+		result.Counts[i] = make([]int64, n)
+	}
+	for i := 0; i < n; i++ {
+		for j := i; j < n; j++ {
+			count := acc.pairs[i][j].Count
+			if i == j {
+				count = acc.moments[i].Count
 			}
+			result.Counts[i][j] = count
+			result.Counts[j][i] = count
+		}
+	}
+	result.Tests = make(map[string][][]stats.CorrelationTest, len(methods))
+	for _, method := range methods {
+		result.Tests[method] = testCorrelations(method, result.Matrix(method), result.Counts)
+	}
+	if options.Partial || len(controls) > 0 {
+		if errRnt = result.partialCorrelation(acc, controls); errRnt != nil {
+			return CorrelationResult{}, errRnt
+		}
+	}
+	return result, nil
+}
 
-			// Update accumulators for all column pairs
-			for i := 0; i < int(n); i++ {
-				x := values[i]
-				sumX[i] += x
-				sumX2[i] += x * x
-
-				for j := 0; j < int(n); j++ {
-					if i == j {
-						continue
-					}
-					y := values[j]
-					sumXY[i][j] += x * y
-				}
+// partialCorrelation fills PartialCorrelation and PartialTests from the covariance matrix of
+// acc. Constant columns carry no information and are left out of the inversion.
+func (r *CorrelationResult) partialCorrelation(acc *correlationAccumulator, controls []int) error {
+	n := len(acc.moments)
+	var varying []int // Columns entering the covariance matrix
+	for i := 0; i < n; i++ {
+		if acc.moments[i].Count > 1 && acc.moments[i].Variance() > 0 {
+			varying = append(varying, i)
+		}
+	}
+	position := make(map[int]int, len(varying))
+	cov := make([][]float64, len(varying))
+	for a, i := range varying {
+		position[i] = a
+		cov[a] = make([]float64, len(varying))
+	}
+	for a, i := range varying {
+		cov[a][a] = acc.moments[i].Variance()
+		for b := a + 1; b < len(varying); b++ {
+			pair := acc.pairs[i][varying[b]]
+			if pair.Count > 1 {
+				cov[a][b] = pair.CXY / float64(pair.Count-1)
+				cov[b][a] = cov[a][b]
 			}
+		}
+	}
 
-			// Update sumY and sumY2 (same as sumX and sumX2 for symmetric matrix)
-			for j := 0; j < int(n); j++ {
-				y := values[j]
-				sumY[j] += y
-				sumY2[j] += y * y
+	var partial [][]float64
+	var ok bool
+	conditioned := len(varying) - 2 // Variables held fixed per pair
+	if len(controls) == 0 {
+		partial, ok = stats.PartialCorrelation(cov)
+	} else {
+		var held []int
+		for _, c := range controls {
+			if a, exists := position[c]; exists {
+				held = append(held, a)
 			}
 		}
+		conditioned = len(held)
+		partial, ok = stats.PartialCorrelationGiven(cov, held)
+	}
+	if !ok {
+		return invalidQueryf("covariance matrix is singular, a measurement is a linear combination of others")
+	}
 
-		// Calculate Pearson correlation coefficients
-		for i := 0; i < int(n); i++ {
-			for j := 0; j < int(n); j++ {
-				if i == j {
-					result.PearsonCorrelation[i][j] = 1.0
-					continue
-				}
+	r.PartialCorrelation = make([][]float64, n)
+	counts := make([][]int64, n) // Pairs less the controls, which gives the degrees of freedom of the test
+	for i := range r.PartialCorrelation {
+		r.PartialCorrelation[i] = make([]float64, n)
+		r.PartialCorrelation[i][i] = 1
+		counts[i] = make([]int64, n)
+		for j := range counts[i] {
+			counts[i][j] = max(r.Counts[i][j]-int64(max(conditioned, 0)), 0)
+		}
+	}
+	for a, i := range varying {
+		for b, j := range varying {
+			if i != j {
+				r.PartialCorrelation[i][j] = partial[a][b]
+			}
+		}
+	}
+	r.PartialTests = testCorrelations(stats.CorrelationPearson, r.PartialCorrelation, counts)
+	return nil
+}
 
-				numerator := float64(count)*sumXY[i][j] - sumX[i]*sumY[j]
-				denomX := math.Sqrt(float64(count)*sumX2[i] - sumX[i]*sumX[i])
-				denomY := math.Sqrt(float64(count)*sumY2[j] - sumY[j]*sumY[j])
+// testCorrelations tests every coefficient of matrix and adjusts the p-values of the pairs
+// for multiple testing. The diagonal is left at the zero value.
+func testCorrelations(method string, matrix [][]float64, counts [][]int64) [][]stats.CorrelationTest {
+	n := len(matrix)
+	tests := make([][]stats.CorrelationTest, n)
+	for i := range tests {
+		tests[i] = make([]stats.CorrelationTest, n)
+	}
+	var pValues []float64
+	for i := 0; i < n; i++ {
+		for j := i + 1; j < n; j++ {
+			tests[i][j] = stats.TestCorrelation(method, matrix[i][j], counts[i][j], CorrelationConfidence)
+			pValues = append(pValues, tests[i][j].PValue)
+		}
+	}
+	adjusted := stats.BenjaminiHochberg(pValues)
+	k := 0
+	for i := 0; i < n; i++ {
+		for j := i + 1; j < n; j++ {
+			tests[i][j].AdjustedP = adjusted[k]
+			tests[j][i] = tests[i][j]
+			k++
+		}
+	}
+	return tests
+}
 
-				if denomX == 0 || denomY == 0 {
-					result.PearsonCorrelation[i][j] = 0
-				} else {
-					result.PearsonCorrelation[i][j] = numerator / (denomX * denomY)
+// pairwiseCorrelation applies correlation to every pair of columns over the rows in which
+// both are present
+func pairwiseCorrelation(ctx context.Context, deviceId string, columns [][]float64, correlation func(x []float64, y []float64) float64) ([][]float64, error) {
+	n := len(columns)
+	matrix := make([][]float64, n)
+	for i := range matrix {
+		matrix[i] = make([]float64, n)
+		matrix[i][i] = 1.0
+	}
+	for i := 0; i < n; i++ {
+		for j := i + 1; j < n; j++ {
+			if errCtx := checkContext(ctx, "correlate", deviceId); errCtx != nil {
+				return nil, errCtx
+			}
+			x, y := make([]float64, 0, len(columns[i])), make([]float64, 0, len(columns[j]))
+			for k := range columns[i] {
+				if !math.IsNaN(columns[i][k]) && !math.IsNaN(columns[j][k]) {
+					x = append(x, columns[i][k])
+					y = append(y, columns[j][k])
 				}
 			}
+			r := correlation(x, y)
+			matrix[i][j] = r
+			matrix[j][i] = r
 		}
+	}
+	return matrix, nil
+}
 
-	} else {
-		log.Fatal(err)
-		errRnt = err
+// scanCorrelationPartition runs query and accumulates the co-moments of the given columns of
+// its rows
+func scanCorrelationPartition(ctx context.Context, session client.Session, query SelectQuery, timeout int64,
+	columnNames []string, columnTypes []string, columns []int, keepValues bool) (*correlationAccumulator, error) {
+	deviceId := query.Device
+	sql, errBuild := query.Build()
+	if errBuild != nil {
+		return nil, errBuild
+	}
+
+	ds, err := executeQuery(ctx, session, sql, timeout)
+	if err != nil {
+		return nil, wrapError("query", deviceId, err)
+	}
+	defer ds.Close()
+
+	n := len(columns)
+	acc := newCorrelationAccumulator(n, keepValues)
+	values := make([]float64, n)
+	present := make([]bool, n)
+
+	// Process each row
+	var next bool
+	var errNext error
+	for next, errNext = ds.Next(); errNext == nil && next; next, errNext = ds.Next() {
+		if errCtx := checkContext(ctx, "scan", deviceId); errCtx != nil {
+			return nil, errCtx
+		}
+
+		// Read values for all numeric columns
+		for k, i := range columns {
+			data, isNull, err := fetchNullableData(ds, columnTypes[i], int32(i+1)) // For Get***ByIndex(), index 1 is timestamp
+			if err != nil {
+				return nil, wrapError("read "+columnNames[i]+" of", deviceId, err)
+			}
+			values[k] = data
+			present[k] = !isNull
+		}
+		acc.add(values, present)
+	}
+	if errNext != nil {
+		return nil, wrapError("scan", deviceId, errNext)
 	}
-	return
+	return acc, nil
 }
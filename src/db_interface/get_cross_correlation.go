@@ -0,0 +1,97 @@
+package db_interface
+
+import (
+	"bdgp2025/src/utils/filter"
+	"bdgp2025/src/utils/xcorr"
+	"context"
+	"slices"
+
+	"github.com/apache/iotdb-client-go/v2/client"
+)
+
+// CrossCorrelationResult is the cross-correlation function of measurements X and Y
+type CrossCorrelationResult struct {
+	X string `json:"x"`
+	Y string `json:"y"`
+	*xcorr.Result
+	Step int64 `json:"step_ms"` // Median time between consecutive rows, converting lags to durations
+}
+
+// GetCrossCorrelationResult computes the cross-correlation of measurements x and y of deviceId
+// for lags up to maxLag rows, over the rows in time order in which both are present. Both
+// series are held in memory for the duration of the call.
+func GetCrossCorrelationResult(ctx context.Context, session client.Session, deviceId string, x string, y string, maxLag int, filterExpr *filter.Expression, timeout int64) (CrossCorrelationResult, error) {
+	columnNames, columnTypes, errMetadata := FetchMetadata(ctx, session, deviceId, timeout)
+	if errMetadata != nil {
+		return CrossCorrelationResult{}, errMetadata
+	}
+	xIndex, errFind := findMeasurement(columnNames, deviceId, x)
+	if errFind != nil {
+		return CrossCorrelationResult{}, errFind
+	}
+	yIndex, errFind := findMeasurement(columnNames, deviceId, y)
+	if errFind != nil {
+		return CrossCorrelationResult{}, errFind
+	}
+	for _, i := range []int{xIndex, yIndex} {
+		if !isNumericType(columnTypes[i]) {
+			return CrossCorrelationResult{}, unsupportedTypef("%s is %s, cross-correlation needs a numeric measurement", columnNames[i], columnTypes[i])
+		}
+	}
+
+	sql, errBuild := SelectQuery{Device: deviceId, Where: filterExpr}.Build()
+	if errBuild != nil {
+		return CrossCorrelationResult{}, errBuild
+	}
+	ds, err := executeQuery(ctx, session, sql, timeout)
+	if err != nil {
+		return CrossCorrelationResult{}, wrapError("query", deviceId, err)
+	}
+	defer ds.Close()
+
+	var xs, ys []float64
+	var steps []int64
+	var last int64
+	var next bool
+	var errNext error
+	for next, errNext = ds.Next(); errNext == nil && next; next, errNext = ds.Next() {
+		if errCtx := checkContext(ctx, "scan", deviceId); errCtx != nil {
+			return CrossCorrelationResult{}, errCtx
+		}
+		xValue, xNull, err := fetchNullableData(ds, columnTypes[xIndex], int32(xIndex+1)) // For Get***ByIndex(), index 1 is timestamp
+		if err != nil {
+			return CrossCorrelationResult{}, wrapError("read "+columnNames[xIndex]+" of", deviceId, err)
+		}
+		yValue, yNull, err := fetchNullableData(ds, columnTypes[yIndex], int32(yIndex+1))
+		if err != nil {
+			return CrossCorrelationResult{}, wrapError("read "+columnNames[yIndex]+" of", deviceId, err)
+		}
+		if xNull || yNull {
+			continue
+		}
+		timestamp, err := ds.GetLongByIndex(1)
+		if err != nil {
+			return CrossCorrelationResult{}, wrapError("read timestamp of", deviceId, err)
+		}
+		if len(xs) > 0 {
+			steps = append(steps, timestamp-last)
+		}
+		last = timestamp
+		xs = append(xs, xValue)
+		ys = append(ys, yValue)
+	}
+	if errNext != nil {
+		return CrossCorrelationResult{}, wrapError("scan", deviceId, errNext)
+	}
+
+	result, errCompute := xcorr.Compute(xs, ys, maxLag)
+	if errCompute != nil {
+		return CrossCorrelationResult{}, invalidQueryf("cross-correlation of %s and %s: %v", x, y, errCompute)
+	}
+	var step int64
+	if len(steps) > 0 {
+		slices.Sort(steps)
+		step = steps[len(steps)/2]
+	}
+	return CrossCorrelationResult{X: x, Y: y, Result: result, Step: step}, nil
+}
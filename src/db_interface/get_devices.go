@@ -0,0 +1,243 @@
+package db_interface
+
+import (
+	"context"
+	"strings"
+
+	"github.com/apache/iotdb-client-go/v2/client"
+)
+
+type TimeseriesInfo struct {
+	Path           string `json:"path"`
+	Measurement    string `json:"measurement"`
+	Alias          string `json:"alias,omitempty"`
+	DataType       string `json:"data_type"`
+	Encoding       string `json:"encoding"`
+	Compression    string `json:"compression"`
+	RowCount       int64  `json:"row_count"`
+	FirstTimestamp *int64 `json:"first_timestamp"` // nil when the timeseries holds no data
+	LastTimestamp  *int64 `json:"last_timestamp"`
+}
+
+type DeviceInfo struct {
+	Path            string `json:"path"`
+	IsAligned       bool   `json:"is_aligned"`
+	TimeseriesCount int    `json:"timeseries_count"`
+	RowCount        int64  `json:"row_count"` // Largest row count among the device's timeseries
+	FirstTimestamp  *int64 `json:"first_timestamp"`
+	LastTimestamp   *int64 `json:"last_timestamp"`
+}
+
+type timeseriesExtent struct {
+	rowCount int64
+	first    *int64
+	last     *int64
+}
+
+// ListDevices wraps SHOW DEVICES and adds row counts and the time range of every device.
+func ListDevices(ctx context.Context, session client.Session, timeout int64) ([]DeviceInfo, error) {
+	devices, err := listDevicePaths(ctx, session, timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range devices {
+		extents, err := fetchTimeseriesExtents(ctx, session, devices[i].Path, timeout)
+		if err != nil {
+			return nil, err
+		}
+		devices[i].TimeseriesCount = len(extents)
+		for _, extent := range extents {
+			if extent.rowCount > devices[i].RowCount {
+				devices[i].RowCount = extent.rowCount
+			}
+			if extent.first != nil && (devices[i].FirstTimestamp == nil || *extent.first < *devices[i].FirstTimestamp) {
+				devices[i].FirstTimestamp = extent.first
+			}
+			if extent.last != nil && (devices[i].LastTimestamp == nil || *extent.last > *devices[i].LastTimestamp) {
+				devices[i].LastTimestamp = extent.last
+			}
+		}
+	}
+
+	return devices, nil
+}
+
+func listDevicePaths(ctx context.Context, session client.Session, timeout int64) ([]DeviceInfo, error) {
+	ds, err := executeQuery(ctx, session, "show devices", timeout)
+	if err != nil {
+		return nil, wrapError("list devices", "", err)
+	}
+	defer ds.Close()
+
+	devices := make([]DeviceInfo, 0)
+	columnIndex := columnIndexByName(ds.GetColumnNames())
+	var next bool
+	var errNext error
+	for next, errNext = ds.Next(); errNext == nil && next; next, errNext = ds.Next() {
+		if errCtx := checkContext(ctx, "list devices", ""); errCtx != nil {
+			return nil, errCtx
+		}
+		path, err := getStringByName(ds, columnIndex, "Device")
+		if err != nil {
+			return nil, wrapError("list devices", "", err)
+		}
+		isAligned, err := getStringByName(ds, columnIndex, "IsAligned")
+		if err != nil {
+			return nil, wrapError("list devices", "", err)
+		}
+		devices = append(devices, DeviceInfo{Path: path, IsAligned: strings.EqualFold(isAligned, "true")})
+	}
+	if errNext != nil {
+		return nil, wrapError("list devices", "", errNext)
+	}
+	return devices, nil
+}
+
+// ListTimeseries wraps SHOW TIMESERIES for the direct children of devicePath.
+func ListTimeseries(ctx context.Context, session client.Session, devicePath string, timeout int64) ([]TimeseriesInfo, error) {
+	device, err := ParseDevicePath(devicePath)
+	if err != nil {
+		return nil, err
+	}
+	sql, err := BuildShowTimeseries(devicePath)
+	if err != nil {
+		return nil, err
+	}
+	timeseries, err := listTimeseriesPaths(ctx, session, device, sql, timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	extents, err := fetchTimeseriesExtents(ctx, session, devicePath, timeout)
+	if err != nil {
+		return nil, err
+	}
+	for i := range timeseries {
+		if extent, ok := extents[timeseries[i].Path]; ok {
+			timeseries[i].RowCount = extent.rowCount
+			timeseries[i].FirstTimestamp = extent.first
+			timeseries[i].LastTimestamp = extent.last
+		}
+	}
+
+	return timeseries, nil
+}
+
+func listTimeseriesPaths(ctx context.Context, session client.Session, device DevicePath, sql string, timeout int64) ([]TimeseriesInfo, error) {
+	ds, err := executeQuery(ctx, session, sql, timeout)
+	if err != nil {
+		return nil, wrapError("list timeseries of", device.String(), err)
+	}
+	defer ds.Close()
+
+	timeseries := make([]TimeseriesInfo, 0)
+	columnIndex := columnIndexByName(ds.GetColumnNames())
+	var next bool
+	var errNext error
+	for next, errNext = ds.Next(); errNext == nil && next; next, errNext = ds.Next() {
+		if errCtx := checkContext(ctx, "list timeseries of", device.String()); errCtx != nil {
+			return nil, errCtx
+		}
+		info := TimeseriesInfo{}
+		fields := []struct {
+			name   string
+			target *string
+		}{
+			{"Timeseries", &info.Path},
+			{"Alias", &info.Alias},
+			{"DataType", &info.DataType},
+			{"Encoding", &info.Encoding},
+			{"Compression", &info.Compression},
+		}
+		for _, field := range fields {
+			value, err := getStringByName(ds, columnIndex, field.name)
+			if err != nil {
+				return nil, wrapError("list timeseries of", device.String(), err)
+			}
+			*field.target = value
+		}
+		info.Measurement = strings.TrimPrefix(info.Path, device.String()+".")
+		timeseries = append(timeseries, info)
+	}
+	if errNext != nil {
+		return nil, wrapError("list timeseries of", device.String(), errNext)
+	}
+	return timeseries, nil
+}
+
+// fetchTimeseriesExtents runs one aggregation query returning count, min_time and max_time
+// for every timeseries directly under devicePath, keyed by full timeseries path.
+func fetchTimeseriesExtents(ctx context.Context, session client.Session, devicePath string, timeout int64) (map[string]*timeseriesExtent, error) {
+	sql, err := SelectQuery{Device: devicePath, Aggregations: []string{"count", "min_time", "max_time"}}.Build()
+	if err != nil {
+		return nil, err
+	}
+	ds, err := executeQuery(ctx, session, sql, timeout)
+	if err != nil {
+		return nil, wrapError("count rows of", devicePath, err)
+	}
+	defer ds.Close()
+
+	extents := make(map[string]*timeseriesExtent)
+	columnNames := ds.GetColumnNames()
+	if next, err := ds.Next(); err != nil || !next {
+		return extents, wrapError("count rows of", devicePath, err)
+	}
+
+	for i, columnName := range columnNames {
+		open := strings.Index(columnName, "(")
+		if open < 0 || !strings.HasSuffix(columnName, ")") {
+			continue // Time column
+		}
+		aggregation := columnName[:open]
+		path := columnName[open+1 : len(columnName)-1]
+
+		index := int32(i + 1) // For Get***ByIndex(), index 1 is the first column
+		isNull, err := ds.IsNullByIndex(index)
+		if err != nil {
+			return nil, wrapError("count rows of", devicePath, err)
+		}
+		extent, ok := extents[path]
+		if !ok {
+			extent = &timeseriesExtent{}
+			extents[path] = extent
+		}
+		if isNull {
+			continue
+		}
+		value, err := ds.GetLongByIndex(index)
+		if err != nil {
+			return nil, wrapError("count rows of", devicePath, err)
+		}
+
+		switch aggregation {
+		case "count":
+			extent.rowCount = value
+		case "min_time":
+			extent.first = &value
+		case "max_time":
+			extent.last = &value
+		}
+	}
+
+	return extents, nil
+}
+
+func columnIndexByName(columnNames []string) map[string]int32 {
+	columnIndex := make(map[string]int32, len(columnNames))
+	for i, name := range columnNames {
+		columnIndex[name] = int32(i + 1)
+	}
+	return columnIndex
+}
+
+// getStringByName returns an empty string for columns the server did not send,
+// since the column set of SHOW statements differs between IoTDB versions.
+func getStringByName(ds *client.SessionDataSet, columnIndex map[string]int32, name string) (string, error) {
+	index, ok := columnIndex[name]
+	if !ok {
+		return "", nil
+	}
+	return ds.GetStringByIndex(index)
+}
@@ -0,0 +1,40 @@
+package db_interface
+
+import (
+	"bdgp2025/src/utils/filter"
+	"bdgp2025/src/utils/stats"
+	"context"
+
+	"github.com/apache/iotdb-client-go/v2/client"
+)
+
+// DistributionFitResult holds the candidate distributions fitted to every numeric column
+type DistributionFitResult struct {
+	ColumnNames    []string      // All columns of the device, index 0 is Time
+	NumericColumns []int         // Indices into ColumnNames that were fitted
+	Fits           [][]stats.Fit // Indexed like ColumnNames, best AIC first, empty when a column has too few distinct values
+}
+
+// Best returns the fit with the lowest AIC for column i
+func (r DistributionFitResult) Best(i int) (stats.Fit, bool) {
+	if len(r.Fits[i]) == 0 {
+		return stats.Fit{}, false
+	}
+	return r.Fits[i][0], true
+}
+
+// GetDistributionFitResult fits the candidates of stats.FitDistributions to every numeric
+// column by maximum likelihood. Columns are fitted on the uniform sample that also feeds the
+// normality tests, so a device of any size is fitted on at most a few thousand values per column.
+func GetDistributionFitResult(ctx context.Context, session client.Session, deviceId string, filterExpr *filter.Expression, timeout int64, scan ScanOptions) (DistributionFitResult, error) {
+	acc, columnNames, numericColumns, errScan := scanWholeDevice(ctx, session, deviceId, filterExpr, timeout, scan)
+	if errScan != nil {
+		return DistributionFitResult{}, errScan
+	}
+
+	fits := make([][]stats.Fit, len(columnNames))
+	for _, i := range numericColumns {
+		fits[i] = stats.FitDistributions(acc.columns[i].Sample())
+	}
+	return DistributionFitResult{ColumnNames: columnNames, NumericColumns: numericColumns, Fits: fits}, nil
+}
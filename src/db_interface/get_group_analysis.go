@@ -0,0 +1,320 @@
+package db_interface
+
+import (
+	"bdgp2025/src/utils/filter"
+	"bdgp2025/src/utils/sketch"
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+
+	"github.com/apache/iotdb-client-go/v2/client"
+)
+
+// NullGroup labels rows whose group column holds no value
+const NullGroup = "null"
+
+// GroupBy names the column rows are grouped by. INT32, INT64, BOOLEAN and TEXT columns are
+// grouped by value. A positive BinWidth groups a numeric column into bands of that width
+// instead, e.g. engine_rpm with BinWidth 500 yields [500, 1000), [1000, 1500), ...
+type GroupBy struct {
+	Column   string  `json:"column"`
+	BinWidth float64 `json:"bin_width,omitempty"`
+}
+
+type GroupAnalysisResult struct {
+	GroupBy        GroupBy
+	ColumnNames    []string                            // All columns of the device, index 0 is Time
+	NumericColumns []int                               // Indices into ColumnNames that statistics were computed for
+	Groups         []string                            // Group labels, sorted by value
+	Statistics     map[string]DetailedStatisticsResult // Keyed by group label
+}
+
+// groupAccumulator collects the statistics of one group, one accumulator per numeric column
+type groupAccumulator struct {
+	cnt     int
+	columns []*ColumnAccumulator // Indexed like the columns of the device, nil for skipped ones
+}
+
+func newGroupAccumulator(columnLength int32, numericColumns []int, quantiles sketch.Config) *groupAccumulator {
+	acc := &groupAccumulator{columns: make([]*ColumnAccumulator, columnLength)}
+	for _, i := range numericColumns {
+		acc.columns[i] = NewColumnAccumulator(quantiles)
+	}
+	return acc
+}
+
+// merge folds the rows of other, accumulated over a disjoint set of rows, into acc
+func (acc *groupAccumulator) merge(other *groupAccumulator) {
+	acc.cnt += other.cnt
+	for i, column := range acc.columns {
+		if column != nil {
+			column.Merge(other.columns[i])
+		}
+	}
+}
+
+func (acc *groupAccumulator) result(numericColumns []int, columnLength int32) DetailedStatisticsResult {
+	stats := DetailedStatisticsResult{
+		Cnt:           acc.cnt,
+		Sum:           make([]float64, columnLength),
+		Mean:          make([]float64, columnLength),
+		Variance:      make([]float64, columnLength),
+		StdDev:        make([]float64, columnLength),
+		Min:           make([]float64, columnLength),
+		Max:           make([]float64, columnLength),
+		Median:        make([]float64, columnLength),
+		Q1:            make([]float64, columnLength),
+		Q3:            make([]float64, columnLength),
+		IQR:           make([]float64, columnLength),
+		Skewness:      make([]float64, columnLength),
+		Kurtosis:      make([]float64, columnLength),
+		NullCount:     make([]int64, columnLength),
+		DistinctCount: make([]int64, columnLength),
+		DistinctExact: make([]bool, columnLength),
+		Mode:          make([]float64, columnLength),
+		ModeCount:     make([]int64, columnLength),
+		TopValues:     make([][]sketch.FrequentValue, columnLength),
+		Entropy:       make([]float64, columnLength),
+		Quantiles:     make([]*sketch.KLL, columnLength),
+		Samples:       make([][]float64, columnLength),
+	}
+
+	for _, i := range numericColumns {
+		summary := acc.columns[i].Summary()
+		stats.Sum[i] = summary.Sum
+		stats.Mean[i] = summary.Mean
+		stats.Variance[i] = summary.Variance
+		stats.StdDev[i] = summary.StdDev
+		stats.Min[i] = summary.Min
+		stats.Max[i] = summary.Max
+		stats.Median[i] = summary.Median
+		stats.Q1[i] = summary.Q1
+		stats.Q3[i] = summary.Q3
+		stats.IQR[i] = summary.IQR
+		stats.Skewness[i] = summary.Skewness
+		stats.Kurtosis[i] = summary.Kurtosis
+		stats.NullCount[i] = summary.NullCount
+		stats.DistinctCount[i] = summary.DistinctCount
+		stats.DistinctExact[i] = summary.DistinctExact
+		stats.Mode[i] = summary.Mode
+		stats.ModeCount[i] = summary.ModeCount
+		stats.TopValues[i] = summary.TopValues
+		stats.Entropy[i] = summary.Entropy
+		stats.Quantiles[i] = acc.columns[i].Quantiles()
+		stats.Samples[i] = acc.columns[i].Sample()
+	}
+	return stats
+}
+
+// groupKey is a group label together with the value it is sorted by
+type groupKey struct {
+	label string
+	order float64
+}
+
+// sortGroupKeys sorts text labels alphabetically, everything else by value, with the null
+// group last
+func sortGroupKeys(keys []groupKey) {
+	sort.SliceStable(keys, func(a, b int) bool {
+		if keys[a].order != keys[b].order {
+			return keys[a].order < keys[b].order
+		}
+		return keys[a].label < keys[b].label
+	})
+}
+
+// readGroupKey reads the group column of the current row
+func readGroupKey(ds *client.SessionDataSet, columnType string, index int32, binWidth float64) (groupKey, error) {
+	isNull, err := ds.IsNullByIndex(index)
+	if err != nil {
+		return groupKey{}, err
+	}
+	if isNull {
+		return groupKey{label: NullGroup, order: math.Inf(1)}, nil
+	}
+
+	if binWidth > 0 {
+		value, err := fetchDataByColumnType(ds, columnType, index)
+		if err != nil {
+			return groupKey{}, err
+		}
+		lower := math.Floor(value/binWidth) * binWidth
+		return groupKey{label: fmt.Sprintf("[%g, %g)", lower, lower+binWidth), order: lower}, nil
+	}
+
+	switch columnType {
+	case "INT32", "INT64":
+		value, err := fetchDataByColumnType(ds, columnType, index)
+		if err != nil {
+			return groupKey{}, err
+		}
+		return groupKey{label: strconv.FormatInt(int64(value), 10), order: value}, nil
+	case "BOOLEAN":
+		value, err := ds.GetBooleanByIndex(index)
+		if err != nil {
+			return groupKey{}, err
+		}
+		if value {
+			return groupKey{label: "true", order: 1}, nil
+		}
+		return groupKey{label: "false", order: 0}, nil
+	case "TEXT", "STRING":
+		value, err := ds.GetStringByIndex(index)
+		if err != nil {
+			return groupKey{}, err
+		}
+		return groupKey{label: value}, nil
+	case "DOUBLE", "FLOAT":
+		return groupKey{}, invalidQueryf("column %d has type %s, group it with a bin width", index-1, columnType)
+	}
+	return groupKey{}, unsupportedTypef("cannot group by column %d of type %s", index-1, columnType)
+}
+
+// GetGroupAnalysisResult computes DetailedStatisticsResult for every group of rows sharing
+// a value (or a value band) of groupBy.Column. Statistics cover every numeric column.
+func GetGroupAnalysisResult(ctx context.Context, session client.Session, deviceId string, filterExpr *filter.Expression, groupBy GroupBy, timeout int64, scan ScanOptions) (result GroupAnalysisResult, errRnt error) {
+	if err := ValidateMeasurement(groupBy.Column); err != nil {
+		return GroupAnalysisResult{}, err
+	}
+	if groupBy.BinWidth < 0 || math.IsNaN(groupBy.BinWidth) || math.IsInf(groupBy.BinWidth, 0) {
+		return GroupAnalysisResult{}, invalidQueryf("bin width must be a positive number, got %v", groupBy.BinWidth)
+	}
+
+	columnNames, columnTypes, errMetadata := FetchMetadata(ctx, session, deviceId, timeout)
+	if errMetadata != nil {
+		return GroupAnalysisResult{}, errMetadata
+	}
+	columnLength := int32(len(columnNames))
+
+	numericColumns := NumericColumnIndices(columnTypes)
+	groupIndex, errFind := findMeasurement(columnNames, deviceId, groupBy.Column)
+	if errFind != nil {
+		return GroupAnalysisResult{}, errFind
+	}
+
+	groupColumnType := columnTypes[groupIndex]
+	groupOf := func(ds *client.SessionDataSet) (groupKey, error) {
+		key, err := readGroupKey(ds, groupColumnType, int32(groupIndex+1), groupBy.BinWidth) // For Get***ByIndex(), index 1 is timestamp
+		if err != nil {
+			return groupKey{}, wrapError("read "+columnNames[groupIndex]+" of", deviceId, err)
+		}
+		return key, nil
+	}
+	groups, keys, errScan := scanGroups(ctx, session, deviceId, filterExpr, timeout, scan, columnNames, columnTypes, numericColumns, groupOf)
+	if errScan != nil {
+		return GroupAnalysisResult{}, errScan
+	}
+
+	sortGroupKeys(keys)
+
+	result = GroupAnalysisResult{
+		GroupBy:        groupBy,
+		ColumnNames:    columnNames,
+		NumericColumns: numericColumns,
+		Groups:         make([]string, len(keys)),
+		Statistics:     make(map[string]DetailedStatisticsResult, len(keys)),
+	}
+	for i, key := range keys {
+		result.Groups[i] = key.label
+		result.Statistics[key.label] = groups[key.label].result(numericColumns, columnLength)
+	}
+	return result, nil
+}
+
+// scanGroups reads every row of deviceId matching filterExpr once, assigns it to the group
+// returned by groupOf and feeds its numeric columns to that group's accumulators. Partitions
+// scanned concurrently are merged in time order, so the keys are returned in the order the
+// groups were first seen.
+func scanGroups(ctx context.Context, session client.Session, deviceId string, filterExpr *filter.Expression, timeout int64, scan ScanOptions,
+	columnNames []string, columnTypes []string, numericColumns []int, groupOf func(ds *client.SessionDataSet) (groupKey, error)) (map[string]*groupAccumulator, []groupKey, error) {
+	partitions, errPlan := planPartitions(ctx, session, deviceId, scan.Partitions, timeout)
+	if errPlan != nil {
+		return nil, nil, errPlan
+	}
+
+	type partial struct {
+		groups map[string]*groupAccumulator
+		keys   []groupKey
+	}
+	partials := make([]partial, len(partitions))
+	errScan := scanPartitions(ctx, session, scan, partitions, func(ctx context.Context, session client.Session, index int, partition timePartition) error {
+		query := SelectQuery{Device: deviceId, Where: filterExpr, StartTime: partition.start, EndTime: partition.end}
+		groups, keys, err := scanGroupPartition(ctx, session, query, timeout, columnNames, columnTypes, numericColumns, scan.quantileConfig(), groupOf)
+		partials[index] = partial{groups: groups, keys: keys}
+		return err
+	})
+	if errScan != nil {
+		return nil, nil, errScan
+	}
+
+	groups, keys := partials[0].groups, partials[0].keys
+	for _, p := range partials[1:] {
+		for _, key := range p.keys {
+			if acc, exists := groups[key.label]; exists {
+				acc.merge(p.groups[key.label])
+			} else {
+				groups[key.label] = p.groups[key.label]
+				keys = append(keys, key)
+			}
+		}
+	}
+	return groups, keys, nil
+}
+
+// scanGroupPartition runs query and accumulates its rows, see scanGroups
+func scanGroupPartition(ctx context.Context, session client.Session, query SelectQuery, timeout int64,
+	columnNames []string, columnTypes []string, numericColumns []int, quantiles sketch.Config, groupOf func(ds *client.SessionDataSet) (groupKey, error)) (map[string]*groupAccumulator, []groupKey, error) {
+	deviceId := query.Device
+	sql, errBuild := query.Build()
+	if errBuild != nil {
+		return nil, nil, errBuild
+	}
+
+	columnLength := int32(len(columnNames))
+	groups := make(map[string]*groupAccumulator)
+	keys := make([]groupKey, 0)
+
+	ds, err := executeQuery(ctx, session, sql, timeout)
+	if err != nil {
+		return nil, nil, wrapError("query", deviceId, err)
+	}
+	defer ds.Close()
+
+	var next bool
+	var errNext error
+	for next, errNext = ds.Next(); errNext == nil && next; next, errNext = ds.Next() {
+		if errCtx := checkContext(ctx, "scan", deviceId); errCtx != nil {
+			return nil, nil, errCtx
+		}
+
+		key, errGroup := groupOf(ds)
+		if errGroup != nil {
+			return nil, nil, errGroup
+		}
+		acc, exists := groups[key.label]
+		if !exists {
+			acc = newGroupAccumulator(columnLength, numericColumns, quantiles)
+			groups[key.label] = acc
+			keys = append(keys, key)
+		}
+		acc.cnt++
+
+		for _, i := range numericColumns {
+			data, isNull, err := fetchNullableData(ds, columnTypes[i], int32(i+1)) // For Get***ByIndex(), index 1 is timestamp
+			if err != nil {
+				return nil, nil, wrapError("read "+columnNames[i]+" of", deviceId, err)
+			}
+			if isNull {
+				acc.columns[i].AddNull()
+			} else {
+				acc.columns[i].Add(data)
+			}
+		}
+	}
+	if errNext != nil {
+		return nil, nil, wrapError("scan", deviceId, errNext)
+	}
+	return groups, keys, nil
+}
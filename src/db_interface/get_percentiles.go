@@ -0,0 +1,151 @@
+package db_interface
+
+import (
+	"bdgp2025/src/utils/filter"
+	"bdgp2025/src/utils/stats"
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/apache/iotdb-client-go/v2/client"
+)
+
+// DefaultPercentiles are computed when no percentiles are requested
+var DefaultPercentiles = []float64{1, 5, 25, 50, 75, 95, 99}
+
+// PercentileQuery selects the percentiles GetPercentilesResult computes
+type PercentileQuery struct {
+	Measurements []string             `json:"measurements"` // Empty means every numeric measurement
+	Percentiles  []float64            `json:"percentiles"`  // 0-100, empty means DefaultPercentiles
+	Method       stats.QuantileMethod `json:"method"`       // Hyndman-Fan type, 0 means the default
+	GroupBy      string               `json:"group_by,omitempty"`
+}
+
+// PercentileGroup holds the percentiles of one group of rows
+type PercentileGroup struct {
+	Group  string      `json:"group,omitempty"` // Empty without grouping
+	Counts []int       `json:"counts"`          // Non-null values per measurement
+	Values [][]float64 `json:"values"`          // Per measurement, then per percentile; nil without values
+}
+
+// PercentileResult holds exact percentiles of the requested measurements, per group when
+// the query has a group column
+type PercentileResult struct {
+	PercentileQuery
+	Groups []PercentileGroup `json:"groups"`
+}
+
+// GetPercentilesResult computes exact percentiles by the Hyndman-Fan definition of
+// query.Method. Unlike the quartiles of the statistics, which come from a sketch, they are
+// computed from every value, which are held in memory for the duration of the call.
+func GetPercentilesResult(ctx context.Context, session client.Session, deviceId string, query PercentileQuery, filterExpr *filter.Expression, timeout int64) (PercentileResult, error) {
+	if len(query.Percentiles) == 0 {
+		query.Percentiles = DefaultPercentiles
+	}
+	if query.Method == 0 {
+		query.Method = stats.DefaultQuantileMethod
+	}
+	if query.Method < 1 || query.Method > 9 {
+		return PercentileResult{}, invalidQueryf("quantile method must be a Hyndman-Fan type from 1 to 9, got %d", query.Method)
+	}
+
+	columnNames, columnTypes, errMetadata := FetchMetadata(ctx, session, deviceId, timeout)
+	if errMetadata != nil {
+		return PercentileResult{}, errMetadata
+	}
+	var columns []int
+	if len(query.Measurements) == 0 {
+		columns = NumericColumnIndices(columnTypes)
+		device, errPath := ParseDevicePath(deviceId)
+		if errPath != nil {
+			return PercentileResult{}, errPath
+		}
+		for _, i := range columns {
+			query.Measurements = append(query.Measurements, strings.TrimPrefix(columnNames[i], device.String()+"."))
+		}
+	} else {
+		for _, measurement := range query.Measurements {
+			index, errFind := findMeasurement(columnNames, deviceId, measurement)
+			if errFind != nil {
+				return PercentileResult{}, errFind
+			}
+			if !isNumericType(columnTypes[index]) {
+				return PercentileResult{}, unsupportedTypef("%s has type %s, percentiles need a numeric column", measurement, columnTypes[index])
+			}
+			columns = append(columns, index)
+		}
+	}
+	groupIndex := -1
+	if query.GroupBy != "" {
+		index, errFind := findMeasurement(columnNames, deviceId, query.GroupBy)
+		if errFind != nil {
+			return PercentileResult{}, errFind
+		}
+		groupIndex = index
+	}
+
+	sql, errBuild := SelectQuery{Device: deviceId, Where: filterExpr}.Build()
+	if errBuild != nil {
+		return PercentileResult{}, errBuild
+	}
+	ds, err := executeQuery(ctx, session, sql, timeout)
+	if err != nil {
+		return PercentileResult{}, wrapError("query", deviceId, err)
+	}
+	defer ds.Close()
+
+	// values[label][k] collects the non-null values of columns[k]
+	values := make(map[string][][]float64)
+	var keys []groupKey
+	var next bool
+	var errNext error
+	for next, errNext = ds.Next(); errNext == nil && next; next, errNext = ds.Next() {
+		if errCtx := checkContext(ctx, "scan", deviceId); errCtx != nil {
+			return PercentileResult{}, errCtx
+		}
+		var key groupKey
+		if groupIndex >= 0 {
+			key, err = readGroupKey(ds, columnTypes[groupIndex], int32(groupIndex+1), 0) // For Get***ByIndex(), index 1 is timestamp
+			if err != nil {
+				return PercentileResult{}, wrapError("read "+columnNames[groupIndex]+" of", deviceId, err)
+			}
+		}
+		group, exists := values[key.label]
+		if !exists {
+			group = make([][]float64, len(columns))
+			values[key.label] = group
+			keys = append(keys, key)
+		}
+		for k, i := range columns {
+			data, isNull, err := fetchNullableData(ds, columnTypes[i], int32(i+1)) // For Get***ByIndex(), index 1 is timestamp
+			if err != nil {
+				return PercentileResult{}, wrapError("read "+columnNames[i]+" of", deviceId, err)
+			}
+			if !isNull {
+				group[k] = append(group[k], data)
+			}
+		}
+	}
+	if errNext != nil {
+		return PercentileResult{}, wrapError("scan", deviceId, errNext)
+	}
+
+	sortGroupKeys(keys)
+	result := PercentileResult{PercentileQuery: query, Groups: make([]PercentileGroup, 0, len(keys))}
+	for _, key := range keys {
+		group := PercentileGroup{Group: key.label, Counts: make([]int, len(columns)), Values: make([][]float64, len(columns))}
+		for k, sample := range values[key.label] {
+			group.Counts[k] = len(sample)
+			if len(sample) == 0 {
+				continue
+			}
+			sort.Float64s(sample)
+			group.Values[k] = make([]float64, len(query.Percentiles))
+			for j, p := range query.Percentiles {
+				group.Values[k][j] = stats.SampleQuantile(sample, p/100, query.Method)
+			}
+		}
+		result.Groups = append(result.Groups, group)
+	}
+	return result, nil
+}
@@ -0,0 +1,120 @@
+package db_interface
+
+import (
+	"context"
+	"encoding/base64"
+	"strconv"
+	"strings"
+
+	"github.com/apache/iotdb-client-go/v2/client"
+)
+
+const (
+	DefaultRawDataLimit = 100
+	MaxRawDataLimit     = 1000
+	rawDataCursorPrefix = "t:"
+)
+
+type RawDataRow struct {
+	Timestamp int64         `json:"timestamp"`
+	Values    []interface{} `json:"values"`
+}
+
+type RawDataPage struct {
+	Columns    []string     `json:"columns"` // Measurement columns, excluding the timestamp
+	Rows       []RawDataRow `json:"rows"`
+	NextCursor string       `json:"next_cursor,omitempty"`
+}
+
+// EncodeRawDataCursor turns the timestamp of the last row of a page into an opaque cursor.
+func EncodeRawDataCursor(lastTimestamp int64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(rawDataCursorPrefix + strconv.FormatInt(lastTimestamp, 10)))
+}
+
+// DecodeRawDataCursor recovers the timestamp after which the next page starts.
+func DecodeRawDataCursor(cursor string) (int64, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil || !strings.HasPrefix(string(raw), rawDataCursorPrefix) {
+		return 0, invalidQueryf("invalid cursor %q", cursor)
+	}
+	lastTimestamp, err := strconv.ParseInt(strings.TrimPrefix(string(raw), rawDataCursorPrefix), 10, 64)
+	if err != nil {
+		return 0, invalidQueryf("invalid cursor %q", cursor)
+	}
+	return lastTimestamp, nil
+}
+
+// GetRawDataPage returns at most limit rows of deviceId in [startTime, endTime), ordered by time.
+// A zero startTime or endTime leaves that side of the range open. Pages are seeked by timestamp
+// rather than OFFSET, so later pages cost the same as the first one.
+func GetRawDataPage(ctx context.Context, session client.Session, deviceId string, startTime int64, endTime int64, limit int, cursor string, timeout int64) (page RawDataPage, errRnt error) {
+	if limit <= 0 {
+		limit = DefaultRawDataLimit
+	}
+	if limit > MaxRawDataLimit {
+		limit = MaxRawDataLimit
+	}
+
+	// Fetch one extra row to find out whether another page exists
+	query := SelectQuery{Device: deviceId, StartTime: startTime, EndTime: endTime, Limit: limit + 1}
+	if cursor != "" {
+		lastTimestamp, err := DecodeRawDataCursor(cursor)
+		if err != nil {
+			return RawDataPage{}, err
+		}
+		query.AfterTime = &lastTimestamp
+	}
+
+	sql, err := query.Build()
+	if err != nil {
+		return RawDataPage{}, err
+	}
+
+	ds, err := executeQuery(ctx, session, sql, timeout)
+	if err != nil {
+		return RawDataPage{}, wrapError("query", deviceId, err)
+	}
+	defer ds.Close()
+
+	columnNames := ds.GetColumnNames()
+	columnLength := int32(len(columnNames))
+	page.Columns = columnNames[1:]
+	page.Rows = make([]RawDataRow, 0, limit)
+
+	hasMore := false
+	var next bool
+	var errNext error
+	for next, errNext = ds.Next(); errNext == nil && next; next, errNext = ds.Next() {
+		if errCtx := checkContext(ctx, "scan", deviceId); errCtx != nil {
+			return RawDataPage{}, errCtx
+		}
+		if len(page.Rows) == limit {
+			hasMore = true
+			break
+		}
+		timestamp, err := ds.GetLongByIndex(1) // For Get***ByIndex(), index 1 is timestamp
+		if err != nil {
+			return RawDataPage{}, wrapError("read timestamp of", deviceId, err)
+		}
+		row := RawDataRow{
+			Timestamp: timestamp,
+			Values:    make([]interface{}, columnLength-1),
+		}
+		for i := int32(1); i < columnLength; i++ {
+			value, err := ds.GetObjectByIndex(i + 1)
+			if err != nil {
+				return RawDataPage{}, wrapError("read "+columnNames[i]+" of", deviceId, err)
+			}
+			row.Values[i-1] = value
+		}
+		page.Rows = append(page.Rows, row)
+	}
+	if errNext != nil {
+		return RawDataPage{}, wrapError("scan", deviceId, errNext)
+	}
+
+	if hasMore {
+		page.NextCursor = EncodeRawDataCursor(page.Rows[len(page.Rows)-1].Timestamp)
+	}
+	return
+}
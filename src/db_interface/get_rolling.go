@@ -0,0 +1,32 @@
+package db_interface
+
+import (
+	"bdgp2025/src/utils/filter"
+	"bdgp2025/src/utils/rolling"
+	"context"
+
+	"github.com/apache/iotdb-client-go/v2/client"
+)
+
+// GetRollingResult computes the statistics of measurement over the windows described by
+// config, in time order over the rows of deviceId matching filterExpr
+func GetRollingResult(ctx context.Context, session client.Session, deviceId string, measurement string, filterExpr *filter.Expression, config rolling.Config, timeout int64) (*rolling.Series, error) {
+	roller, errConfig := rolling.NewRoller(config)
+	if errConfig != nil {
+		return nil, invalidQueryf("%v", errConfig)
+	}
+
+	columnNames, _, errMetadata := FetchMetadata(ctx, session, deviceId, timeout)
+	if errMetadata != nil {
+		return nil, errMetadata
+	}
+	index, errFind := findMeasurement(columnNames, deviceId, measurement)
+	if errFind != nil {
+		return nil, errFind
+	}
+
+	if err := TraverseWithTime(ctx, session, deviceId, filterExpr, timeout, roller.Add, int32(index)); err != nil {
+		return nil, err
+	}
+	return roller.Finish(), nil
+}
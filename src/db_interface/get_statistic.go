@@ -1,97 +1,151 @@
 package db_interface
 
 import (
-	"log"
-	"math"
+	"bdgp2025/src/utils/filter"
+	"bdgp2025/src/utils/stats"
+	"context"
 
 	"github.com/apache/iotdb-client-go/v2/client"
 )
 
+// StatisticsResult holds the descriptive statistics of every numeric column over the whole
+// device. It is computed with the same accumulators as the per-group DetailedStatisticsResult.
 type StatisticsResult struct {
-	Cnt               int
-	Sum               []float64
-	Average           []float64
-	Variance          []float64
-	StandardDeviation []float64
+	DetailedStatisticsResult
+	ColumnNames    []string                // All columns of the device, index 0 is Time
+	NumericColumns []int                   // Indices into ColumnNames that statistics were computed for
+	Normality      []stats.NormalityReport // Indexed like ColumnNames, empty for skipped columns
 }
 
-func FetchMetadata(session client.Session, deviceId string, timeout int64) (columnNames []string, columnTypes []string, errRnt error) {
-	var sql string = "select * from " + deviceId
-	if ds, err := session.ExecuteQueryStatement(sql, &timeout); err == nil {
+func FetchMetadata(ctx context.Context, session client.Session, deviceId string, timeout int64) (columnNames []string, columnTypes []string, errRnt error) {
+	// Only the header is needed, so a single row is enough
+	sql, errBuild := SelectQuery{Device: deviceId, Limit: 1}.Build()
+	if errBuild != nil {
+		return nil, nil, errBuild
+	}
+	if ds, err := executeQuery(ctx, session, sql, timeout); err == nil {
 		columnNames = ds.GetColumnNames()
 		columnTypes = ds.GetColumnTypes()
 		ds.Close()
 	} else {
-		log.Fatal(err)
-		errRnt = err
+		return nil, nil, wrapError("fetch metadata of", deviceId, err)
+	}
+	// IoTDB answers a query on an unknown path with nothing but the Time column
+	if len(columnNames) <= 1 {
+		return nil, nil, wrapError("fetch metadata of", deviceId, ErrDeviceNotFound)
 	}
 	return
 }
+
+// NumericColumnIndices returns the indices of the columns statistics can be computed for,
+// skipping the Time column at index 0
+func NumericColumnIndices(columnTypes []string) []int {
+	columns := make([]int, 0, len(columnTypes))
+	for i := 1; i < len(columnTypes); i++ {
+		if isNumericType(columnTypes[i]) {
+			columns = append(columns, i)
+		}
+	}
+	return columns
+}
+
+// isNumericType reports whether fetchDataByColumnType can read a column of columnType
+func isNumericType(columnType string) bool {
+	switch columnType {
+	case "DOUBLE", "FLOAT", "INT64", "INT32", "BOOLEAN":
+		return true
+	}
+	return false
+}
+
+// fetchNullableData reads a numeric value and reports nulls instead of returning them as 0
+func fetchNullableData(ds *client.SessionDataSet, columnType string, index int32) (float64, bool, error) {
+	isNull, err := ds.IsNullByIndex(index)
+	if err != nil || isNull {
+		return 0, isNull, err
+	}
+	data, err := fetchDataByColumnType(ds, columnType, index)
+	return data, false, err
+}
+
 func fetchDataByColumnType(ds *client.SessionDataSet, columnType string, index int32) (float64, error) {
 	var data float64
-	if columnType == "DOUBLE" {
+	switch columnType {
+	case "DOUBLE":
 		dataRaw, errGet := ds.GetDoubleByIndex(index)
 		if errGet != nil {
 			return 0, errGet
 		}
 		data = dataRaw
-	} else if columnType == "INT64" {
+	case "FLOAT":
+		dataRaw, errGet := ds.GetFloatByIndex(index)
+		if errGet != nil {
+			return 0, errGet
+		}
+		data = float64(dataRaw)
+	case "INT64":
 		dataRaw, errGet := ds.GetLongByIndex(index)
 		if errGet != nil {
 			return 0, errGet
 		}
 		data = float64(dataRaw)
+	case "INT32":
+		dataRaw, errGet := ds.GetIntByIndex(index)
+		if errGet != nil {
+			return 0, errGet
+		}
+		data = float64(dataRaw)
+	case "BOOLEAN":
+		dataRaw, errGet := ds.GetBooleanByIndex(index)
+		if errGet != nil {
+			return 0, errGet
+		}
+		if dataRaw {
+			data = 1
+		}
+	default:
+		return 0, unsupportedTypef("column %d has type %s", index-1, columnType)
 	}
 	return data, nil
 }
 
-func GetStatisticsResult(session client.Session, deviceId string, timeout int64) (result StatisticsResult, errRnt error) {
-	columnNames, columnTypes, errMetadata := FetchMetadata(session, deviceId, timeout)
+func GetStatisticsResult(ctx context.Context, session client.Session, deviceId string, filterExpr *filter.Expression, timeout int64, scan ScanOptions) (result StatisticsResult, errRnt error) {
+	acc, columnNames, numericColumns, errScan := scanWholeDevice(ctx, session, deviceId, filterExpr, timeout, scan)
+	if errScan != nil {
+		return StatisticsResult{}, errScan
+	}
+
+	normality := make([]stats.NormalityReport, len(columnNames))
+	for _, i := range numericColumns {
+		normality[i] = acc.columns[i].Normality()
+	}
+	return StatisticsResult{
+		DetailedStatisticsResult: acc.result(numericColumns, int32(len(columnNames))),
+		ColumnNames:              columnNames,
+		NumericColumns:           numericColumns,
+		Normality:                normality,
+	}, nil
+}
+
+// scanWholeDevice accumulates every numeric column of deviceId over the rows matching
+// filterExpr, treating the whole device as a single group
+func scanWholeDevice(ctx context.Context, session client.Session, deviceId string, filterExpr *filter.Expression, timeout int64, scan ScanOptions) (*groupAccumulator, []string, []int, error) {
+	columnNames, columnTypes, errMetadata := FetchMetadata(ctx, session, deviceId, timeout)
 	if errMetadata != nil {
-		log.Fatal(errMetadata)
-		return StatisticsResult{}, errMetadata
+		return nil, nil, nil, errMetadata
 	}
+	numericColumns := NumericColumnIndices(columnTypes)
 
-	columnLength := int32(len(columnNames))
-	result = StatisticsResult{
-		Cnt:               0,
-		Sum:               make([]float64, columnLength),
-		Average:           make([]float64, columnLength),
-		Variance:          make([]float64, columnLength),
-		StandardDeviation: make([]float64, columnLength),
+	wholeDevice := groupKey{label: deviceId}
+	groupOf := func(ds *client.SessionDataSet) (groupKey, error) { return wholeDevice, nil }
+	groups, _, errScan := scanGroups(ctx, session, deviceId, filterExpr, timeout, scan, columnNames, columnTypes, numericColumns, groupOf)
+	if errScan != nil {
+		return nil, nil, nil, errScan
 	}
-	sql := "select * from " + deviceId
 
-	if ds, err := session.ExecuteQueryStatement(sql, &timeout); err == nil {
-		var welfordMean []float64 = make([]float64, columnLength)
-		var welfordM2 []float64 = make([]float64, columnLength)
-		for next, err := ds.Next(); err == nil && next; next, err = ds.Next() {
-			result.Cnt++
-			var i int32 = 1
-			for ; i < columnLength; i++ {
-				var index int32 = i + 1 //For Get***ByIndex(), index 1 is timestamp.
-				columnType := columnTypes[i]
-				data, err := fetchDataByColumnType(ds, columnType, index)
-				if err != nil {
-					log.Fatal(err)
-				}
-				result.Sum[i] += data
-				result.Average[i] += data
-				welfordDiff := data - welfordMean[i]
-				welfordMean[i] = welfordDiff / float64(result.Cnt)
-				welfordDiff2 := data - welfordMean[i]
-				welfordM2[i] = welfordDiff2 * welfordDiff
-			}
-		}
-		var i int32 = 1
-		for ; i < columnLength; i++ {
-			result.Average[i] /= float64(result.Cnt)
-			result.Variance[i] = welfordM2[i] / float64(result.Cnt-1)
-			result.StandardDeviation[i] = math.Sqrt(result.Variance[i])
-		}
-	} else {
-		log.Fatal(err)
-		errRnt = err
+	acc, ok := groups[wholeDevice.label]
+	if !ok {
+		acc = newGroupAccumulator(int32(len(columnNames)), numericColumns, scan.quantileConfig())
 	}
-	return
+	return acc, columnNames, numericColumns, nil
 }
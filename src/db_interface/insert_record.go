@@ -6,7 +6,7 @@ import (
 	"github.com/apache/iotdb-client-go/v2/client"
 )
 
-func InsertRecordsOfOneDevice(session client.Session, deviceId string, dataMatrix [][]interface{}, ts int64) {
+func InsertRecordsOfOneDevice(session client.Session, deviceId string, dataMatrix [][]interface{}, ts int64) error {
 	if ts == 0 {
 		ts = time.Now().UTC().UnixNano() / 1000000
 	}
@@ -20,5 +20,5 @@ func InsertRecordsOfOneDevice(session client.Session, deviceId string, dataMatri
 		values     = dataMatrix
 		timestamps = []int64{ts}
 	)
-	CheckError(session.InsertRecordsOfOneDevice(deviceId, timestamps, measurementsSlice, dataTypes, values, false))
+	return wrapError("insert into", deviceId, CheckError(session.InsertRecordsOfOneDevice(deviceId, timestamps, measurementsSlice, dataTypes, values, false)))
 }
@@ -0,0 +1,301 @@
+package db_interface
+
+import (
+	"bdgp2025/src/utils/filter"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// DevicePath is a validated tree-model path such as root.example.exampledev.
+// Nodes are stored unquoted; String renders them back with backticks where IoTDB requires them.
+type DevicePath struct {
+	nodes []string
+}
+
+// SelectQuery describes a SELECT statement on a single device. Every user supplied
+// part is validated and quoted by Build, so callers never concatenate SQL themselves.
+type SelectQuery struct {
+	Device       string             // Full device path
+	Measurements []string           // Measurements to select, empty means every measurement
+	Aggregations []string           // Aggregation functions applied to every selected measurement
+	Where        *filter.Expression // Optional row filter
+	StartTime    int64              // Inclusive lower time bound in milliseconds, 0 leaves it open
+	EndTime      int64              // Exclusive upper time bound in milliseconds, 0 leaves it open
+	AfterTime    *int64             // Exclusive lower time bound used by seek pagination
+	GroupBy      *GroupByTime
+	Limit        int // 0 means no limit
+	Offset       int
+}
+
+// GroupByTime renders IoTDB's GROUP BY ([StartTime, EndTime), Interval[, SlidingStep]) clause.
+type GroupByTime struct {
+	StartTime   int64
+	EndTime     int64
+	Interval    string // Duration literal such as 1m or 1h30m
+	SlidingStep string // Optional, defaults to Interval on the server side
+}
+
+var (
+	unquotedNodePattern = regexp.MustCompile(`^[0-9A-Za-z_\x{2E80}-\x{9FFF}]+$`)
+	realNumberPattern   = regexp.MustCompile(`^[+-]?([0-9]+\.?[0-9]*|\.[0-9]+)([eE][+-]?[0-9]+)?$`)
+	durationPattern     = regexp.MustCompile(`^([0-9]+(ns|us|ms|mo|s|m|h|d|w|y))+$`)
+)
+
+var supportedAggregations = map[string]bool{
+	"count": true, "sum": true, "avg": true, "extreme": true,
+	"min_value": true, "max_value": true, "first_value": true, "last_value": true,
+	"min_time": true, "max_time": true, "stddev": true, "variance": true,
+}
+
+// Keywords that must be quoted when used as a path node
+var reservedWords = toSet(strings.Fields(`
+	add after alias align aligned all alter and any append as asc attributes before begin between
+	boolean by case child clear cluster concat configuration contain continuous count create data
+	database databases datanodes date delete desc describe details device devices disable double drop
+	else end endtime every explain false fill first float flush for from full function functions global
+	grant group having in index info insert int32 int64 into is kill last latest level like limit
+	linear list load local lock merge metadata nodes none not now null nulls of off offset on or order
+	partition password paths pipe pipes previous privileges processlist query range readonly regexp
+	region regions remove rename revoke role root schema select session set show slimit soffset storage
+	start starttime stop string system tags template text time timeseries timestamp to top trigger
+	triggers true ttl unset update upsert user using values variation version view when where with
+	without`))
+
+func toSet(words []string) map[string]bool {
+	set := make(map[string]bool, len(words))
+	for _, word := range words {
+		set[word] = true
+	}
+	return set
+}
+
+// ParseDevicePath splits and validates a device path. Nodes may be written plainly when they
+// follow IoTDB's identifier rules, or wrapped in backticks otherwise (a doubled backtick stands for a literal one).
+// Wildcards are rejected because every query in this project targets exactly one device.
+func ParseDevicePath(path string) (DevicePath, error) {
+	nodes, err := splitPathNodes(path)
+	if err != nil {
+		return DevicePath{}, err
+	}
+	if len(nodes) < 2 || nodes[0] != "root" {
+		return DevicePath{}, invalidQueryf("invalid device path %q: must start with root and name at least one more node", path)
+	}
+	return DevicePath{nodes: nodes}, nil
+}
+
+func splitPathNodes(path string) ([]string, error) {
+	nodes := make([]string, 0)
+	runes := []rune(path)
+
+	for i := 0; i <= len(runes); {
+		var node strings.Builder
+		if i < len(runes) && runes[i] == '`' {
+			i++
+			closed := false
+			for i < len(runes) {
+				if runes[i] == '`' {
+					if i+1 < len(runes) && runes[i+1] == '`' {
+						node.WriteRune('`')
+						i += 2
+						continue
+					}
+					i++
+					closed = true
+					break
+				}
+				node.WriteRune(runes[i])
+				i++
+			}
+			if !closed {
+				return nil, invalidQueryf("invalid path %q: unterminated backtick", path)
+			}
+			if node.Len() == 0 {
+				return nil, invalidQueryf("invalid path %q: empty node", path)
+			}
+		} else {
+			for i < len(runes) && runes[i] != '.' {
+				node.WriteRune(runes[i])
+				i++
+			}
+			if err := validateUnquotedNode(node.String()); err != nil {
+				return nil, invalidQueryf("invalid path %q: %v", path, err)
+			}
+		}
+		nodes = append(nodes, node.String())
+
+		if i == len(runes) {
+			break
+		}
+		if runes[i] != '.' {
+			return nil, invalidQueryf("invalid path %q: expected '.' after node %q", path, node.String())
+		}
+		i++
+		if i == len(runes) {
+			return nil, invalidQueryf("invalid path %q: empty node", path)
+		}
+	}
+
+	return nodes, nil
+}
+
+func validateUnquotedNode(node string) error {
+	if node == "" {
+		return invalidQueryf("empty node")
+	}
+	if !unquotedNodePattern.MatchString(node) {
+		return invalidQueryf("node %q contains characters that must be quoted with backticks", node)
+	}
+	return nil
+}
+
+// ValidateMeasurement checks a single measurement name given without backticks.
+func ValidateMeasurement(name string) error {
+	return validateUnquotedNode(name)
+}
+
+// QuoteNode renders one path node, adding backticks when IoTDB could not parse it unquoted.
+func QuoteNode(node string) string {
+	if unquotedNodePattern.MatchString(node) && !realNumberPattern.MatchString(node) && !reservedWords[strings.ToLower(node)] {
+		return node
+	}
+	return "`" + strings.ReplaceAll(node, "`", "``") + "`"
+}
+
+// String renders the path in the form accepted by IoTDB statements.
+func (p DevicePath) String() string {
+	quoted := make([]string, len(p.nodes))
+	for i, node := range p.nodes {
+		if i == 0 {
+			quoted[i] = node // root
+			continue
+		}
+		quoted[i] = QuoteNode(node)
+	}
+	return strings.Join(quoted, ".")
+}
+
+// Measurement returns the full path of a measurement under this device.
+func (p DevicePath) Measurement(name string) string {
+	return p.String() + "." + QuoteNode(name)
+}
+
+// Build renders the statement, validating every part of the query.
+func (q SelectQuery) Build() (string, error) {
+	device, err := ParseDevicePath(q.Device)
+	if err != nil {
+		return "", err
+	}
+
+	targets := make([]string, 0)
+	if len(q.Measurements) == 0 {
+		targets = append(targets, "*")
+	} else {
+		for _, measurement := range q.Measurements {
+			if measurement == "*" {
+				targets = append(targets, "*")
+				continue
+			}
+			if strings.TrimSpace(measurement) == "" {
+				return "", invalidQueryf("empty measurement name")
+			}
+			targets = append(targets, QuoteNode(measurement))
+		}
+	}
+
+	selectList := targets
+	if len(q.Aggregations) > 0 {
+		selectList = make([]string, 0, len(q.Aggregations)*len(targets))
+		for _, aggregation := range q.Aggregations {
+			aggregation = strings.ToLower(aggregation)
+			if !supportedAggregations[aggregation] {
+				return "", invalidQueryf("unsupported aggregation %q", aggregation)
+			}
+			for _, target := range targets {
+				selectList = append(selectList, aggregation+"("+target+")")
+			}
+		}
+	}
+
+	var sb strings.Builder
+	sb.WriteString("select ")
+	sb.WriteString(strings.Join(selectList, ", "))
+	sb.WriteString(" from ")
+	sb.WriteString(device.String())
+
+	conditions := make([]string, 0, 4)
+	if q.StartTime != 0 {
+		conditions = append(conditions, "time >= "+strconv.FormatInt(q.StartTime, 10))
+	}
+	if q.EndTime != 0 {
+		conditions = append(conditions, "time < "+strconv.FormatInt(q.EndTime, 10))
+	}
+	if q.AfterTime != nil {
+		conditions = append(conditions, "time > "+strconv.FormatInt(*q.AfterTime, 10))
+	}
+	if q.Where != nil {
+		conditions = append(conditions, q.Where.SQLWithQuote(quoteFilterMeasurement))
+	}
+	if len(conditions) > 0 {
+		sb.WriteString(" where ")
+		sb.WriteString(strings.Join(conditions, " and "))
+	}
+
+	if q.GroupBy != nil {
+		clause, err := q.GroupBy.clause()
+		if err != nil {
+			return "", err
+		}
+		sb.WriteString(" ")
+		sb.WriteString(clause)
+	}
+
+	if q.Limit < 0 || q.Offset < 0 {
+		return "", invalidQueryf("limit and offset must not be negative")
+	}
+	if q.Limit > 0 {
+		sb.WriteString(" limit ")
+		sb.WriteString(strconv.Itoa(q.Limit))
+	}
+	if q.Offset > 0 {
+		sb.WriteString(" offset ")
+		sb.WriteString(strconv.Itoa(q.Offset))
+	}
+
+	return sb.String(), nil
+}
+
+func (g *GroupByTime) clause() (string, error) {
+	if g.EndTime <= g.StartTime {
+		return "", invalidQueryf("group by time range [%d, %d) is empty", g.StartTime, g.EndTime)
+	}
+	if !durationPattern.MatchString(g.Interval) {
+		return "", invalidQueryf("invalid group by interval %q", g.Interval)
+	}
+	clause := fmt.Sprintf("group by ([%d, %d), %s", g.StartTime, g.EndTime, g.Interval)
+	if g.SlidingStep != "" {
+		if !durationPattern.MatchString(g.SlidingStep) {
+			return "", invalidQueryf("invalid group by sliding step %q", g.SlidingStep)
+		}
+		clause += ", " + g.SlidingStep
+	}
+	return clause + ")", nil
+}
+
+// quoteFilterMeasurement keeps the time keyword usable in filters and quotes everything else.
+func quoteFilterMeasurement(name string) string {
+	if strings.EqualFold(name, "time") {
+		return "time"
+	}
+	return QuoteNode(name)
+}
+
+// BuildShowTimeseries renders SHOW TIMESERIES for the direct children of a device.
+func BuildShowTimeseries(devicePath string) (string, error) {
+	device, err := ParseDevicePath(devicePath)
+	if err != nil {
+		return "", err
+	}
+	return "show timeseries " + device.String() + ".*", nil
+}
@@ -0,0 +1,129 @@
+package db_interface
+
+import (
+	"bdgp2025/src/utils/sketch"
+	"context"
+	"errors"
+	"math"
+	"sync"
+
+	"github.com/apache/iotdb-client-go/v2/client"
+)
+
+// ScanOptions controls how the full-device analyses read their rows. With Partitions above 1
+// the device's time range is split into that many slices, which are scanned concurrently on
+// sessions borrowed from Pool and combined with the accumulators' Merge. The zero value
+// scans in a single query on the caller's session.
+type ScanOptions struct {
+	Pool            *SessionPool
+	Partitions      int
+	QuantileEpsilon float64 // Rank error bound of median, quartile and percentile estimates, 0 for the sketch default
+}
+
+// quantileConfig sizes the sketches behind every median, quartile and percentile
+func (s ScanOptions) quantileConfig() sketch.Config {
+	config := sketch.DefaultConfig()
+	if s.QuantileEpsilon > 0 {
+		config.Epsilon = s.QuantileEpsilon
+	}
+	return config
+}
+
+// timePartition is the time slice [start, end) of a device. 0 leaves a side open, like the
+// bounds of SelectQuery.
+type timePartition struct {
+	start int64
+	end   int64
+}
+
+// planPartitions splits the time range of deviceId into at most count slices that together
+// cover every row exactly once. The first and last slices are open-ended, so rows written
+// after planning still land in exactly one of them.
+func planPartitions(ctx context.Context, session client.Session, deviceId string, count int, timeout int64) ([]timePartition, error) {
+	whole := []timePartition{{}}
+	if count < 2 {
+		return whole, nil
+	}
+
+	extents, err := fetchTimeseriesExtents(ctx, session, deviceId, timeout)
+	if err != nil {
+		return nil, err
+	}
+	first, last := int64(math.MaxInt64), int64(math.MinInt64)
+	for _, extent := range extents {
+		if extent.first != nil && *extent.first < first {
+			first = *extent.first
+		}
+		if extent.last != nil && *extent.last > last {
+			last = *extent.last
+		}
+	}
+	if first >= last {
+		return whole, nil
+	}
+
+	span := uint64(last-first) + 1
+	if uint64(count) > span {
+		count = int(span)
+	}
+	partitions := make([]timePartition, count)
+	for i := 1; i < count; i++ {
+		boundary := first + int64(span/uint64(count)*uint64(i)+span%uint64(count)*uint64(i)/uint64(count))
+		if boundary == 0 {
+			boundary = 1 // 0 would read as an open bound; both neighbours share the boundary, so nothing is lost
+		}
+		partitions[i-1].end = boundary
+		partitions[i].start = boundary
+	}
+	return partitions, nil
+}
+
+// scanPartitions calls scan once for every partition. The caller's session works through the
+// partitions as well, and extra sessions are borrowed from opts.Pool only while idle ones are
+// free, so a busy pool degrades to a sequential scan. The first error cancels the remaining
+// partitions and is returned.
+func scanPartitions(ctx context.Context, session client.Session, opts ScanOptions, partitions []timePartition,
+	scan func(ctx context.Context, session client.Session, index int, partition timePartition) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	work := make(chan int, len(partitions))
+	for i := range partitions {
+		work <- i
+	}
+	close(work)
+
+	var mu sync.Mutex
+	var firstErr error
+	worker := func(session client.Session) error {
+		for i := range work {
+			if err := scan(ctx, session, i, partitions[i]); err != nil {
+				mu.Lock()
+				if firstErr == nil {
+					firstErr = err
+				}
+				mu.Unlock()
+				cancel()
+				return err
+			}
+		}
+		return nil
+	}
+
+	var wg sync.WaitGroup
+	for extra := 1; extra < len(partitions) && opts.Pool != nil; extra++ {
+		borrowed, ok := opts.Pool.tryAcquire()
+		if !ok {
+			break
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := worker(borrowed)
+			opts.Pool.release(borrowed, errors.Is(err, ErrConnectionLost))
+		}()
+	}
+	worker(session)
+	wg.Wait()
+	return firstErr
+}
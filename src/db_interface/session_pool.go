@@ -0,0 +1,319 @@
+package db_interface
+
+import (
+	"context"
+	"errors"
+	"log"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/apache/iotdb-client-go/v2/client"
+)
+
+const (
+	DefaultPoolSize            = 8
+	DefaultMaxRetries          = 3
+	defaultConnectTimeoutMs    = 5000
+	defaultAcquireTimeout      = 30 * time.Second
+	defaultHealthCheckInterval = 30 * time.Second
+	defaultInitialBackoff      = 200 * time.Millisecond
+	defaultMaxBackoff          = 5 * time.Second
+)
+
+var ErrPoolClosed = errors.New("session pool closed")
+
+// PoolConfig configures a SessionPool. Zero values fall back to the defaults above.
+type PoolConfig struct {
+	Host                string
+	Port                string
+	User                string
+	Password            string
+	Size                int           // Maximum number of sessions in use at the same time
+	MaxRetries          int           // Retries for connecting and for idempotent reads
+	ConnectTimeoutMs    int           // Timeout of a single connection attempt
+	AcquireTimeout      time.Duration // How long a caller waits for a free session
+	HealthCheckInterval time.Duration // How often idle sessions are pinged, negative disables it
+	InitialBackoff      time.Duration
+	MaxBackoff          time.Duration
+}
+
+// SessionPool hands out one IoTDB session per caller, so concurrent requests never share a
+// Thrift connection. Broken sessions are dropped and replaced, reconnecting with exponential
+// backoff, and idle sessions are health-checked in the background.
+type SessionPool struct {
+	config    PoolConfig
+	idle      chan client.Session
+	slots     chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// NewSessionPool creates the pool and opens its first session, so a misconfigured
+// address is reported at startup rather than on the first request.
+func NewSessionPool(config PoolConfig) (*SessionPool, error) {
+	if config.Size <= 0 {
+		config.Size = DefaultPoolSize
+	}
+	if config.MaxRetries < 0 {
+		config.MaxRetries = 0
+	}
+	if config.ConnectTimeoutMs <= 0 {
+		config.ConnectTimeoutMs = defaultConnectTimeoutMs
+	}
+	if config.AcquireTimeout <= 0 {
+		config.AcquireTimeout = defaultAcquireTimeout
+	}
+	if config.HealthCheckInterval == 0 {
+		config.HealthCheckInterval = defaultHealthCheckInterval
+	}
+	if config.InitialBackoff <= 0 {
+		config.InitialBackoff = defaultInitialBackoff
+	}
+	if config.MaxBackoff <= 0 {
+		config.MaxBackoff = defaultMaxBackoff
+	}
+
+	pool := &SessionPool{
+		config: config,
+		idle:   make(chan client.Session, config.Size),
+		slots:  make(chan struct{}, config.Size),
+		done:   make(chan struct{}),
+	}
+
+	session, err := pool.connect(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	pool.idle <- session
+
+	if config.HealthCheckInterval > 0 {
+		go pool.healthCheckLoop()
+	}
+	return pool, nil
+}
+
+// WithSession runs fn with a session reserved for the caller. It does not retry, so it is
+// the right choice for writes such as CSV imports. Waiting for a free session stops when ctx is done.
+func (p *SessionPool) WithSession(ctx context.Context, fn func(session client.Session) error) error {
+	session, err := p.acquire(ctx)
+	if err != nil {
+		return err
+	}
+	err = fn(session)
+	p.release(session, errors.Is(err, ErrConnectionLost))
+	return err
+}
+
+// WithRetry runs an idempotent read. When the connection is lost the session is replaced
+// and fn runs again, up to MaxRetries times with exponential backoff between attempts.
+func (p *SessionPool) WithRetry(ctx context.Context, fn func(session client.Session) error) error {
+	for attempt := 0; ; attempt++ {
+		err := p.WithSession(ctx, fn)
+		if err == nil || !errors.Is(err, ErrConnectionLost) || attempt >= p.config.MaxRetries {
+			return err
+		}
+		log.Printf("IoTDB connection lost, retrying (%d/%d): %v\n", attempt+1, p.config.MaxRetries, err)
+		if err := p.sleep(ctx, p.backoff(attempt)); err != nil {
+			return err
+		}
+	}
+}
+
+// Close stops the health checks and closes every idle session. Sessions still in use are
+// closed when they are released.
+func (p *SessionPool) Close() {
+	p.closeOnce.Do(func() {
+		close(p.done)
+		for {
+			select {
+			case session := <-p.idle:
+				session.Close()
+			default:
+				return
+			}
+		}
+	})
+}
+
+func (p *SessionPool) closed() bool {
+	select {
+	case <-p.done:
+		return true
+	default:
+		return false
+	}
+}
+
+func (p *SessionPool) acquire(ctx context.Context) (client.Session, error) {
+	if p.closed() {
+		return client.Session{}, ErrPoolClosed
+	}
+
+	timer := time.NewTimer(p.config.AcquireTimeout)
+	defer timer.Stop()
+	select {
+	case p.slots <- struct{}{}:
+	case <-timer.C:
+		return client.Session{}, wrapError("acquire session", "", ErrTimeout)
+	case <-ctx.Done():
+		return client.Session{}, wrapError("acquire session", "", ctx.Err())
+	case <-p.done:
+		return client.Session{}, ErrPoolClosed
+	}
+
+	select {
+	case session := <-p.idle:
+		return session, nil
+	default:
+	}
+
+	session, err := p.connect(ctx)
+	if err != nil {
+		<-p.slots
+		return client.Session{}, err
+	}
+	return session, nil
+}
+
+// tryAcquire reserves a session only if one can be had without waiting for another caller:
+// an idle session, or a single connection attempt when a slot is free. Partitioned scans use
+// it to borrow extra sessions, so a busy pool slows them down instead of deadlocking them.
+func (p *SessionPool) tryAcquire() (client.Session, bool) {
+	if p.closed() {
+		return client.Session{}, false
+	}
+	select {
+	case p.slots <- struct{}{}:
+	default:
+		return client.Session{}, false
+	}
+
+	select {
+	case session := <-p.idle:
+		return session, true
+	default:
+	}
+
+	session, err := p.open()
+	if err != nil {
+		<-p.slots
+		return client.Session{}, false
+	}
+	return session, true
+}
+
+func (p *SessionPool) release(session client.Session, broken bool) {
+	defer func() { <-p.slots }()
+
+	if broken || p.closed() {
+		session.Close()
+		return
+	}
+	select {
+	case p.idle <- session:
+	default:
+		session.Close()
+	}
+}
+
+// open makes a single connection attempt.
+func (p *SessionPool) open() (client.Session, error) {
+	session := client.NewSession(&client.Config{
+		Host:     p.config.Host,
+		Port:     p.config.Port,
+		UserName: p.config.User,
+		Password: p.config.Password,
+	})
+	if err := session.Open(false, p.config.ConnectTimeoutMs); err != nil {
+		return client.Session{}, err
+	}
+	return session, nil
+}
+
+// connect opens a new session, retrying with exponential backoff.
+func (p *SessionPool) connect(ctx context.Context) (client.Session, error) {
+	var err error
+	for attempt := 0; ; attempt++ {
+		var session client.Session
+		if session, err = p.open(); err == nil {
+			return session, nil
+		}
+		if attempt >= p.config.MaxRetries {
+			break
+		}
+		log.Printf("Failed to connect to IoTDB at %s:%s, retrying (%d/%d): %v\n", p.config.Host, p.config.Port, attempt+1, p.config.MaxRetries, err)
+		if err := p.sleep(ctx, p.backoff(attempt)); err != nil {
+			return client.Session{}, err
+		}
+	}
+	return client.Session{}, wrapError("connect to", p.config.Host+":"+p.config.Port, err)
+}
+
+// backoff doubles the delay with every attempt and adds up to 20% jitter.
+func (p *SessionPool) backoff(attempt int) time.Duration {
+	delay := p.config.InitialBackoff << attempt
+	if delay <= 0 || delay > p.config.MaxBackoff {
+		delay = p.config.MaxBackoff
+	}
+	return delay + time.Duration(rand.Int64N(int64(delay)/5+1))
+}
+
+// sleep waits for d and fails early when ctx is done or the pool is closed.
+func (p *SessionPool) sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return wrapError("wait for IoTDB", "", ctx.Err())
+	case <-p.done:
+		return ErrPoolClosed
+	}
+}
+
+func (p *SessionPool) healthCheckLoop() {
+	ticker := time.NewTicker(p.config.HealthCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			p.checkIdleSessions()
+		case <-p.done:
+			return
+		}
+	}
+}
+
+// checkIdleSessions pings every session that is idle right now and drops the broken ones.
+// Replacements are opened lazily by the next acquire.
+func (p *SessionPool) checkIdleSessions() {
+	timeout := int64(p.config.ConnectTimeoutMs)
+	for n := len(p.idle); n > 0; n-- {
+		var session client.Session
+		select {
+		case session = <-p.idle:
+		default:
+			return
+		}
+
+		ds, err := session.ExecuteQueryStatement("show version", &timeout)
+		if err != nil {
+			log.Printf("Dropping unhealthy IoTDB session: %v\n", err)
+			session.Close()
+			continue
+		}
+		ds.Close()
+
+		if p.closed() {
+			session.Close()
+			continue
+		}
+		select {
+		case p.idle <- session:
+		default:
+			session.Close()
+		}
+	}
+}
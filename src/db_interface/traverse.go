@@ -1,33 +1,99 @@
 package db_interface
 
 import (
-	"log"
+	"bdgp2025/src/utils/filter"
+	"context"
 
 	"github.com/apache/iotdb-client-go/v2/client"
 )
 
-func TraverseWithProcess(session client.Session, deviceId string, timeout int64, processFunc func(float64), targetColumn int32) error {
-	sql := "select * from " + deviceId
+// TraverseWithProcess calls processFunc with the value of targetColumn in every row of deviceId
+// matching filterExpr. Rows in which the column is null are skipped.
+func TraverseWithProcess(ctx context.Context, session client.Session, deviceId string, filterExpr *filter.Expression, timeout int64, processFunc func(float64), targetColumn int32) error {
+	return TraverseWithTime(ctx, session, deviceId, filterExpr, timeout, func(_ int64, value float64) { processFunc(value) }, targetColumn)
+}
+
+// TraverseWithTime is TraverseWithProcess for analyses over time: processFunc also receives
+// the timestamp of each row, and rows arrive in time order.
+func TraverseWithTime(ctx context.Context, session client.Session, deviceId string, filterExpr *filter.Expression, timeout int64, processFunc func(timestamp int64, value float64), targetColumn int32) error {
+	sql, errBuild := SelectQuery{Device: deviceId, Where: filterExpr}.Build()
+	if errBuild != nil {
+		return errBuild
+	}
 
-	_, columnTypes, errMetadata := FetchMetadata(session, deviceId, timeout)
+	columnNames, columnTypes, errMetadata := FetchMetadata(ctx, session, deviceId, timeout)
 	if errMetadata != nil {
-		log.Fatal(errMetadata)
 		return errMetadata
 	}
+	if targetColumn < 1 || int(targetColumn) >= len(columnTypes) {
+		return wrapError("traverse", deviceId, ErrMeasurementNotFound)
+	}
 
-	if ds, err := session.ExecuteQueryStatement(sql, &timeout); err == nil {
-		for next, err := ds.Next(); err == nil && next; next, err = ds.Next() {
+	if ds, err := executeQuery(ctx, session, sql, timeout); err == nil {
+		defer ds.Close()
+		var next bool
+		var errNext error
+		for next, errNext = ds.Next(); errNext == nil && next; next, errNext = ds.Next() {
+			if errCtx := checkContext(ctx, "scan", deviceId); errCtx != nil {
+				return errCtx
+			}
 			var index int32 = targetColumn + 1 //For Get***ByIndex(), index 1 is timestamp.
 			columnType := columnTypes[targetColumn]
-			data, err := fetchDataByColumnType(ds, columnType, index)
+			data, isNull, err := fetchNullableData(ds, columnType, index)
 			if err != nil {
-				log.Fatal(err)
+				return wrapError("read "+columnNames[targetColumn]+" of", deviceId, err)
+			}
+			if isNull {
+				continue
 			}
-			processFunc(data)
+			timestamp, err := ds.GetLongByIndex(1) // For Get***ByIndex(), index 1 is timestamp
+			if err != nil {
+				return wrapError("read timestamp of", deviceId, err)
+			}
+			processFunc(timestamp, data)
+		}
+		if errNext != nil {
+			return wrapError("scan", deviceId, errNext)
 		}
 	} else {
-		log.Fatal(err)
-		return err
+		return wrapError("query", deviceId, err)
 	}
 	return nil
 }
+
+// CollectMeasurement returns the non-null values of one measurement of deviceId in the rows
+// matching filterExpr, for analyses that need every value in memory
+func CollectMeasurement(ctx context.Context, session client.Session, deviceId string, measurement string, filterExpr *filter.Expression, timeout int64) ([]float64, error) {
+	columnNames, _, errMetadata := FetchMetadata(ctx, session, deviceId, timeout)
+	if errMetadata != nil {
+		return nil, errMetadata
+	}
+	index, errFind := findMeasurement(columnNames, deviceId, measurement)
+	if errFind != nil {
+		return nil, errFind
+	}
+
+	values := make([]float64, 0)
+	errTraverse := TraverseWithProcess(ctx, session, deviceId, filterExpr, timeout, func(v float64) { values = append(values, v) }, int32(index))
+	if errTraverse != nil {
+		return nil, errTraverse
+	}
+	return values, nil
+}
+
+// findMeasurement returns the index of measurement in the columns of deviceId
+func findMeasurement(columnNames []string, deviceId string, measurement string) (int, error) {
+	if err := ValidateMeasurement(measurement); err != nil {
+		return 0, err
+	}
+	device, errPath := ParseDevicePath(deviceId)
+	if errPath != nil {
+		return 0, errPath
+	}
+	for i := 1; i < len(columnNames); i++ {
+		if columnNames[i] == device.Measurement(measurement) {
+			return i, nil
+		}
+	}
+	return 0, wrapError("find "+measurement+" of", deviceId, ErrMeasurementNotFound)
+}
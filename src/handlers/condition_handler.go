@@ -2,21 +2,46 @@ package handlers
 
 import (
 	"bdgp2025/src/db_interface"
+	"bdgp2025/src/utils/filter"
+	"bdgp2025/src/utils/stats"
+	"context"
 	"fmt"
+	"strconv"
 
 	"github.com/apache/iotdb-client-go/v2/client"
 )
 
-// HandleConditionAnalysis 处理条件分析功能
-func HandleConditionAnalysis(session client.Session, deviceId string, timeout int64) (string, error) {
-	result, err := db_interface.GetConditionAnalysisResult(session, deviceId, timeout)
+type conditionAnalysisJSON struct {
+	Device     string                                             `json:"device"`
+	Conditions []groupStatisticsJSON                              `json:"conditions"`
+	Intervals  map[string]map[string]db_interface.ColumnIntervals `json:"intervals,omitempty"` // Keyed by condition, then column
+	Comparison db_interface.GroupComparison                       `json:"comparison"`
+}
+
+// HandleConditionAnalysis 处理条件分析功能，启用自助法时为均值、中位数和标准差附上置信区间，
+// 并给出各测量值在条件间的假设检验和效应量
+func HandleConditionAnalysis(ctx context.Context, session client.Session, deviceId string, filterExpr *filter.Expression, timeout int64, scan db_interface.ScanOptions, bootstrap stats.BootstrapConfig, format string) (string, error) {
+	result, err := db_interface.GetConditionAnalysisResult(ctx, session, deviceId, filterExpr, timeout, scan, bootstrap)
 	if err != nil {
 		return "", err
 	}
 
-	columnNames, _, err := db_interface.FetchMetadata(session, deviceId, timeout)
-	if err != nil {
-		return "", err
+	if format == FormatJSON {
+		output := conditionAnalysisJSON{Device: deviceId, Conditions: make([]groupStatisticsJSON, 0, len(result.ConditionValues)), Comparison: result.Comparison}
+		for _, conditionValue := range result.ConditionValues {
+			label := strconv.FormatInt(conditionValue, 10)
+			output.Conditions = append(output.Conditions, groupStatisticsToJSON(label, result.Statistics[conditionValue], result.ColumnNames, result.NumericColumns))
+			if intervals, ok := result.Intervals[conditionValue]; ok {
+				if output.Intervals == nil {
+					output.Intervals = make(map[string]map[string]db_interface.ColumnIntervals)
+				}
+				output.Intervals[label] = make(map[string]db_interface.ColumnIntervals)
+				for _, i := range result.NumericColumns {
+					output.Intervals[label][result.ColumnNames[i]] = intervals[i]
+				}
+			}
+		}
+		return marshalJSON(output)
 	}
 
 	var output string
@@ -27,28 +52,102 @@ func HandleConditionAnalysis(session client.Session, deviceId string, timeout in
 
 	// 为每个条件值打印统计信息
 	for _, conditionValue := range result.ConditionValues {
-		stats := result.Statistics[conditionValue]
 		output += fmt.Sprintf("Condition %d:\n", conditionValue)
-		output += fmt.Sprintf("  Count: %d\n", stats.Cnt)
-
-		output += "  Column Statistics:\n"
-		for i := 1; i < len(columnNames); i++ {
-			output += fmt.Sprintf("    %s:\n", columnNames[i])
-			output += fmt.Sprintf("      Sum: %.2f\n", stats.Sum[i])
-			output += fmt.Sprintf("      Mean: %.2f\n", stats.Mean[i])
-			output += fmt.Sprintf("      Variance: %.2f\n", stats.Variance[i])
-			output += fmt.Sprintf("      StdDev: %.2f\n", stats.StdDev[i])
-			output += fmt.Sprintf("      Min: %.2f\n", stats.Min[i])
-			output += fmt.Sprintf("      Max: %.2f\n", stats.Max[i])
-			output += fmt.Sprintf("      Median: %.2f\n", stats.Median[i])
-			output += fmt.Sprintf("      Q1: %.2f\n", stats.Q1[i])
-			output += fmt.Sprintf("      Q3: %.2f\n", stats.Q3[i])
-			output += fmt.Sprintf("      IQR: %.2f\n", stats.IQR[i])
-			output += fmt.Sprintf("      Skewness: %.2f\n", stats.Skewness[i])
-			output += fmt.Sprintf("      Kurtosis: %.2f\n", stats.Kurtosis[i])
+		output += formatDetailedStatistics(result.Statistics[conditionValue], result.ColumnNames, result.NumericColumns)
+		if intervals, ok := result.Intervals[conditionValue]; ok {
+			output += formatIntervals(intervals, result.ColumnNames, result.NumericColumns, bootstrap)
 		}
 		output += "\n"
 	}
 
+	output += formatComparison(result.Comparison)
 	return output, nil
 }
+
+// formatDetailedStatistics 以文本形式输出一个分组在各数值列上的统计信息
+func formatDetailedStatistics(stats db_interface.DetailedStatisticsResult, columnNames []string, columns []int) string {
+	var output string
+	output += fmt.Sprintf("  Count: %d\n", stats.Cnt)
+
+	output += "  Column Statistics:\n"
+	for _, i := range columns {
+		output += fmt.Sprintf("    %s:\n", columnNames[i])
+		output += fmt.Sprintf("      NullCount: %d\n", stats.NullCount[i])
+		output += fmt.Sprintf("      DistinctCount: %s\n", formatDistinctCount(stats.DistinctCount[i], stats.DistinctExact[i]))
+		output += fmt.Sprintf("      Mode: %g (%s)\n", stats.Mode[i], formatShare(stats.ModeCount[i], stats.ColumnCount(i)))
+		output += fmt.Sprintf("      Entropy: %.2f bits\n", stats.Entropy[i])
+		output += fmt.Sprintf("      Sum: %.2f\n", stats.Sum[i])
+		output += fmt.Sprintf("      Mean: %.2f\n", stats.Mean[i])
+		output += fmt.Sprintf("      Variance: %.2f\n", stats.Variance[i])
+		output += fmt.Sprintf("      StdDev: %.2f\n", stats.StdDev[i])
+		output += fmt.Sprintf("      Min: %.2f\n", stats.Min[i])
+		output += fmt.Sprintf("      Max: %.2f\n", stats.Max[i])
+		output += fmt.Sprintf("      Median: %.2f\n", stats.Median[i])
+		output += fmt.Sprintf("      Q1: %.2f\n", stats.Q1[i])
+		output += fmt.Sprintf("      Q3: %.2f\n", stats.Q3[i])
+		output += fmt.Sprintf("      IQR: %.2f\n", stats.IQR[i])
+		output += fmt.Sprintf("      Skewness: %.2f\n", stats.Skewness[i])
+		output += fmt.Sprintf("      Kurtosis: %.2f\n", stats.Kurtosis[i])
+	}
+	return output
+}
+
+// formatIntervals 以文本形式输出各数值列均值、中位数和标准差的自助法置信区间
+func formatIntervals(intervals []db_interface.ColumnIntervals, columnNames []string, columns []int, bootstrap stats.BootstrapConfig) string {
+	var output string
+	output += fmt.Sprintf("  Bootstrap %g%% Confidence Intervals (percentile | BCa, %d resamples):\n", 100*bootstrap.Confidence, bootstrap.Resamples)
+	for _, i := range columns {
+		output += fmt.Sprintf("    %s:\n", columnNames[i])
+		output += fmt.Sprintf("      Mean: %s\n", formatInterval(intervals[i].Mean))
+		output += fmt.Sprintf("      Median: %s\n", formatInterval(intervals[i].Median))
+		output += fmt.Sprintf("      StdDev: %s\n", formatInterval(intervals[i].StdDev))
+	}
+	return output
+}
+
+func formatInterval(interval stats.BootstrapInterval) string {
+	return fmt.Sprintf("[%.2f, %.2f] | [%.2f, %.2f]", interval.Percentile.Lower, interval.Percentile.Upper, interval.BCa.Lower, interval.BCa.Upper)
+}
+
+// formatComparison 以文本形式输出各测量值在分组间的假设检验结果，并按区分能力排序
+func formatComparison(comparison db_interface.GroupComparison) string {
+	var output string
+	output += "Differences Between Groups\n"
+	output += "==========================\n"
+	output += fmt.Sprintf("Benjamini-Hochberg adjusted p-values, * marks a difference at FDR %g%%\n\n", 100*db_interface.ComparisonAlpha)
+
+	for _, measurement := range comparison.Measurements {
+		output += fmt.Sprintf("  %s:\n", measurement.Name)
+		if len(measurement.Tests) == 0 {
+			output += "    not enough values to test\n"
+		}
+		for _, test := range measurement.Tests {
+			mark := ""
+			if test.Significant() {
+				mark = " *"
+			}
+			output += fmt.Sprintf("    %s: %.4f, p = %.4g, adjusted p = %.4g%s\n", test.Name, test.Statistic, test.PValue, test.AdjustedP, mark)
+		}
+		for _, pair := range measurement.EffectSizes {
+			output += fmt.Sprintf("    Effect sizes (%s vs %s):\n", pair.GroupA, pair.GroupB)
+			for _, size := range pair.Sizes {
+				output += fmt.Sprintf("      %s: %.4f (%s)\n", size.Name, size.Value, size.Magnitude)
+			}
+		}
+	}
+
+	scoreName := "Kolmogorov-Smirnov distance"
+	if len(comparison.Groups) > 2 {
+		scoreName = "Kruskal-Wallis epsilon squared"
+	}
+	output += fmt.Sprintf("\nMost Discriminating Attributes (by %s):\n", scoreName)
+	for rank, m := range comparison.Ranking {
+		measurement := comparison.Measurements[m]
+		verdict := "no significant difference"
+		if p := measurement.MinAdjustedP(); p < db_interface.ComparisonAlpha {
+			verdict = fmt.Sprintf("min adjusted p = %.4g", p)
+		}
+		output += fmt.Sprintf("  %d. %s: %.4f, %s\n", rank+1, measurement.Name, measurement.Score, verdict)
+	}
+	return output
+}
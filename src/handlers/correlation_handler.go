@@ -2,40 +2,258 @@ package handlers
 
 import (
 	"bdgp2025/src/db_interface"
+	"bdgp2025/src/utils/filter"
+	"bdgp2025/src/utils/stats"
+	"context"
 	"fmt"
+	"slices"
+	"strings"
+	"text/tabwriter"
 
 	"github.com/apache/iotdb-client-go/v2/client"
 )
 
-// HandleCorrelationCalc 处理相关性计算功能
-func HandleCorrelationCalc(session client.Session, deviceId string, timeout int64) (string, error) {
-	result, err := db_interface.GetCorrelationResult(session, deviceId, timeout)
+// correlationTitles names the coefficient of each correlation method
+var correlationTitles = map[string]string{
+	stats.CorrelationPearson:  "Pearson's r",
+	stats.CorrelationSpearman: "Spearman's rho",
+	stats.CorrelationKendall:  "Kendall's tau-b",
+}
+
+type correlationTestJSON struct {
+	Coefficient float64                  `json:"coefficient"`
+	PValue      float64                  `json:"p_value"`
+	AdjustedP   float64                  `json:"adjusted_p"` // Benjamini-Hochberg across the pairs
+	Significant bool                     `json:"significant"`
+	Interval    stats.Interval           `json:"interval"` // Fisher-z
+	Bootstrap   *stats.BootstrapInterval `json:"bootstrap,omitempty"`
+}
+
+type correlationPairJSON struct {
+	X       string                         `json:"x"`
+	Y       string                         `json:"y"`
+	N       int64                          `json:"n"`
+	Methods map[string]correlationTestJSON `json:"methods"`
+	Partial *correlationTestJSON           `json:"partial,omitempty"` // Nil for pairs involving a control
+}
+
+type correlationJSON struct {
+	Device     string                 `json:"device"`
+	Columns    []string               `json:"columns"`
+	Methods    []string               `json:"methods"`
+	Confidence float64                `json:"confidence"`
+	Matrices   map[string][][]float64 `json:"matrices"`
+	Counts     [][]int64              `json:"counts"`
+	Pairs      []correlationPairJSON  `json:"pairs"`
+	Partial    [][]float64            `json:"partial,omitempty"`
+	Controls   []string               `json:"controls,omitempty"`
+}
+
+// HandleCorrelationCalc 处理相关性计算功能，可选 Pearson、Spearman 和 Kendall 方法，多种方法时并排对比每对列的系数；
+// 每对列给出样本量、显著性检验、Fisher-z 置信区间和多重检验校正后的显著性标记，启用自助法时附上 Pearson 相关系数的 BCa 置信区间；
+// 请求偏相关时在 Pearson 矩阵旁给出控制其余列或指定控制变量后的偏相关矩阵
+func HandleCorrelationCalc(ctx context.Context, session client.Session, deviceId string, filterExpr *filter.Expression, timeout int64, scan db_interface.ScanOptions, bootstrap stats.BootstrapConfig, options db_interface.CorrelationOptions, format string) (string, error) {
+	result, err := db_interface.GetCorrelationResult(ctx, session, deviceId, filterExpr, timeout, scan, bootstrap, options)
 	if err != nil {
 		return "", err
 	}
 
-	columnNames, _, err := db_interface.FetchMetadata(session, deviceId, timeout)
-	if err != nil {
-		return "", err
+	columns := result.Columns
+
+	if format == FormatJSON {
+		output := correlationJSON{
+			Device:     deviceId,
+			Columns:    columns,
+			Methods:    result.Methods,
+			Confidence: db_interface.CorrelationConfidence,
+			Matrices:   make(map[string][][]float64, len(result.Methods)),
+			Counts:     result.Counts,
+			Pairs:      []correlationPairJSON{},
+			Partial:    result.PartialCorrelation,
+			Controls:   result.Controls,
+		}
+		controlled := controlColumns(result, columns)
+		for _, method := range result.Methods {
+			output.Matrices[method] = result.Matrix(method)
+		}
+		for i := 0; i < len(result.PearsonCorrelation); i++ {
+			for j := i + 1; j < len(result.PearsonCorrelation); j++ {
+				pair := correlationPairJSON{X: columns[i], Y: columns[j], N: result.Counts[i][j], Methods: make(map[string]correlationTestJSON, len(result.Methods))}
+				for _, method := range result.Methods {
+					test := result.Tests[method][i][j]
+					pairTest := correlationTestJSON{
+						Coefficient: result.Matrix(method)[i][j],
+						PValue:      test.PValue,
+						AdjustedP:   test.AdjustedP,
+						Significant: test.AdjustedP < db_interface.ComparisonAlpha,
+						Interval:    test.Interval,
+					}
+					if method == stats.CorrelationPearson && result.Intervals != nil {
+						pairTest.Bootstrap = &result.Intervals[i][j]
+					}
+					pair.Methods[method] = pairTest
+				}
+				if result.PartialCorrelation != nil && !controlled[i] && !controlled[j] {
+					test := result.PartialTests[i][j]
+					pair.Partial = &correlationTestJSON{
+						Coefficient: result.PartialCorrelation[i][j],
+						PValue:      test.PValue,
+						AdjustedP:   test.AdjustedP,
+						Significant: test.AdjustedP < db_interface.ComparisonAlpha,
+						Interval:    test.Interval,
+					}
+				}
+				output.Pairs = append(output.Pairs, pair)
+			}
+		}
+		return marshalJSON(output)
+	}
+
+	controlled := controlColumns(result, columns)
+	var output string
+	for k, method := range result.Methods {
+		// Pearson alone keeps the bare matrix of earlier versions
+		if len(result.Methods) > 1 || method != stats.CorrelationPearson {
+			if k > 0 {
+				output += "\n"
+			}
+			output += correlationTitles[method] + ":\n"
+		}
+		output += formatCorrelationMatrix(result.Matrix(method), result.Tests[method], columns, nil)
+
+		// 添加自助法置信区间矩阵
+		if method == stats.CorrelationPearson && result.Intervals != nil {
+			output += fmt.Sprintf("\nBootstrap %g%% Confidence Intervals (BCa, %d resamples):\n", 100*bootstrap.Confidence, bootstrap.Resamples)
+			output += "\t"
+			for _, column := range columns {
+				output += fmt.Sprintf("%s\t", column)
+			}
+			output += "\n"
+			for i := 0; i < len(result.Intervals); i++ {
+				output += fmt.Sprintf("%s\t", columns[i])
+				for j := 0; j < len(result.Intervals[i]); j++ {
+					if i == j {
+						output += "-\t"
+						continue
+					}
+					output += fmt.Sprintf("[%.4f, %.4f]\t", result.Intervals[i][j].BCa.Lower, result.Intervals[i][j].BCa.Upper)
+				}
+				output += "\n"
+			}
+		}
+
+		// 偏相关矩阵紧跟 Pearson 矩阵
+		if method == stats.CorrelationPearson && result.PartialCorrelation != nil {
+			output += "\n" + formatPartialCorrelation(result, columns, controlled)
+		}
 	}
+	if !slices.Contains(result.Methods, stats.CorrelationPearson) && result.PartialCorrelation != nil {
+		output += "\n" + formatPartialCorrelation(result, columns, controlled)
+	}
+
+	output += "\nSignificance (Benjamini-Hochberg adjusted p): * < 0.05, ** < 0.01, *** < 0.001\n"
+	output += "\n" + formatCorrelationComparison(result, columns, controlled)
+	return output, nil
+}
+
+// significanceStars 按校正后的 p 值给出显著性标记
+func significanceStars(adjustedP float64) string {
+	switch {
+	case adjustedP < 0.001:
+		return "***"
+	case adjustedP < 0.01:
+		return "**"
+	case adjustedP < 0.05:
+		return "*"
+	}
+	return ""
+}
+
+// controlColumns 标记偏相关的控制变量所在的矩阵下标
+func controlColumns(result db_interface.CorrelationResult, columns []string) []bool {
+	controlled := make([]bool, len(columns))
+	for i := range controlled {
+		controlled[i] = slices.Contains(result.Controls, columns[i])
+	}
+	return controlled
+}
+
+// formatPartialCorrelation 输出偏相关矩阵，控制变量所在的行列以 - 表示
+func formatPartialCorrelation(result db_interface.CorrelationResult, columns []string, controlled []bool) string {
+	if len(result.Controls) == 0 {
+		return "Partial Correlation (controlling for all other columns):\n" +
+			formatCorrelationMatrix(result.PartialCorrelation, result.PartialTests, columns, controlled)
+	}
+	return fmt.Sprintf("Partial Correlation (controlling for %s):\n", strings.Join(result.Controls, ", ")) +
+		formatCorrelationMatrix(result.PartialCorrelation, result.PartialTests, columns, controlled)
+}
 
+// formatCorrelationMatrix 以制表符分隔的矩阵输出一种相关系数，非对角元素附显著性标记，omitted 标记的行列以 - 表示
+func formatCorrelationMatrix(matrix [][]float64, tests [][]stats.CorrelationTest, columns []string, omitted []bool) string {
 	var output string
 
 	// 添加标题行
 	output += "\t"
-	for i := 1; i < len(columnNames); i++ {
-		output += fmt.Sprintf("%s\t", columnNames[i])
+	for _, column := range columns {
+		output += fmt.Sprintf("%s\t", column)
 	}
 	output += "\n"
 
 	// 添加相关性矩阵
-	for i := 0; i < len(result.PearsonCorrelation); i++ {
-		output += fmt.Sprintf("%s\t", columnNames[i+1])
-		for j := 0; j < len(result.PearsonCorrelation[i]); j++ {
-			output += fmt.Sprintf("%.4f\t", result.PearsonCorrelation[i][j])
+	for i := 0; i < len(matrix); i++ {
+		output += fmt.Sprintf("%s\t", columns[i])
+		for j := 0; j < len(matrix[i]); j++ {
+			if omitted != nil && (omitted[i] || omitted[j]) {
+				output += "-\t"
+				continue
+			}
+			stars := ""
+			if i != j {
+				stars = significanceStars(tests[i][j].AdjustedP)
+			}
+			output += fmt.Sprintf("%.4f%s\t", matrix[i][j], stars)
 		}
 		output += "\n"
 	}
+	return output
+}
 
-	return output, nil
+// formatCorrelationComparison 逐对输出样本量以及各方法下的相关系数、Fisher-z 置信区间和校正后的 p 值，
+// 多种方法时并排对比；秩相关与 Pearson 相差较大说明关系非线性或受离群值影响
+func formatCorrelationComparison(result db_interface.CorrelationResult, columns []string, controlled []bool) string {
+	var sb strings.Builder
+	if len(result.Methods) > 1 || result.PartialCorrelation != nil {
+		sb.WriteString("Side-by-side Comparison:\n")
+	} else {
+		sb.WriteString("Pair Tests:\n")
+	}
+	tw := tabwriter.NewWriter(&sb, 0, 0, 2, ' ', 0)
+	fmt.Fprint(tw, "Pair\tN")
+	for _, method := range result.Methods {
+		fmt.Fprintf(tw, "\t%s\t%g%% CI\tAdjusted p", correlationTitles[method], 100*db_interface.CorrelationConfidence)
+	}
+	if result.PartialCorrelation != nil {
+		fmt.Fprintf(tw, "\tPartial r\t%g%% CI\tAdjusted p", 100*db_interface.CorrelationConfidence)
+	}
+	fmt.Fprintln(tw)
+	for i := 0; i < len(result.PearsonCorrelation); i++ {
+		for j := i + 1; j < len(result.PearsonCorrelation); j++ {
+			fmt.Fprintf(tw, "%s ~ %s\t%d", columns[i], columns[j], result.Counts[i][j])
+			for _, method := range result.Methods {
+				test := result.Tests[method][i][j]
+				fmt.Fprintf(tw, "\t%.4f%s\t[%.4f, %.4f]\t%.4g", result.Matrix(method)[i][j], significanceStars(test.AdjustedP), test.Interval.Lower, test.Interval.Upper, test.AdjustedP)
+			}
+			if result.PartialCorrelation != nil {
+				if controlled[i] || controlled[j] {
+					fmt.Fprint(tw, "\t-\t-\t-")
+				} else {
+					test := result.PartialTests[i][j]
+					fmt.Fprintf(tw, "\t%.4f%s\t[%.4f, %.4f]\t%.4g", result.PartialCorrelation[i][j], significanceStars(test.AdjustedP), test.Interval.Lower, test.Interval.Upper, test.AdjustedP)
+				}
+			}
+			fmt.Fprintln(tw)
+		}
+	}
+	tw.Flush()
+	return sb.String()
 }
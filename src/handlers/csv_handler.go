@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"bdgp2025/src/utils"
+	"context"
 	"fmt"
 	"log"
 	"path/filepath"
@@ -11,7 +12,7 @@ import (
 )
 
 // HandleCSVImport 处理CSV文件导入功能
-func HandleCSVImport(csvFile string, session client.Session, deviceId string) error {
+func HandleCSVImport(ctx context.Context, csvFile string, session client.Session, deviceId string) error {
 	// 检查文件扩展名
 	ext := strings.ToLower(filepath.Ext(csvFile))
 	if ext != ".csv" {
@@ -19,6 +20,5 @@ func HandleCSVImport(csvFile string, session client.Session, deviceId string) er
 	}
 
 	log.Printf("Importing data from CSV file: %s", csvFile)
-	utils.ImportCSVFile(csvFile, utils.ReadinCSVOneByOne, session, deviceId)
-	return nil
+	return utils.ImportCSVFile(ctx, csvFile, utils.ReadinCSVOneByOne, session, deviceId)
 }
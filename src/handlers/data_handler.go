@@ -0,0 +1,18 @@
+package handlers
+
+import (
+	"bdgp2025/src/db_interface"
+	"context"
+
+	"github.com/apache/iotdb-client-go/v2/client"
+)
+
+// HandleRawDataQuery 处理原始数据分页浏览功能，返回JSON格式的一页数据
+func HandleRawDataQuery(ctx context.Context, session client.Session, deviceId string, startTime int64, endTime int64, limit int, cursor string, timeout int64) (string, error) {
+	page, err := db_interface.GetRawDataPage(ctx, session, deviceId, startTime, endTime, limit, cursor, timeout)
+	if err != nil {
+		return "", err
+	}
+
+	return marshalJSON(page)
+}
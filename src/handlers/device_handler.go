@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"bdgp2025/src/db_interface"
+	"context"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/apache/iotdb-client-go/v2/client"
+)
+
+// HandleDeviceList 处理设备发现功能，列出所有设备及其数据量和时间范围
+func HandleDeviceList(ctx context.Context, session client.Session, timeout int64, format string) (string, error) {
+	devices, err := db_interface.ListDevices(ctx, session, timeout)
+	if err != nil {
+		return "", err
+	}
+
+	if format == FormatJSON {
+		return marshalJSON(devices)
+	}
+
+	var sb strings.Builder
+	tw := tabwriter.NewWriter(&sb, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "Device\tAligned\tTimeseries\tRows\tFirst\tLast")
+	for _, device := range devices {
+		fmt.Fprintf(tw, "%s\t%t\t%d\t%d\t%s\t%s\n",
+			device.Path, device.IsAligned, device.TimeseriesCount, device.RowCount,
+			formatTimestamp(device.FirstTimestamp), formatTimestamp(device.LastTimestamp))
+	}
+	tw.Flush()
+
+	return sb.String(), nil
+}
+
+// HandleTimeseriesList 处理时间序列发现功能，列出设备下所有测量值的类型、编码和数据量
+func HandleTimeseriesList(ctx context.Context, session client.Session, deviceId string, timeout int64, format string) (string, error) {
+	timeseries, err := db_interface.ListTimeseries(ctx, session, deviceId, timeout)
+	if err != nil {
+		return "", err
+	}
+
+	if format == FormatJSON {
+		return marshalJSON(timeseries)
+	}
+
+	var sb strings.Builder
+	tw := tabwriter.NewWriter(&sb, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "Measurement\tDataType\tEncoding\tCompression\tRows\tFirst\tLast")
+	for _, ts := range timeseries {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%d\t%s\t%s\n",
+			ts.Measurement, ts.DataType, ts.Encoding, ts.Compression, ts.RowCount,
+			formatTimestamp(ts.FirstTimestamp), formatTimestamp(ts.LastTimestamp))
+	}
+	tw.Flush()
+
+	return sb.String(), nil
+}
+
+func formatTimestamp(timestamp *int64) string {
+	if timestamp == nil {
+		return "-"
+	}
+	return fmt.Sprintf("%d", *timestamp)
+}
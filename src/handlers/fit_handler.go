@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"bdgp2025/src/db_interface"
+	"bdgp2025/src/utils/filter"
+	"bdgp2025/src/utils/stats"
+	"context"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/apache/iotdb-client-go/v2/client"
+)
+
+type columnFitJSON struct {
+	Column string      `json:"column"`
+	Fits   []stats.Fit `json:"fits"`
+}
+
+type distributionFitJSON struct {
+	Device  string          `json:"device"`
+	Columns []columnFitJSON `json:"columns"`
+}
+
+// HandleDistributionFit 处理分布拟合功能，按 AIC 排序列出每个数值列的候选分布及其参数
+func HandleDistributionFit(ctx context.Context, session client.Session, deviceId string, filterExpr *filter.Expression, timeout int64, scan db_interface.ScanOptions, format string) (string, error) {
+	result, err := db_interface.GetDistributionFitResult(ctx, session, deviceId, filterExpr, timeout, scan)
+	if err != nil {
+		return "", err
+	}
+
+	if format == FormatJSON {
+		output := distributionFitJSON{Device: deviceId, Columns: make([]columnFitJSON, 0, len(result.NumericColumns))}
+		for _, i := range result.NumericColumns {
+			fits := result.Fits[i]
+			if fits == nil {
+				fits = []stats.Fit{}
+			}
+			output.Columns = append(output.Columns, columnFitJSON{Column: result.ColumnNames[i], Fits: fits})
+		}
+		return marshalJSON(output)
+	}
+
+	var sb strings.Builder
+	sb.WriteString("Distribution Fits\n")
+	sb.WriteString("=================\n\n")
+
+	for _, i := range result.NumericColumns {
+		best, ok := result.Best(i)
+		if !ok {
+			fmt.Fprintf(&sb, "%s: not enough distinct values to fit\n\n", result.ColumnNames[i])
+			continue
+		}
+		fmt.Fprintf(&sb, "%s (fitted on %d values):\n", result.ColumnNames[i], best.N)
+
+		tw := tabwriter.NewWriter(&sb, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "  Distribution\tAIC\tBIC\tKS\tRanks (AIC/BIC/KS)\tParameters")
+		for _, fit := range result.Fits[i] {
+			fmt.Fprintf(tw, "  %s\t%.2f\t%.2f\t%.4f\t%d/%d/%d\t%s\n",
+				fit.Distribution, fit.AIC, fit.BIC, fit.KS, fit.RankAIC, fit.RankBIC, fit.RankKS, formatParameters(fit.Parameters))
+		}
+		tw.Flush()
+		fmt.Fprintf(&sb, "  Best fit: %s (%s)\n\n", best.Distribution, formatParameters(best.Parameters))
+	}
+
+	return sb.String(), nil
+}
+
+func formatParameters(parameters []stats.Parameter) string {
+	parts := make([]string, len(parameters))
+	for i, p := range parameters {
+		parts[i] = fmt.Sprintf("%s=%.4g", p.Name, p.Value)
+	}
+	return strings.Join(parts, ", ")
+}
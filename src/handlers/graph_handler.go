@@ -2,16 +2,24 @@ package handlers
 
 import (
 	"bdgp2025/src/db_interface"
+	"bdgp2025/src/utils/filter"
 	"bdgp2025/src/utils/histogram"
+	"bdgp2025/src/utils/kde"
+	"bdgp2025/src/utils/stats"
+	"context"
 	"fmt"
 	"strconv"
+	"strings"
 
 	"github.com/apache/iotdb-client-go/v2/client"
 )
 
-// HandleStatisticGraph 处理统计图表生成功能
-func HandleStatisticGraph(session client.Session, deviceId string, timeout int64) (string, error) {
-	columnNames, _, err := db_interface.FetchMetadata(session, deviceId, timeout)
+var fileNameReplacer = strings.NewReplacer("/", "_", "\\", "_", "`", "")
+
+// HandleStatisticGraph 处理统计图表生成功能，并在直方图上叠加核密度估计与最佳拟合分布的密度曲线
+// 核密度估计与分布拟合都复用直方图扫描时收集的列数据
+func HandleStatisticGraph(ctx context.Context, session client.Session, deviceId string, filterExpr *filter.Expression, timeout int64) (string, error) {
+	columnNames, columnTypes, err := db_interface.FetchMetadata(ctx, session, deviceId, timeout)
 	if err != nil {
 		return "", err
 	}
@@ -21,15 +29,22 @@ func HandleStatisticGraph(session client.Session, deviceId string, timeout int64
 
 	var output string
 
-	for i := 1; i < len(hists); i++ {
+	for _, i := range db_interface.NumericColumnIndices(columnTypes) {
 		hists[i] = histogram.NewStreamingHistogram(histogram.DefaultConfig())
-		err := db_interface.TraverseWithProcess(session, deviceId, timeout, hists[i].AddValue, int32(i))
+		err := db_interface.TraverseWithProcess(ctx, session, deviceId, filterExpr, timeout, hists[i].AddValue, int32(i))
 		if err != nil {
 			return "", err
 		}
 
 		result := hists[i].Finalize()
-		filename := "output" + strconv.Itoa(i) + " " + columnNames[i] + ".html"
+		if density, err := kde.Estimate(hists[i].Values(), kde.DefaultConfig()); err == nil {
+			result.AddCurve(fmt.Sprintf("KDE (%s, h=%.3g)", density.Config.Kernel, density.Bandwidth), density.PDF)
+		}
+		if fits := stats.FitDistributions(hists[i].Values()); len(fits) > 0 {
+			result.AddCurve("Best fit: "+fits[0].Distribution, fits[0].PDF)
+		}
+		// Quoted path nodes may contain path separators, which must not leak into the file name
+		filename := "output" + strconv.Itoa(i) + " " + fileNameReplacer.Replace(columnNames[i]) + ".html"
 		err = result.SaveAsHTML(filename)
 		if err != nil {
 			return "", err
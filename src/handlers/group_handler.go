@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"bdgp2025/src/db_interface"
+	"bdgp2025/src/utils/filter"
+	"bdgp2025/src/utils/sketch"
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/apache/iotdb-client-go/v2/client"
+)
+
+type columnStatisticsJSON struct {
+	NullCount     int64                  `json:"null_count"`
+	DistinctCount int64                  `json:"distinct_count"`
+	DistinctExact bool                   `json:"distinct_exact"`
+	Mode          float64                `json:"mode"`
+	ModeCount     int64                  `json:"mode_count"`
+	TopValues     []sketch.FrequentValue `json:"top_values"`
+	Entropy       float64                `json:"entropy"`
+	Sum           float64                `json:"sum"`
+	Mean          float64                `json:"mean"`
+	Variance      float64                `json:"variance"`
+	StdDev        float64                `json:"std_dev"`
+	Min           float64                `json:"min"`
+	Max           float64                `json:"max"`
+	Median        float64                `json:"median"`
+	Q1            float64                `json:"q1"`
+	Q3            float64                `json:"q3"`
+	IQR           float64                `json:"iqr"`
+	Skewness      float64                `json:"skewness"`
+	Kurtosis      float64                `json:"kurtosis"`
+}
+
+type groupStatisticsJSON struct {
+	Group   string                          `json:"group"`
+	Count   int                             `json:"count"`
+	Columns map[string]columnStatisticsJSON `json:"columns"`
+}
+
+type groupAnalysisJSON struct {
+	Device  string                `json:"device"`
+	GroupBy db_interface.GroupBy  `json:"group_by"`
+	Groups  []groupStatisticsJSON `json:"groups"`
+}
+
+// HandleGroupAnalysis 处理分组分析功能，按任意分类列（或数值列的分箱）分组计算详细统计信息
+func HandleGroupAnalysis(ctx context.Context, session client.Session, deviceId string, filterExpr *filter.Expression, groupBy db_interface.GroupBy, timeout int64, scan db_interface.ScanOptions, format string) (string, error) {
+	result, err := db_interface.GetGroupAnalysisResult(ctx, session, deviceId, filterExpr, groupBy, timeout, scan)
+	if err != nil {
+		return "", err
+	}
+
+	if format == FormatJSON {
+		output := groupAnalysisJSON{Device: deviceId, GroupBy: groupBy, Groups: make([]groupStatisticsJSON, 0, len(result.Groups))}
+		for _, label := range result.Groups {
+			output.Groups = append(output.Groups, groupStatisticsToJSON(label, result.Statistics[label], result.ColumnNames, result.NumericColumns))
+		}
+		return marshalJSON(output)
+	}
+
+	var output string
+
+	// 添加标题
+	title := "Group Analysis by " + groupBy.Column
+	if groupBy.BinWidth > 0 {
+		title += fmt.Sprintf(" (bin width %g)", groupBy.BinWidth)
+	}
+	output += title + "\n"
+	output += strings.Repeat("=", len(title)) + "\n\n"
+
+	// 为每个分组打印统计信息
+	for _, label := range result.Groups {
+		output += fmt.Sprintf("Group %s:\n", label)
+		output += formatDetailedStatistics(result.Statistics[label], result.ColumnNames, result.NumericColumns)
+		output += "\n"
+	}
+
+	return output, nil
+}
+
+// groupStatisticsToJSON 将一个分组在各数值列上的统计信息转换为 JSON 结构
+func groupStatisticsToJSON(label string, stats db_interface.DetailedStatisticsResult, columnNames []string, columns []int) groupStatisticsJSON {
+	group := groupStatisticsJSON{Group: label, Count: stats.Cnt, Columns: make(map[string]columnStatisticsJSON)}
+	for _, i := range columns {
+		group.Columns[columnNames[i]] = columnStatisticsJSON{
+			NullCount: stats.NullCount[i], DistinctCount: stats.DistinctCount[i], DistinctExact: stats.DistinctExact[i],
+			Mode: stats.Mode[i], ModeCount: stats.ModeCount[i], TopValues: stats.TopValues[i], Entropy: stats.Entropy[i],
+			Sum: stats.Sum[i], Mean: stats.Mean[i], Variance: stats.Variance[i], StdDev: stats.StdDev[i],
+			Min: stats.Min[i], Max: stats.Max[i], Median: stats.Median[i], Q1: stats.Q1[i], Q3: stats.Q3[i],
+			IQR: stats.IQR[i], Skewness: stats.Skewness[i], Kurtosis: stats.Kurtosis[i],
+		}
+	}
+	return group
+}
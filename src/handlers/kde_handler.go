@@ -0,0 +1,31 @@
+package handlers
+
+import (
+	"bdgp2025/src/db_interface"
+	"bdgp2025/src/utils/filter"
+	"bdgp2025/src/utils/kde"
+	"context"
+	"fmt"
+
+	"github.com/apache/iotdb-client-go/v2/client"
+)
+
+type kdeJSON struct {
+	Device      string `json:"device"`
+	Measurement string `json:"measurement"`
+	*kde.Result
+}
+
+// HandleKDE 处理核密度估计功能，以 JSON 形式返回网格上的密度曲线
+func HandleKDE(ctx context.Context, session client.Session, deviceId string, measurement string, filterExpr *filter.Expression, config kde.Config, timeout int64) (string, error) {
+	values, err := db_interface.CollectMeasurement(ctx, session, deviceId, measurement, filterExpr, timeout)
+	if err != nil {
+		return "", err
+	}
+
+	result, err := kde.Estimate(values, config)
+	if err != nil {
+		return "", fmt.Errorf("estimate density of %s: %w", measurement, err)
+	}
+	return marshalJSON(kdeJSON{Device: deviceId, Measurement: measurement, Result: result})
+}
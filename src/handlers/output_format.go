@@ -0,0 +1,18 @@
+package handlers
+
+import "encoding/json"
+
+// Output formats accepted by handlers that can render either plain text or JSON
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// marshalJSON renders a handler result as a JSON string
+func marshalJSON(v interface{}) (string, error) {
+	output, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return string(output), nil
+}
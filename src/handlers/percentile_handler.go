@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"bdgp2025/src/db_interface"
+	"bdgp2025/src/utils/filter"
+	"bdgp2025/src/utils/stats"
+	"context"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/apache/iotdb-client-go/v2/client"
+)
+
+type percentilesJSON struct {
+	Device string `json:"device"`
+	db_interface.PercentileResult
+}
+
+// HandlePercentiles 处理任意百分位数功能，按 Hyndman-Fan 定义精确计算各测量值（可按列分组）的百分位数
+func HandlePercentiles(ctx context.Context, session client.Session, deviceId string, query db_interface.PercentileQuery, filterExpr *filter.Expression, timeout int64, format string) (string, error) {
+	result, err := db_interface.GetPercentilesResult(ctx, session, deviceId, query, filterExpr, timeout)
+	if err != nil {
+		return "", err
+	}
+
+	if format == FormatJSON {
+		return marshalJSON(percentilesJSON{Device: deviceId, PercentileResult: result})
+	}
+
+	var sb strings.Builder
+	title := fmt.Sprintf("Percentiles (Hyndman-Fan type %d)", result.Method)
+	if result.GroupBy != "" {
+		title += " by " + result.GroupBy
+	}
+	sb.WriteString(title + "\n")
+	sb.WriteString(strings.Repeat("=", len(title)) + "\n")
+
+	for _, group := range result.Groups {
+		sb.WriteString("\n")
+		if result.GroupBy != "" {
+			fmt.Fprintf(&sb, "Group %s:\n", group.Group)
+		}
+		tw := tabwriter.NewWriter(&sb, 0, 0, 2, ' ', tabwriter.AlignRight)
+		fmt.Fprint(tw, "\tCount\t")
+		for _, p := range result.Percentiles {
+			fmt.Fprintf(tw, "%s\t", stats.PercentileName(p))
+		}
+		fmt.Fprintln(tw)
+		for k, measurement := range result.Measurements {
+			fmt.Fprintf(tw, "%s:\t%d\t", measurement, group.Counts[k])
+			for j := range result.Percentiles {
+				if group.Values[k] == nil {
+					fmt.Fprint(tw, "-\t")
+				} else {
+					fmt.Fprintf(tw, "%.4f\t", group.Values[k][j])
+				}
+			}
+			fmt.Fprintln(tw)
+		}
+		tw.Flush()
+	}
+	return sb.String(), nil
+}
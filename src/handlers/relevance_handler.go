@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"bdgp2025/src/db_interface"
+	"bdgp2025/src/utils/filter"
+	"context"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/apache/iotdb-client-go/v2/client"
+)
+
+type relevanceJSON struct {
+	Device string `json:"device"`
+	db_interface.AssociationResult
+}
+
+// HandleRelevance 处理特征相关性功能，按与分类目标列（如 engine_condition）的关联强度对各测量值排序
+func HandleRelevance(ctx context.Context, session client.Session, deviceId string, target string, filterExpr *filter.Expression, timeout int64, format string) (string, error) {
+	result, err := db_interface.GetAssociationResult(ctx, session, deviceId, target, filterExpr, timeout)
+	if err != nil {
+		return "", err
+	}
+
+	if format == FormatJSON {
+		return marshalJSON(relevanceJSON{Device: deviceId, AssociationResult: result})
+	}
+
+	var sb strings.Builder
+	title := "Feature Relevance for " + result.Target
+	sb.WriteString(title + "\n")
+	sb.WriteString(strings.Repeat("=", len(title)) + "\n")
+	classes := make([]string, len(result.Classes))
+	for i, class := range result.Classes {
+		classes[i] = fmt.Sprintf("%s (%d)", class, result.ClassCounts[i])
+	}
+	fmt.Fprintf(&sb, "Classes: %s\n", strings.Join(classes, ", "))
+	fmt.Fprintf(&sb, "Mutual information in bits over a sample of %d rows\n\n", result.SampleSize)
+
+	binary := len(result.Classes) == 2
+	tw := tabwriter.NewWriter(&sb, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprint(tw, "Rank\tMeasurement\tN\tMI (KSG)\tMI (binned)\tANOVA F\tp-value\t")
+	if binary {
+		fmt.Fprint(tw, "Point-biserial r\tp-value\t")
+	}
+	fmt.Fprintln(tw)
+	for rank, feature := range result.Features {
+		fmt.Fprintf(tw, "%d\t%s\t%d\t%.4f\t%.4f\t", rank+1, feature.Measurement, feature.N, feature.MIKSG, feature.MIBinned)
+		if feature.ANOVA != nil {
+			fmt.Fprintf(tw, "%.4f\t%.4g\t", feature.ANOVA.Statistic, feature.ANOVA.PValue)
+		} else {
+			fmt.Fprint(tw, "-\t-\t")
+		}
+		if binary {
+			if feature.PointBiserial != nil {
+				fmt.Fprintf(tw, "%.4f\t%.4g\t", feature.PointBiserial.R, feature.PointBiserial.PValue)
+			} else {
+				fmt.Fprint(tw, "-\t-\t")
+			}
+		}
+		fmt.Fprintln(tw)
+	}
+	tw.Flush()
+	return sb.String(), nil
+}
@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"bdgp2025/src/db_interface"
+	"bdgp2025/src/utils/filter"
+	"bdgp2025/src/utils/rolling"
+	"bdgp2025/src/utils/stats"
+	"context"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/apache/iotdb-client-go/v2/client"
+)
+
+type rollingJSON struct {
+	Device      string `json:"device"`
+	Measurement string `json:"measurement"`
+	*rolling.Series
+}
+
+// HandleRolling 处理滚动窗口统计功能，按时间顺序输出每个窗口的均值、标准差、极值和百分位数
+func HandleRolling(ctx context.Context, session client.Session, deviceId string, measurement string, filterExpr *filter.Expression, config rolling.Config, timeout int64, format string) (string, error) {
+	series, err := db_interface.GetRollingResult(ctx, session, deviceId, measurement, filterExpr, config, timeout)
+	if err != nil {
+		return "", err
+	}
+
+	if format == FormatJSON {
+		return marshalJSON(rollingJSON{Device: deviceId, Measurement: measurement, Series: series})
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Rolling Statistics of %s\n", measurement)
+	sb.WriteString("==========================\n")
+	fmt.Fprintf(&sb, "Window: %s, Step: %s, Windows: %d\n\n", formatWindowLength(series.Config.Kind, series.Config.Length), formatWindowLength(series.Config.Kind, series.Config.Step), series.Len())
+
+	tw := tabwriter.NewWriter(&sb, 0, 0, 2, ' ', 0)
+	fmt.Fprint(tw, "Start\tEnd\tCount\tMean\tStdDev\tMin\tMax")
+	for _, p := range series.Config.Percentiles {
+		fmt.Fprintf(tw, "\t%s", stats.PercentileName(p))
+	}
+	fmt.Fprintln(tw)
+	for w := 0; w < series.Len(); w++ {
+		fmt.Fprintf(tw, "%d\t%d\t%d\t%.2f\t%.2f\t%.2f\t%.2f", series.Start[w], series.End[w], series.Count[w], series.Mean[w], series.StdDev[w], series.Min[w], series.Max[w])
+		for _, p := range series.Config.Percentiles {
+			fmt.Fprintf(tw, "\t%.2f", series.Percentiles[stats.PercentileName(p)][w])
+		}
+		fmt.Fprintln(tw)
+	}
+	tw.Flush()
+	return sb.String(), nil
+}
+
+func formatWindowLength(kind string, length int64) string {
+	if kind == rolling.KindCount {
+		return fmt.Sprintf("%d rows", length)
+	}
+	return (time.Duration(length) * time.Millisecond).String()
+}
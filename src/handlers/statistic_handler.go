@@ -2,37 +2,152 @@ package handlers
 
 import (
 	"bdgp2025/src/db_interface"
+	"bdgp2025/src/utils/filter"
+	"context"
 	"fmt"
-	"reflect"
+	"strings"
+	"text/tabwriter"
 
 	"github.com/apache/iotdb-client-go/v2/client"
 )
 
-// HandleStatisticCalc 处理统计计算功能
-func HandleStatisticCalc(session client.Session, deviceId string, timeout int64) (string, error) {
-	result, err := db_interface.GetStatisticsResult(session, deviceId, timeout)
+// HandleStatisticCalc 处理统计计算功能，以表格形式输出每个数值列的描述性统计量
+func HandleStatisticCalc(ctx context.Context, session client.Session, deviceId string, filterExpr *filter.Expression, timeout int64, scan db_interface.ScanOptions) (string, error) {
+	result, err := db_interface.GetStatisticsResult(ctx, session, deviceId, filterExpr, timeout, scan)
 	if err != nil {
 		return "", err
 	}
 
-	v := reflect.ValueOf(result)
-	t := reflect.TypeOf(result)
-	columnNames, _, err := db_interface.FetchMetadata(session, deviceId, timeout)
-	if err != nil {
-		return "", err
-	}
+	var sb strings.Builder
+	tw := tabwriter.NewWriter(&sb, 0, 0, 2, ' ', tabwriter.AlignRight)
 
-	var output string
 	// 添加列名
-	for _, columnName := range columnNames {
-		output += fmt.Sprintf("%s\t", columnName)
+	fmt.Fprint(tw, "\t")
+	for _, i := range result.NumericColumns {
+		fmt.Fprintf(tw, "%s\t", result.ColumnNames[i])
 	}
-	output += "\n"
+	fmt.Fprintln(tw)
 
 	// 添加统计结果
-	for i := 0; i < t.NumField(); i++ {
-		output += fmt.Sprintf("%s: %v\n", t.Field(i).Name, v.Field(i).Interface())
+	fmt.Fprintf(tw, "Rows:\t%d\t\n", result.Cnt)
+	intRows := []struct {
+		name   string
+		values []int64
+	}{
+		{"NullCount", result.NullCount},
+	}
+	for _, row := range intRows {
+		fmt.Fprintf(tw, "%s:\t", row.name)
+		for _, i := range result.NumericColumns {
+			fmt.Fprintf(tw, "%d\t", row.values[i])
+		}
+		fmt.Fprintln(tw)
+	}
+	fmt.Fprint(tw, "DistinctCount:\t")
+	for _, i := range result.NumericColumns {
+		fmt.Fprintf(tw, "%s\t", formatDistinctCount(result.DistinctCount[i], result.DistinctExact[i]))
+	}
+	fmt.Fprintln(tw)
+
+	floatRows := []struct {
+		name   string
+		values []float64
+	}{
+		{"Sum", result.Sum},
+		{"Mean", result.Mean},
+		{"Variance", result.Variance},
+		{"StdDev", result.StdDev},
+		{"Min", result.Min},
+		{"Q1", result.Q1},
+		{"Median", result.Median},
+		{"Q3", result.Q3},
+		{"Max", result.Max},
+		{"IQR", result.IQR},
+		{"Skewness", result.Skewness},
+		{"Kurtosis", result.Kurtosis},
+		{"Mode", result.Mode},
+		{"Entropy", result.Entropy},
+	}
+	for _, row := range floatRows {
+		fmt.Fprintf(tw, "%s:\t", row.name)
+		for _, i := range result.NumericColumns {
+			fmt.Fprintf(tw, "%.4f\t", row.values[i])
+		}
+		fmt.Fprintln(tw)
+	}
+	tw.Flush()
+
+	writeFrequentValues(&sb, result)
+	writeNormality(&sb, result)
+
+	return sb.String(), nil
+}
+
+// writeNormality 输出每个数值列的正态性检验（统计量与 p 值）及结论
+func writeNormality(sb *strings.Builder, result db_interface.StatisticsResult) {
+	// Every column runs the same tests, unless it has too few values for some of them
+	var testNames []string
+	seen := make(map[string]bool)
+	for _, i := range result.NumericColumns {
+		for _, test := range result.Normality[i].Tests {
+			if !seen[test.Name] {
+				seen[test.Name] = true
+				testNames = append(testNames, test.Name)
+			}
+		}
 	}
 
-	return output, nil
+	fmt.Fprintf(sb, "\nNormality tests (statistic, p-value):\n")
+	tw := tabwriter.NewWriter(sb, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprint(tw, "\t")
+	for _, i := range result.NumericColumns {
+		fmt.Fprintf(tw, "%s\t", result.ColumnNames[i])
+	}
+	fmt.Fprintln(tw)
+	for _, name := range testNames {
+		fmt.Fprintf(tw, "%s:\t", name)
+		for _, i := range result.NumericColumns {
+			cell := "-"
+			for _, test := range result.Normality[i].Tests {
+				if test.Name == name {
+					cell = fmt.Sprintf("%.4f (p=%.4f)", test.Statistic, test.PValue)
+				}
+			}
+			fmt.Fprintf(tw, "%s\t", cell)
+		}
+		fmt.Fprintln(tw)
+	}
+	tw.Flush()
+
+	for _, i := range result.NumericColumns {
+		fmt.Fprintf(sb, "  %s: %s\n", result.ColumnNames[i], result.Normality[i].Verdict)
+	}
+}
+
+// writeFrequentValues 输出每个数值列出现最频繁的取值及其占比，便于发现量化的传感器和卡死的读数
+func writeFrequentValues(sb *strings.Builder, result db_interface.StatisticsResult) {
+	fmt.Fprintf(sb, "\nMost frequent values (share of non-null rows, entropy over 32 bins):\n")
+	for _, i := range result.NumericColumns {
+		values := make([]string, len(result.TopValues[i]))
+		for k, v := range result.TopValues[i] {
+			values[k] = fmt.Sprintf("%g (%s)", v.Value, formatShare(v.Count, result.ColumnCount(i)))
+		}
+		fmt.Fprintf(sb, "  %s: %s; entropy %.2f bits\n", result.ColumnNames[i], strings.Join(values, ", "), result.Entropy[i])
+	}
+}
+
+// formatDistinctCount 输出不同取值个数，HyperLogLog 估计值前加 ~
+func formatDistinctCount(count int64, exact bool) string {
+	if exact {
+		return fmt.Sprintf("%d", count)
+	}
+	return fmt.Sprintf("~%d", count)
+}
+
+// formatShare 输出出现次数占非空行数的百分比
+func formatShare(count int64, total int64) string {
+	if total == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.1f%%", 100*float64(count)/float64(total))
 }
@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"bdgp2025/src/db_interface"
+	"bdgp2025/src/utils/filter"
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/apache/iotdb-client-go/v2/client"
+)
+
+// strongestLagCount is the number of lags listed by the text output
+const strongestLagCount = 5
+
+type crossCorrelationJSON struct {
+	Device string `json:"device"`
+	db_interface.CrossCorrelationResult
+	Chart string `json:"chart"`
+}
+
+// HandleCrossCorrelation 处理滞后互相关功能，按时间顺序计算两个测量值在各滞后下的互相关系数，
+// 给出峰值滞后及其系数，并生成 ECharts 折线图
+func HandleCrossCorrelation(ctx context.Context, session client.Session, deviceId string, x string, y string, maxLag int, filterExpr *filter.Expression, timeout int64, format string) (string, error) {
+	result, err := db_interface.GetCrossCorrelationResult(ctx, session, deviceId, x, y, maxLag, filterExpr, timeout)
+	if err != nil {
+		return "", err
+	}
+
+	// Quoted path nodes may contain path separators, which must not leak into the file name
+	filename := "xcorr " + fileNameReplacer.Replace(x) + " " + fileNameReplacer.Replace(y) + ".html"
+	if err := result.SaveAsHTML(filename, x, y); err != nil {
+		return "", err
+	}
+
+	if format == FormatJSON {
+		return marshalJSON(crossCorrelationJSON{Device: deviceId, CrossCorrelationResult: result, Chart: filename})
+	}
+
+	var sb strings.Builder
+	title := fmt.Sprintf("Cross-correlation of %s and %s", x, y)
+	sb.WriteString(title + "\n")
+	sb.WriteString(strings.Repeat("=", len(title)) + "\n")
+	fmt.Fprintf(&sb, "Rows: %d, Lags: -%d to %d rows, Median step: %s\n", result.N, result.MaxLag, result.MaxLag, formatLagDuration(1, result.Step))
+	fmt.Fprintf(&sb, "Peak: lag %d (%s), r = %.4f\n", result.PeakLag, formatLagDuration(result.PeakLag, result.Step), result.PeakCoefficient)
+	switch {
+	case result.PeakLag > 0:
+		fmt.Fprintf(&sb, "Changes in %s follow those in %s by %d rows\n", y, x, result.PeakLag)
+	case result.PeakLag < 0:
+		fmt.Fprintf(&sb, "Changes in %s follow those in %s by %d rows\n", x, y, -result.PeakLag)
+	default:
+		sb.WriteString("The strongest relationship is simultaneous\n")
+	}
+	fmt.Fprintf(&sb, "95%% band of independent white noise: ±%.4f\n\n", result.Band)
+
+	order := make([]int, len(result.Lags))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return math.Abs(result.Coefficients[order[a]]) > math.Abs(result.Coefficients[order[b]])
+	})
+	sb.WriteString("Strongest lags:\n")
+	tw := tabwriter.NewWriter(&sb, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "Lag\tDuration\tr\t")
+	for _, i := range order[:min(strongestLagCount, len(order))] {
+		fmt.Fprintf(tw, "%d\t%s\t%.4f\t\n", result.Lags[i], formatLagDuration(result.Lags[i], result.Step), result.Coefficients[i])
+	}
+	tw.Flush()
+
+	fmt.Fprintf(&sb, "\nGenerated graph: %s\n", filename)
+	return sb.String(), nil
+}
+
+// formatLagDuration 按行间的中位时间间隔把以行计的滞后换算为时长
+func formatLagDuration(lag int, step int64) string {
+	if step <= 0 {
+		return "-"
+	}
+	return (time.Duration(int64(lag)*step) * time.Millisecond).String()
+}
@@ -0,0 +1,71 @@
+package server
+
+import (
+	"bdgp2025/src/db_interface"
+	"bdgp2025/src/utils/kde"
+	"encoding/json"
+	"errors"
+	"net/http"
+)
+
+// statusClientClosedRequest is the nginx convention for a request the client abandoned
+const statusClientClosedRequest = 499
+
+// errorResponse is the JSON body sent with every failed request
+type errorResponse struct {
+	Error string `json:"error"`
+	Kind  string `json:"kind"`
+	Code  int32  `json:"code,omitempty"` // IoTDB status code, when IoTDB reported one
+}
+
+// classifyError maps an error from the analysis layer to an HTTP status and a stable error kind
+func classifyError(err error) (int, string) {
+	switch {
+	case errors.Is(err, db_interface.ErrInvalidQuery):
+		return http.StatusBadRequest, "invalid_query"
+	case errors.Is(err, db_interface.ErrDeviceNotFound):
+		return http.StatusNotFound, "device_not_found"
+	case errors.Is(err, db_interface.ErrMeasurementNotFound):
+		return http.StatusNotFound, "measurement_not_found"
+	case errors.Is(err, db_interface.ErrTypeUnsupported):
+		return http.StatusUnprocessableEntity, "type_unsupported"
+	case errors.Is(err, kde.ErrTooFewValues):
+		return http.StatusUnprocessableEntity, "too_few_values"
+	case errors.Is(err, db_interface.ErrTimeout):
+		return http.StatusGatewayTimeout, "timeout"
+	case errors.Is(err, db_interface.ErrCanceled):
+		return statusClientClosedRequest, "canceled"
+	case errors.Is(err, db_interface.ErrConnectionLost):
+		return http.StatusServiceUnavailable, "connection_lost"
+	}
+
+	var statusErr *db_interface.StatusError
+	if errors.As(err, &statusErr) {
+		return http.StatusBadGateway, "iotdb_status"
+	}
+	return http.StatusInternalServerError, "internal"
+}
+
+// writeError sends err as a JSON error body with the status code matching its type
+func writeError(w http.ResponseWriter, err error) {
+	status, kind := classifyError(err)
+	response := errorResponse{Error: err.Error(), Kind: kind}
+
+	var statusErr *db_interface.StatusError
+	if errors.As(err, &statusErr) {
+		response.Code = statusErr.Code
+	}
+	writeErrorResponse(w, status, response)
+}
+
+// writeErrorMessage sends a JSON error body for failures detected by the server itself
+func writeErrorMessage(w http.ResponseWriter, status int, kind string, message string) {
+	writeErrorResponse(w, status, errorResponse{Error: message, Kind: kind})
+}
+
+func writeErrorResponse(w http.ResponseWriter, status int, response errorResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(response)
+}
@@ -1,10 +1,19 @@
 package server
 
 import (
+	"bdgp2025/src/db_interface"
 	"bdgp2025/src/handlers"
+	"bdgp2025/src/utils/filter"
+	"bdgp2025/src/utils/kde"
+	"bdgp2025/src/utils/rolling"
+	"bdgp2025/src/utils/stats"
+	"bdgp2025/src/utils/xcorr"
+	"context"
 	"fmt"
 	"log"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	config "bdgp2025/src/utils"
@@ -22,18 +31,24 @@ func Main() {
 	// Convert to IoTDB config
 	iotdbConfig := configWithSources.ToIoTDBConfig()
 	timeout := iotdbConfig.Timeout
+	requestTimeout := time.Duration(iotdbConfig.RequestTimeout) * time.Millisecond
 
-	config := &client.Config{
-		Host:     iotdbConfig.Host,
-		Port:     iotdbConfig.Port,
-		UserName: iotdbConfig.User,
-		Password: iotdbConfig.Password,
-	}
-	session := client.NewSession(config)
-	if err := session.Open(false, 0); err != nil {
+	pool, err := db_interface.NewSessionPool(db_interface.PoolConfig{
+		Host:       iotdbConfig.Host,
+		Port:       iotdbConfig.Port,
+		User:       iotdbConfig.User,
+		Password:   iotdbConfig.Password,
+		Size:       iotdbConfig.PoolSize,
+		MaxRetries: iotdbConfig.MaxRetries,
+	})
+	if err != nil {
 		log.Fatal(err)
 	}
-	defer session.Close()
+	defer pool.Close()
+	scan := db_interface.ScanOptions{Pool: pool, Partitions: iotdbConfig.ScanPartitions, QuantileEpsilon: iotdbConfig.QuantileEpsilon}
+	bootstrap := stats.DefaultBootstrapConfig()
+	bootstrap.Resamples = iotdbConfig.BootstrapResamples
+	bootstrap.Seed = uint64(iotdbConfig.BootstrapSeed)
 
 	// Log server startup
 	log.Println("Server started, listening on port 8084")
@@ -43,30 +58,41 @@ func Main() {
 		startTime := time.Now()
 		if r.Method != http.MethodPost {
 			log.Printf("Import API: Method not allowed %s\n", r.Method)
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			writeErrorMessage(w, http.StatusMethodNotAllowed, "method_not_allowed", "Method not allowed")
 			return
 		}
 
+		ctx, cancel := requestContext(r, requestTimeout)
+		defer cancel()
+
 		// 获取查询参数
 		csvFile := r.URL.Query().Get("csvFile")
 		deviceId := r.URL.Query().Get("deviceId")
 		if deviceId == "" {
 			deviceId = "root.example.exampledev" // 默认设备ID
 		}
+		if _, err := db_interface.ParseDevicePath(deviceId); err != nil {
+			log.Printf("Import API: Invalid device ID, Error: %v\n", err)
+			writeError(w, err)
+			return
+		}
 
 		log.Printf("Import API: Starting CSV file import, Device ID: %s, File: %s\n", deviceId, csvFile)
 
 		if csvFile == "" {
 			log.Println("Import API: Missing csvFile parameter")
-			http.Error(w, "csvFile parameter is required", http.StatusBadRequest)
+			writeErrorMessage(w, http.StatusBadRequest, "invalid_request", "csvFile parameter is required")
 			return
 		}
 
 		// 调用处理函数
-		err := handlers.HandleCSVImport(csvFile, session, deviceId)
+		// 导入不是幂等操作，因此不重试
+		err := pool.WithSession(ctx, func(session client.Session) error {
+			return handlers.HandleCSVImport(ctx, csvFile, session, deviceId)
+		})
 		if err != nil {
 			log.Printf("Import API: Processing failed, Error: %v\n", err)
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+			writeError(w, err)
 			return
 		}
 
@@ -80,21 +106,41 @@ func Main() {
 		startTime := time.Now()
 		if r.Method != http.MethodGet {
 			log.Printf("Statistic API: Method not allowed %s\n", r.Method)
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			writeErrorMessage(w, http.StatusMethodNotAllowed, "method_not_allowed", "Method not allowed")
 			return
 		}
 
+		ctx, cancel := requestContext(r, requestTimeout)
+		defer cancel()
+
 		deviceId := r.URL.Query().Get("deviceId")
 		if deviceId == "" {
 			deviceId = "root.example.exampledev" // 默认设备ID
 		}
+		if _, err := db_interface.ParseDevicePath(deviceId); err != nil {
+			log.Printf("Statistic API: Invalid device ID, Error: %v\n", err)
+			writeError(w, err)
+			return
+		}
+
+		filterExpr, err := filter.Parse(r.URL.Query().Get("filter"))
+		if err != nil {
+			log.Printf("Statistic API: Invalid filter, Error: %v\n", err)
+			writeErrorMessage(w, http.StatusBadRequest, "invalid_filter", err.Error())
+			return
+		}
 
 		log.Printf("Statistic API: Starting statistical data calculation, Device ID: %s\n", deviceId)
 
-		result, err := handlers.HandleStatisticCalc(session, deviceId, timeout)
+		var result string
+		err = pool.WithRetry(ctx, func(session client.Session) error {
+			var errHandle error
+			result, errHandle = handlers.HandleStatisticCalc(ctx, session, deviceId, filterExpr, timeout, scan)
+			return errHandle
+		})
 		if err != nil {
 			log.Printf("Statistic API: Calculation failed, Error: %v\n", err)
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+			writeError(w, err)
 			return
 		}
 
@@ -109,27 +155,78 @@ func Main() {
 		startTime := time.Now()
 		if r.Method != http.MethodGet {
 			log.Printf("Correlation API: Method not allowed %s\n", r.Method)
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			writeErrorMessage(w, http.StatusMethodNotAllowed, "method_not_allowed", "Method not allowed")
 			return
 		}
 
+		ctx, cancel := requestContext(r, requestTimeout)
+		defer cancel()
+
 		deviceId := r.URL.Query().Get("deviceId")
 		if deviceId == "" {
 			deviceId = "root.example.exampledev" // 默认设备ID
 		}
+		if _, err := db_interface.ParseDevicePath(deviceId); err != nil {
+			log.Printf("Correlation API: Invalid device ID, Error: %v\n", err)
+			writeError(w, err)
+			return
+		}
+
+		methods, err := stats.ParseCorrelationMethods(r.URL.Query().Get("method"))
+		if err != nil {
+			writeErrorMessage(w, http.StatusBadRequest, "invalid_request", err.Error())
+			return
+		}
+		correlationOptions := db_interface.CorrelationOptions{Methods: methods}
+		if s := r.URL.Query().Get("partial"); s != "" {
+			if correlationOptions.Partial, err = strconv.ParseBool(s); err != nil {
+				writeErrorMessage(w, http.StatusBadRequest, "invalid_request", "partial must be true or false")
+				return
+			}
+		}
+		if s := r.URL.Query().Get("controls"); s != "" {
+			for _, control := range strings.Split(s, ",") {
+				correlationOptions.Controls = append(correlationOptions.Controls, strings.TrimSpace(control))
+			}
+		}
 
-		log.Printf("Correlation API: Starting correlation data calculation, Device ID: %s\n", deviceId)
+		format := r.URL.Query().Get("format")
+		if format == "" {
+			format = handlers.FormatText
+		}
+		if format != handlers.FormatText && format != handlers.FormatJSON {
+			writeErrorMessage(w, http.StatusBadRequest, "invalid_request", "format must be text or json")
+			return
+		}
 
-		result, err := handlers.HandleCorrelationCalc(session, deviceId, timeout)
+		filterExpr, err := filter.Parse(r.URL.Query().Get("filter"))
+		if err != nil {
+			log.Printf("Correlation API: Invalid filter, Error: %v\n", err)
+			writeErrorMessage(w, http.StatusBadRequest, "invalid_filter", err.Error())
+			return
+		}
+
+		log.Printf("Correlation API: Starting correlation data calculation, Device ID: %s, Methods: %v\n", deviceId, methods)
+
+		var result string
+		err = pool.WithRetry(ctx, func(session client.Session) error {
+			var errHandle error
+			result, errHandle = handlers.HandleCorrelationCalc(ctx, session, deviceId, filterExpr, timeout, scan, bootstrap, correlationOptions, format)
+			return errHandle
+		})
 		if err != nil {
 			log.Printf("Correlation API: Calculation failed, Error: %v\n", err)
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+			writeError(w, err)
 			return
 		}
 
 		duration := time.Since(startTime)
 		log.Printf("Correlation API: Successfully completed correlation calculation, Device ID: %s, Duration: %v\n", deviceId, duration)
-		w.Header().Set("Content-Type", "text/plain")
+		if format == handlers.FormatJSON {
+			w.Header().Set("Content-Type", "application/json")
+		} else {
+			w.Header().Set("Content-Type", "text/plain")
+		}
 		fmt.Fprint(w, result)
 	})
 
@@ -138,21 +235,41 @@ func Main() {
 		startTime := time.Now()
 		if r.Method != http.MethodGet {
 			log.Printf("Graph API: Method not allowed %s\n", r.Method)
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			writeErrorMessage(w, http.StatusMethodNotAllowed, "method_not_allowed", "Method not allowed")
 			return
 		}
 
+		ctx, cancel := requestContext(r, requestTimeout)
+		defer cancel()
+
 		deviceId := r.URL.Query().Get("deviceId")
 		if deviceId == "" {
 			deviceId = "root.example.exampledev" // 默认设备ID
 		}
+		if _, err := db_interface.ParseDevicePath(deviceId); err != nil {
+			log.Printf("Graph API: Invalid device ID, Error: %v\n", err)
+			writeError(w, err)
+			return
+		}
+
+		filterExpr, err := filter.Parse(r.URL.Query().Get("filter"))
+		if err != nil {
+			log.Printf("Graph API: Invalid filter, Error: %v\n", err)
+			writeErrorMessage(w, http.StatusBadRequest, "invalid_filter", err.Error())
+			return
+		}
 
 		log.Printf("Graph API: Starting statistical chart generation, Device ID: %s\n", deviceId)
 
-		result, err := handlers.HandleStatisticGraph(session, deviceId, timeout)
+		var result string
+		err = pool.WithRetry(ctx, func(session client.Session) error {
+			var errHandle error
+			result, errHandle = handlers.HandleStatisticGraph(ctx, session, deviceId, filterExpr, timeout)
+			return errHandle
+		})
 		if err != nil {
 			log.Printf("Graph API: Generation failed, Error: %v\n", err)
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+			writeError(w, err)
 			return
 		}
 
@@ -167,27 +284,732 @@ func Main() {
 		startTime := time.Now()
 		if r.Method != http.MethodGet {
 			log.Printf("Condition Analysis API: Method not allowed %s\n", r.Method)
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			writeErrorMessage(w, http.StatusMethodNotAllowed, "method_not_allowed", "Method not allowed")
 			return
 		}
 
+		ctx, cancel := requestContext(r, requestTimeout)
+		defer cancel()
+
 		deviceId := r.URL.Query().Get("deviceId")
 		if deviceId == "" {
 			deviceId = "root.example.exampledev" // 默认设备ID
 		}
+		if _, err := db_interface.ParseDevicePath(deviceId); err != nil {
+			log.Printf("Condition Analysis API: Invalid device ID, Error: %v\n", err)
+			writeError(w, err)
+			return
+		}
+
+		format := r.URL.Query().Get("format")
+		if format == "" {
+			format = handlers.FormatText
+		}
+		if format != handlers.FormatText && format != handlers.FormatJSON {
+			writeErrorMessage(w, http.StatusBadRequest, "invalid_request", "format must be text or json")
+			return
+		}
+
+		filterExpr, err := filter.Parse(r.URL.Query().Get("filter"))
+		if err != nil {
+			log.Printf("Condition Analysis API: Invalid filter, Error: %v\n", err)
+			writeErrorMessage(w, http.StatusBadRequest, "invalid_filter", err.Error())
+			return
+		}
 
 		log.Printf("Condition Analysis API: Starting condition analysis, Device ID: %s\n", deviceId)
 
-		result, err := handlers.HandleConditionAnalysis(session, deviceId, timeout)
+		var result string
+		err = pool.WithRetry(ctx, func(session client.Session) error {
+			var errHandle error
+			result, errHandle = handlers.HandleConditionAnalysis(ctx, session, deviceId, filterExpr, timeout, scan, bootstrap, format)
+			return errHandle
+		})
 		if err != nil {
 			log.Printf("Condition Analysis API: Analysis failed, Error: %v\n", err)
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+			writeError(w, err)
 			return
 		}
 
 		duration := time.Since(startTime)
 		log.Printf("Condition Analysis API: Successfully completed condition analysis, Device ID: %s, Duration: %v\n", deviceId, duration)
-		w.Header().Set("Content-Type", "text/plain")
+		if format == handlers.FormatJSON {
+			w.Header().Set("Content-Type", "application/json")
+		} else {
+			w.Header().Set("Content-Type", "text/plain")
+		}
+		fmt.Fprint(w, result)
+	})
+
+	// 注册分组分析端点
+	http.HandleFunc("/group", func(w http.ResponseWriter, r *http.Request) {
+		startTime := time.Now()
+		if r.Method != http.MethodGet {
+			log.Printf("Group Analysis API: Method not allowed %s\n", r.Method)
+			writeErrorMessage(w, http.StatusMethodNotAllowed, "method_not_allowed", "Method not allowed")
+			return
+		}
+
+		ctx, cancel := requestContext(r, requestTimeout)
+		defer cancel()
+
+		query := r.URL.Query()
+		deviceId := query.Get("deviceId")
+		if deviceId == "" {
+			deviceId = "root.example.exampledev" // 默认设备ID
+		}
+		if _, err := db_interface.ParseDevicePath(deviceId); err != nil {
+			log.Printf("Group Analysis API: Invalid device ID, Error: %v\n", err)
+			writeError(w, err)
+			return
+		}
+
+		groupBy := db_interface.GroupBy{Column: query.Get("column")}
+		if groupBy.Column == "" {
+			log.Println("Group Analysis API: Missing column parameter")
+			writeErrorMessage(w, http.StatusBadRequest, "invalid_request", "column parameter is required")
+			return
+		}
+		if s := query.Get("bin"); s != "" {
+			binWidth, errParse := strconv.ParseFloat(s, 64)
+			if errParse != nil || binWidth <= 0 {
+				writeErrorMessage(w, http.StatusBadRequest, "invalid_request", "bin must be a positive number")
+				return
+			}
+			groupBy.BinWidth = binWidth
+		}
+
+		format := query.Get("format")
+		if format == "" {
+			format = handlers.FormatText
+		}
+		if format != handlers.FormatText && format != handlers.FormatJSON {
+			writeErrorMessage(w, http.StatusBadRequest, "invalid_request", "format must be text or json")
+			return
+		}
+
+		filterExpr, err := filter.Parse(query.Get("filter"))
+		if err != nil {
+			log.Printf("Group Analysis API: Invalid filter, Error: %v\n", err)
+			writeErrorMessage(w, http.StatusBadRequest, "invalid_filter", err.Error())
+			return
+		}
+
+		log.Printf("Group Analysis API: Starting group analysis, Device ID: %s, Column: %s, Bin: %g\n", deviceId, groupBy.Column, groupBy.BinWidth)
+
+		var result string
+		err = pool.WithRetry(ctx, func(session client.Session) error {
+			var errHandle error
+			result, errHandle = handlers.HandleGroupAnalysis(ctx, session, deviceId, filterExpr, groupBy, timeout, scan, format)
+			return errHandle
+		})
+		if err != nil {
+			log.Printf("Group Analysis API: Analysis failed, Error: %v\n", err)
+			writeError(w, err)
+			return
+		}
+
+		duration := time.Since(startTime)
+		log.Printf("Group Analysis API: Successfully completed group analysis, Device ID: %s, Duration: %v\n", deviceId, duration)
+		if format == handlers.FormatJSON {
+			w.Header().Set("Content-Type", "application/json")
+		} else {
+			w.Header().Set("Content-Type", "text/plain")
+		}
+		fmt.Fprint(w, result)
+	})
+
+	// 注册分布拟合端点
+	http.HandleFunc("/fit", func(w http.ResponseWriter, r *http.Request) {
+		startTime := time.Now()
+		if r.Method != http.MethodGet {
+			log.Printf("Distribution Fit API: Method not allowed %s\n", r.Method)
+			writeErrorMessage(w, http.StatusMethodNotAllowed, "method_not_allowed", "Method not allowed")
+			return
+		}
+
+		ctx, cancel := requestContext(r, requestTimeout)
+		defer cancel()
+
+		query := r.URL.Query()
+		deviceId := query.Get("deviceId")
+		if deviceId == "" {
+			deviceId = "root.example.exampledev" // 默认设备ID
+		}
+		if _, err := db_interface.ParseDevicePath(deviceId); err != nil {
+			log.Printf("Distribution Fit API: Invalid device ID, Error: %v\n", err)
+			writeError(w, err)
+			return
+		}
+
+		format := query.Get("format")
+		if format == "" {
+			format = handlers.FormatText
+		}
+		if format != handlers.FormatText && format != handlers.FormatJSON {
+			writeErrorMessage(w, http.StatusBadRequest, "invalid_request", "format must be text or json")
+			return
+		}
+
+		filterExpr, err := filter.Parse(query.Get("filter"))
+		if err != nil {
+			log.Printf("Distribution Fit API: Invalid filter, Error: %v\n", err)
+			writeErrorMessage(w, http.StatusBadRequest, "invalid_filter", err.Error())
+			return
+		}
+
+		log.Printf("Distribution Fit API: Starting distribution fitting, Device ID: %s\n", deviceId)
+
+		var result string
+		err = pool.WithRetry(ctx, func(session client.Session) error {
+			var errHandle error
+			result, errHandle = handlers.HandleDistributionFit(ctx, session, deviceId, filterExpr, timeout, scan, format)
+			return errHandle
+		})
+		if err != nil {
+			log.Printf("Distribution Fit API: Fitting failed, Error: %v\n", err)
+			writeError(w, err)
+			return
+		}
+
+		duration := time.Since(startTime)
+		log.Printf("Distribution Fit API: Successfully completed distribution fitting, Device ID: %s, Duration: %v\n", deviceId, duration)
+		if format == handlers.FormatJSON {
+			w.Header().Set("Content-Type", "application/json")
+		} else {
+			w.Header().Set("Content-Type", "text/plain")
+		}
+		fmt.Fprint(w, result)
+	})
+
+	// 注册核密度估计端点
+	http.HandleFunc("/kde", func(w http.ResponseWriter, r *http.Request) {
+		startTime := time.Now()
+		if r.Method != http.MethodGet {
+			log.Printf("KDE API: Method not allowed %s\n", r.Method)
+			writeErrorMessage(w, http.StatusMethodNotAllowed, "method_not_allowed", "Method not allowed")
+			return
+		}
+
+		ctx, cancel := requestContext(r, requestTimeout)
+		defer cancel()
+
+		query := r.URL.Query()
+		deviceId := query.Get("deviceId")
+		if deviceId == "" {
+			deviceId = "root.example.exampledev" // 默认设备ID
+		}
+		if _, err := db_interface.ParseDevicePath(deviceId); err != nil {
+			log.Printf("KDE API: Invalid device ID, Error: %v\n", err)
+			writeError(w, err)
+			return
+		}
+
+		measurement := query.Get("measurement")
+		if measurement == "" {
+			log.Println("KDE API: Missing measurement parameter")
+			writeErrorMessage(w, http.StatusBadRequest, "invalid_request", "measurement parameter is required")
+			return
+		}
+
+		kdeConfig := kde.DefaultConfig()
+		if s := query.Get("kernel"); s != "" {
+			kdeConfig.Kernel = s
+		}
+		if s := query.Get("bandwidth"); s != "" {
+			if err := kdeConfig.SetBandwidth(s); err != nil {
+				writeErrorMessage(w, http.StatusBadRequest, "invalid_request", err.Error())
+				return
+			}
+		}
+		if s := query.Get("grid"); s != "" {
+			gridSize, errParse := strconv.Atoi(s)
+			if errParse != nil || gridSize < 2 || gridSize > 10000 {
+				writeErrorMessage(w, http.StatusBadRequest, "invalid_request", "grid must be an integer between 2 and 10000")
+				return
+			}
+			kdeConfig.GridSize = gridSize
+		}
+		if err := kdeConfig.Validate(); err != nil {
+			writeErrorMessage(w, http.StatusBadRequest, "invalid_request", err.Error())
+			return
+		}
+
+		filterExpr, err := filter.Parse(query.Get("filter"))
+		if err != nil {
+			log.Printf("KDE API: Invalid filter, Error: %v\n", err)
+			writeErrorMessage(w, http.StatusBadRequest, "invalid_filter", err.Error())
+			return
+		}
+
+		log.Printf("KDE API: Starting density estimation, Device ID: %s, Measurement: %s, Kernel: %s\n", deviceId, measurement, kdeConfig.Kernel)
+
+		var result string
+		err = pool.WithRetry(ctx, func(session client.Session) error {
+			var errHandle error
+			result, errHandle = handlers.HandleKDE(ctx, session, deviceId, measurement, filterExpr, kdeConfig, timeout)
+			return errHandle
+		})
+		if err != nil {
+			log.Printf("KDE API: Estimation failed, Error: %v\n", err)
+			writeError(w, err)
+			return
+		}
+
+		duration := time.Since(startTime)
+		log.Printf("KDE API: Successfully completed density estimation, Device ID: %s, Duration: %v\n", deviceId, duration)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, result)
+	})
+
+	// 注册滚动窗口统计端点
+	http.HandleFunc("/rolling", func(w http.ResponseWriter, r *http.Request) {
+		startTime := time.Now()
+		if r.Method != http.MethodGet {
+			log.Printf("Rolling API: Method not allowed %s\n", r.Method)
+			writeErrorMessage(w, http.StatusMethodNotAllowed, "method_not_allowed", "Method not allowed")
+			return
+		}
+
+		ctx, cancel := requestContext(r, requestTimeout)
+		defer cancel()
+
+		query := r.URL.Query()
+		deviceId := query.Get("deviceId")
+		if deviceId == "" {
+			deviceId = "root.example.exampledev" // 默认设备ID
+		}
+		if _, err := db_interface.ParseDevicePath(deviceId); err != nil {
+			log.Printf("Rolling API: Invalid device ID, Error: %v\n", err)
+			writeError(w, err)
+			return
+		}
+
+		measurement := query.Get("measurement")
+		if measurement == "" {
+			log.Println("Rolling API: Missing measurement parameter")
+			writeErrorMessage(w, http.StatusBadRequest, "invalid_request", "measurement parameter is required")
+			return
+		}
+
+		window := query.Get("window")
+		if window == "" {
+			writeErrorMessage(w, http.StatusBadRequest, "invalid_request", "window parameter is required, e.g. 500 rows or 60s")
+			return
+		}
+		rollingConfig, err := rolling.ParseConfig(window, query.Get("step"), query.Get("p"))
+		if err != nil {
+			writeErrorMessage(w, http.StatusBadRequest, "invalid_request", err.Error())
+			return
+		}
+
+		format := query.Get("format")
+		if format == "" {
+			format = handlers.FormatText
+		}
+		if format != handlers.FormatText && format != handlers.FormatJSON {
+			writeErrorMessage(w, http.StatusBadRequest, "invalid_request", "format must be text or json")
+			return
+		}
+
+		filterExpr, err := filter.Parse(query.Get("filter"))
+		if err != nil {
+			log.Printf("Rolling API: Invalid filter, Error: %v\n", err)
+			writeErrorMessage(w, http.StatusBadRequest, "invalid_filter", err.Error())
+			return
+		}
+
+		log.Printf("Rolling API: Starting rolling statistics, Device ID: %s, Measurement: %s, Window: %s\n", deviceId, measurement, window)
+
+		var result string
+		err = pool.WithRetry(ctx, func(session client.Session) error {
+			var errHandle error
+			result, errHandle = handlers.HandleRolling(ctx, session, deviceId, measurement, filterExpr, rollingConfig, timeout, format)
+			return errHandle
+		})
+		if err != nil {
+			log.Printf("Rolling API: Calculation failed, Error: %v\n", err)
+			writeError(w, err)
+			return
+		}
+
+		duration := time.Since(startTime)
+		log.Printf("Rolling API: Successfully completed rolling statistics, Device ID: %s, Duration: %v\n", deviceId, duration)
+		if format == handlers.FormatJSON {
+			w.Header().Set("Content-Type", "application/json")
+		} else {
+			w.Header().Set("Content-Type", "text/plain")
+		}
+		fmt.Fprint(w, result)
+	})
+
+	// 注册百分位数端点
+	http.HandleFunc("/percentiles", func(w http.ResponseWriter, r *http.Request) {
+		startTime := time.Now()
+		if r.Method != http.MethodGet {
+			log.Printf("Percentiles API: Method not allowed %s\n", r.Method)
+			writeErrorMessage(w, http.StatusMethodNotAllowed, "method_not_allowed", "Method not allowed")
+			return
+		}
+
+		ctx, cancel := requestContext(r, requestTimeout)
+		defer cancel()
+
+		query := r.URL.Query()
+		deviceId := query.Get("deviceId")
+		if deviceId == "" {
+			deviceId = "root.example.exampledev" // 默认设备ID
+		}
+		if _, err := db_interface.ParseDevicePath(deviceId); err != nil {
+			log.Printf("Percentiles API: Invalid device ID, Error: %v\n", err)
+			writeError(w, err)
+			return
+		}
+
+		percentileQuery := db_interface.PercentileQuery{GroupBy: query.Get("groupBy")}
+		if s := query.Get("measurements"); s != "" {
+			for _, measurement := range strings.Split(s, ",") {
+				percentileQuery.Measurements = append(percentileQuery.Measurements, strings.TrimSpace(measurement))
+			}
+		}
+		if s := query.Get("p"); s != "" {
+			percentiles, err := stats.ParsePercentiles(s)
+			if err != nil {
+				writeErrorMessage(w, http.StatusBadRequest, "invalid_request", err.Error())
+				return
+			}
+			percentileQuery.Percentiles = percentiles
+		}
+		if s := query.Get("method"); s != "" {
+			method, err := stats.ParseQuantileMethod(s)
+			if err != nil {
+				writeErrorMessage(w, http.StatusBadRequest, "invalid_request", err.Error())
+				return
+			}
+			percentileQuery.Method = method
+		}
+
+		format := query.Get("format")
+		if format == "" {
+			format = handlers.FormatText
+		}
+		if format != handlers.FormatText && format != handlers.FormatJSON {
+			writeErrorMessage(w, http.StatusBadRequest, "invalid_request", "format must be text or json")
+			return
+		}
+
+		filterExpr, err := filter.Parse(query.Get("filter"))
+		if err != nil {
+			log.Printf("Percentiles API: Invalid filter, Error: %v\n", err)
+			writeErrorMessage(w, http.StatusBadRequest, "invalid_filter", err.Error())
+			return
+		}
+
+		log.Printf("Percentiles API: Starting percentile calculation, Device ID: %s, Measurements: %v\n", deviceId, percentileQuery.Measurements)
+
+		var result string
+		err = pool.WithRetry(ctx, func(session client.Session) error {
+			var errHandle error
+			result, errHandle = handlers.HandlePercentiles(ctx, session, deviceId, percentileQuery, filterExpr, timeout, format)
+			return errHandle
+		})
+		if err != nil {
+			log.Printf("Percentiles API: Calculation failed, Error: %v\n", err)
+			writeError(w, err)
+			return
+		}
+
+		duration := time.Since(startTime)
+		log.Printf("Percentiles API: Successfully completed percentile calculation, Device ID: %s, Duration: %v\n", deviceId, duration)
+		if format == handlers.FormatJSON {
+			w.Header().Set("Content-Type", "application/json")
+		} else {
+			w.Header().Set("Content-Type", "text/plain")
+		}
+		fmt.Fprint(w, result)
+	})
+
+	// 注册特征相关性端点
+	http.HandleFunc("/relevance", func(w http.ResponseWriter, r *http.Request) {
+		startTime := time.Now()
+		if r.Method != http.MethodGet {
+			log.Printf("Relevance API: Method not allowed %s\n", r.Method)
+			writeErrorMessage(w, http.StatusMethodNotAllowed, "method_not_allowed", "Method not allowed")
+			return
+		}
+
+		ctx, cancel := requestContext(r, requestTimeout)
+		defer cancel()
+
+		query := r.URL.Query()
+		deviceId := query.Get("deviceId")
+		if deviceId == "" {
+			deviceId = "root.example.exampledev" // 默认设备ID
+		}
+		if _, err := db_interface.ParseDevicePath(deviceId); err != nil {
+			log.Printf("Relevance API: Invalid device ID, Error: %v\n", err)
+			writeError(w, err)
+			return
+		}
+
+		target := query.Get("target")
+		if target == "" {
+			target = "engine_condition"
+		}
+
+		format := query.Get("format")
+		if format == "" {
+			format = handlers.FormatText
+		}
+		if format != handlers.FormatText && format != handlers.FormatJSON {
+			writeErrorMessage(w, http.StatusBadRequest, "invalid_request", "format must be text or json")
+			return
+		}
+
+		filterExpr, err := filter.Parse(query.Get("filter"))
+		if err != nil {
+			log.Printf("Relevance API: Invalid filter, Error: %v\n", err)
+			writeErrorMessage(w, http.StatusBadRequest, "invalid_filter", err.Error())
+			return
+		}
+
+		log.Printf("Relevance API: Starting feature relevance calculation, Device ID: %s, Target: %s\n", deviceId, target)
+
+		var result string
+		err = pool.WithRetry(ctx, func(session client.Session) error {
+			var errHandle error
+			result, errHandle = handlers.HandleRelevance(ctx, session, deviceId, target, filterExpr, timeout, format)
+			return errHandle
+		})
+		if err != nil {
+			log.Printf("Relevance API: Calculation failed, Error: %v\n", err)
+			writeError(w, err)
+			return
+		}
+
+		duration := time.Since(startTime)
+		log.Printf("Relevance API: Successfully completed feature relevance calculation, Device ID: %s, Duration: %v\n", deviceId, duration)
+		if format == handlers.FormatJSON {
+			w.Header().Set("Content-Type", "application/json")
+		} else {
+			w.Header().Set("Content-Type", "text/plain")
+		}
+		fmt.Fprint(w, result)
+	})
+
+	// 注册滞后互相关端点
+	http.HandleFunc("/xcorr", func(w http.ResponseWriter, r *http.Request) {
+		startTime := time.Now()
+		if r.Method != http.MethodGet {
+			log.Printf("Cross-correlation API: Method not allowed %s\n", r.Method)
+			writeErrorMessage(w, http.StatusMethodNotAllowed, "method_not_allowed", "Method not allowed")
+			return
+		}
+
+		ctx, cancel := requestContext(r, requestTimeout)
+		defer cancel()
+
+		query := r.URL.Query()
+		deviceId := query.Get("deviceId")
+		if deviceId == "" {
+			deviceId = "root.example.exampledev" // 默认设备ID
+		}
+		if _, err := db_interface.ParseDevicePath(deviceId); err != nil {
+			log.Printf("Cross-correlation API: Invalid device ID, Error: %v\n", err)
+			writeError(w, err)
+			return
+		}
+
+		x, y := query.Get("x"), query.Get("y")
+		if x == "" || y == "" {
+			log.Println("Cross-correlation API: Missing x or y parameter")
+			writeErrorMessage(w, http.StatusBadRequest, "invalid_request", "x and y parameters are required")
+			return
+		}
+		maxLag := xcorr.DefaultMaxLag
+		if s := query.Get("maxLag"); s != "" {
+			lag, err := strconv.Atoi(s)
+			if err != nil || lag < 0 {
+				writeErrorMessage(w, http.StatusBadRequest, "invalid_request", "maxLag must be a non-negative row count")
+				return
+			}
+			maxLag = lag
+		}
+
+		format := query.Get("format")
+		if format == "" {
+			format = handlers.FormatText
+		}
+		if format != handlers.FormatText && format != handlers.FormatJSON {
+			writeErrorMessage(w, http.StatusBadRequest, "invalid_request", "format must be text or json")
+			return
+		}
+
+		filterExpr, err := filter.Parse(query.Get("filter"))
+		if err != nil {
+			log.Printf("Cross-correlation API: Invalid filter, Error: %v\n", err)
+			writeErrorMessage(w, http.StatusBadRequest, "invalid_filter", err.Error())
+			return
+		}
+
+		log.Printf("Cross-correlation API: Starting cross-correlation, Device ID: %s, X: %s, Y: %s, Max lag: %d\n", deviceId, x, y, maxLag)
+
+		var result string
+		err = pool.WithRetry(ctx, func(session client.Session) error {
+			var errHandle error
+			result, errHandle = handlers.HandleCrossCorrelation(ctx, session, deviceId, x, y, maxLag, filterExpr, timeout, format)
+			return errHandle
+		})
+		if err != nil {
+			log.Printf("Cross-correlation API: Calculation failed, Error: %v\n", err)
+			writeError(w, err)
+			return
+		}
+
+		duration := time.Since(startTime)
+		log.Printf("Cross-correlation API: Successfully completed cross-correlation, Device ID: %s, Duration: %v\n", deviceId, duration)
+		if format == handlers.FormatJSON {
+			w.Header().Set("Content-Type", "application/json")
+		} else {
+			w.Header().Set("Content-Type", "text/plain")
+		}
+		fmt.Fprint(w, result)
+	})
+
+	// 注册原始数据分页浏览端点
+	http.HandleFunc("/data", func(w http.ResponseWriter, r *http.Request) {
+		startTime := time.Now()
+		if r.Method != http.MethodGet {
+			log.Printf("Data API: Method not allowed %s\n", r.Method)
+			writeErrorMessage(w, http.StatusMethodNotAllowed, "method_not_allowed", "Method not allowed")
+			return
+		}
+
+		ctx, cancel := requestContext(r, requestTimeout)
+		defer cancel()
+
+		query := r.URL.Query()
+		deviceId := query.Get("device")
+		if deviceId == "" {
+			deviceId = "root.example.exampledev" // 默认设备ID
+		}
+		if _, err := db_interface.ParseDevicePath(deviceId); err != nil {
+			log.Printf("Data API: Invalid device ID, Error: %v\n", err)
+			writeError(w, err)
+			return
+		}
+
+		var rangeStart, rangeEnd int64
+		var limit int
+		var errParse error
+		if s := query.Get("start"); s != "" {
+			if rangeStart, errParse = strconv.ParseInt(s, 10, 64); errParse != nil {
+				writeErrorMessage(w, http.StatusBadRequest, "invalid_request", "start must be a timestamp in milliseconds")
+				return
+			}
+		}
+		if s := query.Get("end"); s != "" {
+			if rangeEnd, errParse = strconv.ParseInt(s, 10, 64); errParse != nil {
+				writeErrorMessage(w, http.StatusBadRequest, "invalid_request", "end must be a timestamp in milliseconds")
+				return
+			}
+		}
+		if s := query.Get("limit"); s != "" {
+			if limit, errParse = strconv.Atoi(s); errParse != nil || limit <= 0 {
+				writeErrorMessage(w, http.StatusBadRequest, "invalid_request", "limit must be a positive integer")
+				return
+			}
+		}
+		cursor := query.Get("cursor")
+
+		log.Printf("Data API: Starting raw data query, Device ID: %s, Start: %d, End: %d, Limit: %d\n", deviceId, rangeStart, rangeEnd, limit)
+
+		var result string
+		err := pool.WithRetry(ctx, func(session client.Session) error {
+			var errHandle error
+			result, errHandle = handlers.HandleRawDataQuery(ctx, session, deviceId, rangeStart, rangeEnd, limit, cursor, timeout)
+			return errHandle
+		})
+		if err != nil {
+			log.Printf("Data API: Query failed, Error: %v\n", err)
+			writeError(w, err)
+			return
+		}
+
+		duration := time.Since(startTime)
+		log.Printf("Data API: Successfully completed raw data query, Device ID: %s, Duration: %v\n", deviceId, duration)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, result)
+	})
+
+	// 注册设备发现端点
+	http.HandleFunc("/devices", func(w http.ResponseWriter, r *http.Request) {
+		startTime := time.Now()
+		if r.Method != http.MethodGet {
+			log.Printf("Devices API: Method not allowed %s\n", r.Method)
+			writeErrorMessage(w, http.StatusMethodNotAllowed, "method_not_allowed", "Method not allowed")
+			return
+		}
+
+		ctx, cancel := requestContext(r, requestTimeout)
+		defer cancel()
+
+		log.Println("Devices API: Starting device discovery")
+
+		var result string
+		err := pool.WithRetry(ctx, func(session client.Session) error {
+			var errHandle error
+			result, errHandle = handlers.HandleDeviceList(ctx, session, timeout, handlers.FormatJSON)
+			return errHandle
+		})
+		if err != nil {
+			log.Printf("Devices API: Discovery failed, Error: %v\n", err)
+			writeError(w, err)
+			return
+		}
+
+		duration := time.Since(startTime)
+		log.Printf("Devices API: Successfully completed device discovery, Duration: %v\n", duration)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, result)
+	})
+
+	// 注册时间序列发现端点
+	http.HandleFunc("/devices/{path}/timeseries", func(w http.ResponseWriter, r *http.Request) {
+		startTime := time.Now()
+		if r.Method != http.MethodGet {
+			log.Printf("Timeseries API: Method not allowed %s\n", r.Method)
+			writeErrorMessage(w, http.StatusMethodNotAllowed, "method_not_allowed", "Method not allowed")
+			return
+		}
+
+		ctx, cancel := requestContext(r, requestTimeout)
+		defer cancel()
+
+		deviceId := r.PathValue("path")
+		if _, err := db_interface.ParseDevicePath(deviceId); err != nil {
+			log.Printf("Timeseries API: Invalid device ID, Error: %v\n", err)
+			writeError(w, err)
+			return
+		}
+
+		log.Printf("Timeseries API: Starting timeseries discovery, Device ID: %s\n", deviceId)
+
+		var result string
+		err := pool.WithRetry(ctx, func(session client.Session) error {
+			var errHandle error
+			result, errHandle = handlers.HandleTimeseriesList(ctx, session, deviceId, timeout, handlers.FormatJSON)
+			return errHandle
+		})
+		if err != nil {
+			log.Printf("Timeseries API: Discovery failed, Error: %v\n", err)
+			writeError(w, err)
+			return
+		}
+
+		duration := time.Since(startTime)
+		log.Printf("Timeseries API: Successfully completed timeseries discovery, Device ID: %s, Duration: %v\n", deviceId, duration)
+		w.Header().Set("Content-Type", "application/json")
 		fmt.Fprint(w, result)
 	})
 
@@ -196,3 +1018,11 @@ func Main() {
 		log.Fatal(err)
 	}
 }
+
+// requestContext ends when the client goes away or, if configured, when the request timeout passes
+func requestContext(r *http.Request, requestTimeout time.Duration) (context.Context, context.CancelFunc) {
+	if requestTimeout > 0 {
+		return context.WithTimeout(r.Context(), requestTimeout)
+	}
+	return context.WithCancel(r.Context())
+}
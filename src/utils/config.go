@@ -10,11 +10,19 @@ import (
 
 // IoTDBConfig represents the configuration for IoTDB connection
 type IoTDBConfig struct {
-	Host     string `json:"host"`
-	Port     string `json:"port"`
-	User     string `json:"user"`
-	Password string `json:"password"`
-	Timeout  int64  `json:"timeout"`
+	Host            string  `json:"host"`
+	Port            string  `json:"port"`
+	User            string  `json:"user"`
+	Password        string  `json:"password"`
+	Timeout         int64   `json:"timeout"`
+	RequestTimeout  int64   `json:"request_timeout"` // Bounds a whole request, while Timeout bounds one IoTDB query
+	PoolSize        int     `json:"pool_size"`
+	MaxRetries      int     `json:"max_retries"`
+	QuantileEpsilon float64 `json:"quantile_epsilon"` // Rank error bound of median, quartile and percentile estimates
+	ScanPartitions  int     `json:"scan_partitions"`  // Time slices a full-device analysis scans concurrently
+
+	BootstrapResamples int   `json:"bootstrap_resamples"` // Resamples behind bootstrap confidence intervals, 0 disables them
+	BootstrapSeed      int64 `json:"bootstrap_seed"`      // Seed of the resampling, fixed for reproducible intervals
 }
 
 // Source indicates where a configuration value came from
@@ -34,11 +42,19 @@ type ConfigWithSource struct {
 
 // IoTDBConfigWithSources represents the configuration with source information
 type IoTDBConfigWithSources struct {
-	Host     ConfigWithSource
-	Port     ConfigWithSource
-	User     ConfigWithSource
-	Password ConfigWithSource
-	Timeout  ConfigWithSource
+	Host            ConfigWithSource
+	Port            ConfigWithSource
+	User            ConfigWithSource
+	Password        ConfigWithSource
+	Timeout         ConfigWithSource
+	RequestTimeout  ConfigWithSource
+	PoolSize        ConfigWithSource
+	MaxRetries      ConfigWithSource
+	QuantileEpsilon ConfigWithSource
+	ScanPartitions  ConfigWithSource
+
+	BootstrapResamples ConfigWithSource
+	BootstrapSeed      ConfigWithSource
 }
 
 // LoadIoTDBConfig loads IoTDB configuration with proper precedence:
@@ -46,13 +62,20 @@ type IoTDBConfigWithSources struct {
 func LoadIoTDBConfig() (*IoTDBConfigWithSources, error) {
 	// Define flag variables
 	var (
-		flagHost     string
-		flagPort     string
-		flagUser     string
-		flagPassword string
-		flagTimeout  int
-		configFile   string
-		showConfig   bool
+		flagHost           string
+		flagPort           string
+		flagUser           string
+		flagPassword       string
+		flagTimeout        int
+		flagRequestTimeout int
+		flagPoolSize       int
+		flagRetries        int
+		flagEpsilon        float64
+		flagPartitions     int
+		flagResamples      int
+		flagSeed           int64
+		configFile         string
+		showConfig         bool
 	)
 
 	// Setup flags
@@ -61,100 +84,222 @@ func LoadIoTDBConfig() (*IoTDBConfigWithSources, error) {
 	flag.StringVar(&flagUser, "user", "", "IoTDB user")
 	flag.StringVar(&flagPassword, "password", "", "IoTDB password")
 	flag.IntVar(&flagTimeout, "timeout", 0, "Timeout in milliseconds")
+	flag.IntVar(&flagRequestTimeout, "request-timeout", 0, "Timeout of a whole request in milliseconds, 0 means no limit")
+	flag.IntVar(&flagPoolSize, "pool-size", 0, "Maximum number of concurrent IoTDB sessions")
+	flag.IntVar(&flagRetries, "max-retries", -1, "Retries for reconnecting and for idempotent reads")
+	flag.Float64Var(&flagEpsilon, "quantile-epsilon", 0, "Rank error bound of quantile estimates, e.g. 0.01")
+	flag.IntVar(&flagPartitions, "scan-partitions", 0, "Time slices a full-device analysis scans concurrently, 1 disables it")
+	flag.IntVar(&flagResamples, "bootstrap-resamples", -1, "Resamples behind bootstrap confidence intervals, 0 disables them")
+	flag.Int64Var(&flagSeed, "bootstrap-seed", 0, "Positive seed of the bootstrap resampling")
 	flag.StringVar(&configFile, "config", "config/iotdb.json", "Path to config file")
 	flag.BoolVar(&showConfig, "show-config", false, "Show configuration sources")
 
 	// Parse flags
 	flag.Parse()
 
-	// Load config from file
+	// Load config from file; a missing or unreadable file leaves every value at its default
 	fileConfig, err := loadConfigFromFile(configFile)
 	if err != nil {
-		// If file doesn't exist, use default values
-		fileConfig = &IoTDBConfig{
-			Host:     "127.0.0.1",
-			Port:     "6667",
-			User:     "root",
-			Password: "root",
-			Timeout:  1000,
-		}
+		fileConfig = &iotdbFileConfig{}
+	}
+	configWithSources := resolveFileConfig(fileConfig)
+
+	// Flag values take precedence over the file
+	if flagHost != "" {
+		configWithSources.Host = ConfigWithSource{Value: flagHost, Source: FlagValue}
+	}
+	if flagPort != "" {
+		configWithSources.Port = ConfigWithSource{Value: flagPort, Source: FlagValue}
+	}
+	if flagUser != "" {
+		configWithSources.User = ConfigWithSource{Value: flagUser, Source: FlagValue}
+	}
+	if flagPassword != "" {
+		configWithSources.Password = ConfigWithSource{Value: flagPassword, Source: FlagValue}
+	}
+	if flagTimeout > 0 {
+		configWithSources.Timeout = ConfigWithSource{Value: fmt.Sprintf("%d", flagTimeout), Source: FlagValue}
+	}
+	if flagRequestTimeout > 0 {
+		configWithSources.RequestTimeout = ConfigWithSource{Value: fmt.Sprintf("%d", flagRequestTimeout), Source: FlagValue}
+	}
+	if flagPoolSize > 0 {
+		configWithSources.PoolSize = ConfigWithSource{Value: fmt.Sprintf("%d", flagPoolSize), Source: FlagValue}
+	}
+	if flagRetries >= 0 {
+		configWithSources.MaxRetries = ConfigWithSource{Value: fmt.Sprintf("%d", flagRetries), Source: FlagValue}
+	}
+	if flagEpsilon > 0 {
+		configWithSources.QuantileEpsilon = ConfigWithSource{Value: strconv.FormatFloat(flagEpsilon, 'g', -1, 64), Source: FlagValue}
+	}
+	if flagPartitions > 0 {
+		configWithSources.ScanPartitions = ConfigWithSource{Value: fmt.Sprintf("%d", flagPartitions), Source: FlagValue}
+	}
+	if flagResamples >= 0 {
+		configWithSources.BootstrapResamples = ConfigWithSource{Value: fmt.Sprintf("%d", flagResamples), Source: FlagValue}
+	}
+	if flagSeed > 0 {
+		configWithSources.BootstrapSeed = ConfigWithSource{Value: fmt.Sprintf("%d", flagSeed), Source: FlagValue}
+	}
+
+	// Show configuration sources if requested
+	if showConfig {
+		fmt.Println("Configuration sources:")
+		fmt.Printf("Host: %s (%s)\n", configWithSources.Host.Value, configWithSources.Host.Source)
+		fmt.Printf("Port: %s (%s)\n", configWithSources.Port.Value, configWithSources.Port.Source)
+		fmt.Printf("User: %s (%s)\n", configWithSources.User.Value, configWithSources.User.Source)
+		fmt.Printf("Password: %s (%s)\n", configWithSources.Password.Value, configWithSources.Password.Source)
+		fmt.Printf("Timeout: %s (%s)\n", configWithSources.Timeout.Value, configWithSources.Timeout.Source)
+		fmt.Printf("RequestTimeout: %s (%s)\n", configWithSources.RequestTimeout.Value, configWithSources.RequestTimeout.Source)
+		fmt.Printf("PoolSize: %s (%s)\n", configWithSources.PoolSize.Value, configWithSources.PoolSize.Source)
+		fmt.Printf("MaxRetries: %s (%s)\n", configWithSources.MaxRetries.Value, configWithSources.MaxRetries.Source)
+		fmt.Printf("QuantileEpsilon: %s (%s)\n", configWithSources.QuantileEpsilon.Value, configWithSources.QuantileEpsilon.Source)
+		fmt.Printf("ScanPartitions: %s (%s)\n", configWithSources.ScanPartitions.Value, configWithSources.ScanPartitions.Source)
+		fmt.Printf("BootstrapResamples: %s (%s)\n", configWithSources.BootstrapResamples.Value, configWithSources.BootstrapResamples.Source)
+		fmt.Printf("BootstrapSeed: %s (%s)\n", configWithSources.BootstrapSeed.Value, configWithSources.BootstrapSeed.Source)
+	}
+
+	return configWithSources, nil
+}
+
+// LoadIoTDBConfigFile resolves the configuration of a JSON file, falling back to the default
+// of every value the file leaves out
+func LoadIoTDBConfigFile(filePath string) (*IoTDBConfigWithSources, error) {
+	fileConfig, err := loadConfigFromFile(filePath)
+	if err != nil {
+		return nil, err
 	}
+	return resolveFileConfig(fileConfig), nil
+}
 
-	// Determine the source of each configuration value
+// resolveFileConfig determines the source of each configuration value: file values > default values
+func resolveFileConfig(fileConfig *iotdbFileConfig) *IoTDBConfigWithSources {
 	configWithSources := &IoTDBConfigWithSources{}
 
 	// Host
-	if flagHost != "" {
-		configWithSources.Host = ConfigWithSource{Value: flagHost, Source: FlagValue}
-	} else if fileConfig.Host != "" {
+	if fileConfig.Host != "" {
 		configWithSources.Host = ConfigWithSource{Value: fileConfig.Host, Source: FileValue}
 	} else {
 		configWithSources.Host = ConfigWithSource{Value: "127.0.0.1", Source: DefaultValue}
 	}
 
 	// Port
-	if flagPort != "" {
-		configWithSources.Port = ConfigWithSource{Value: flagPort, Source: FlagValue}
-	} else if fileConfig.Port != "" {
+	if fileConfig.Port != "" {
 		configWithSources.Port = ConfigWithSource{Value: fileConfig.Port, Source: FileValue}
 	} else {
 		configWithSources.Port = ConfigWithSource{Value: "6667", Source: DefaultValue}
 	}
 
 	// User
-	if flagUser != "" {
-		configWithSources.User = ConfigWithSource{Value: flagUser, Source: FlagValue}
-	} else if fileConfig.User != "" {
+	if fileConfig.User != "" {
 		configWithSources.User = ConfigWithSource{Value: fileConfig.User, Source: FileValue}
 	} else {
 		configWithSources.User = ConfigWithSource{Value: "root", Source: DefaultValue}
 	}
 
 	// Password
-	if flagPassword != "" {
-		configWithSources.Password = ConfigWithSource{Value: flagPassword, Source: FlagValue}
-	} else if fileConfig.Password != "" {
+	if fileConfig.Password != "" {
 		configWithSources.Password = ConfigWithSource{Value: fileConfig.Password, Source: FileValue}
 	} else {
 		configWithSources.Password = ConfigWithSource{Value: "root", Source: DefaultValue}
 	}
 
 	// Timeout
-	if flagTimeout > 0 {
-		configWithSources.Timeout = ConfigWithSource{Value: fmt.Sprintf("%d", flagTimeout), Source: FlagValue}
-	} else if fileConfig.Timeout > 0 {
+	if fileConfig.Timeout > 0 {
 		configWithSources.Timeout = ConfigWithSource{Value: fmt.Sprintf("%d", fileConfig.Timeout), Source: FileValue}
 	} else {
 		configWithSources.Timeout = ConfigWithSource{Value: "1000", Source: DefaultValue}
 	}
 
-	// Show configuration sources if requested
-	if showConfig {
-		fmt.Println("Configuration sources:")
-		fmt.Printf("Host: %s (%s)\n", configWithSources.Host.Value, configWithSources.Host.Source)
-		fmt.Printf("Port: %s (%s)\n", configWithSources.Port.Value, configWithSources.Port.Source)
-		fmt.Printf("User: %s (%s)\n", configWithSources.User.Value, configWithSources.User.Source)
-		fmt.Printf("Password: %s (%s)\n", configWithSources.Password.Value, configWithSources.Password.Source)
-		fmt.Printf("Timeout: %s (%s)\n", configWithSources.Timeout.Value, configWithSources.Timeout.Source)
+	// RequestTimeout
+	if fileConfig.RequestTimeout > 0 {
+		configWithSources.RequestTimeout = ConfigWithSource{Value: fmt.Sprintf("%d", fileConfig.RequestTimeout), Source: FileValue}
+	} else {
+		configWithSources.RequestTimeout = ConfigWithSource{Value: "0", Source: DefaultValue}
 	}
 
-	return configWithSources, nil
+	// PoolSize
+	if fileConfig.PoolSize > 0 {
+		configWithSources.PoolSize = ConfigWithSource{Value: fmt.Sprintf("%d", fileConfig.PoolSize), Source: FileValue}
+	} else {
+		configWithSources.PoolSize = ConfigWithSource{Value: "8", Source: DefaultValue}
+	}
+
+	// MaxRetries, where an explicit 0 turns retries off
+	if fileConfig.MaxRetries != nil && *fileConfig.MaxRetries >= 0 {
+		configWithSources.MaxRetries = ConfigWithSource{Value: fmt.Sprintf("%d", *fileConfig.MaxRetries), Source: FileValue}
+	} else {
+		configWithSources.MaxRetries = ConfigWithSource{Value: "3", Source: DefaultValue}
+	}
+
+	// QuantileEpsilon
+	if fileConfig.QuantileEpsilon > 0 {
+		configWithSources.QuantileEpsilon = ConfigWithSource{Value: strconv.FormatFloat(fileConfig.QuantileEpsilon, 'g', -1, 64), Source: FileValue}
+	} else {
+		configWithSources.QuantileEpsilon = ConfigWithSource{Value: "0.01", Source: DefaultValue}
+	}
+
+	// ScanPartitions
+	if fileConfig.ScanPartitions > 0 {
+		configWithSources.ScanPartitions = ConfigWithSource{Value: fmt.Sprintf("%d", fileConfig.ScanPartitions), Source: FileValue}
+	} else {
+		configWithSources.ScanPartitions = ConfigWithSource{Value: "4", Source: DefaultValue}
+	}
+
+	// BootstrapResamples, where an explicit 0 disables bootstrap intervals
+	if fileConfig.BootstrapResamples != nil && *fileConfig.BootstrapResamples >= 0 {
+		configWithSources.BootstrapResamples = ConfigWithSource{Value: fmt.Sprintf("%d", *fileConfig.BootstrapResamples), Source: FileValue}
+	} else {
+		configWithSources.BootstrapResamples = ConfigWithSource{Value: "1000", Source: DefaultValue}
+	}
+
+	// BootstrapSeed
+	if fileConfig.BootstrapSeed > 0 {
+		configWithSources.BootstrapSeed = ConfigWithSource{Value: fmt.Sprintf("%d", fileConfig.BootstrapSeed), Source: FileValue}
+	} else {
+		configWithSources.BootstrapSeed = ConfigWithSource{Value: "1", Source: DefaultValue}
+	}
+
+	return configWithSources
 }
 
 // ToIoTDBConfig converts IoTDBConfigWithSources to IoTDBConfig
 func (c *IoTDBConfigWithSources) ToIoTDBConfig() *IoTDBConfig {
 	timeout, _ := strconv.ParseInt(c.Timeout.Value, 0, 64)
+	requestTimeout, _ := strconv.ParseInt(c.RequestTimeout.Value, 0, 64)
+	poolSize, _ := strconv.Atoi(c.PoolSize.Value)
+	maxRetries, _ := strconv.Atoi(c.MaxRetries.Value)
+	quantileEpsilon, _ := strconv.ParseFloat(c.QuantileEpsilon.Value, 64)
+	scanPartitions, _ := strconv.Atoi(c.ScanPartitions.Value)
+	bootstrapResamples, _ := strconv.Atoi(c.BootstrapResamples.Value)
+	bootstrapSeed, _ := strconv.ParseInt(c.BootstrapSeed.Value, 0, 64)
 	return &IoTDBConfig{
-		Host:     c.Host.Value,
-		Port:     c.Port.Value,
-		User:     c.User.Value,
-		Password: c.Password.Value,
-		Timeout:  timeout,
+		Host:            c.Host.Value,
+		Port:            c.Port.Value,
+		User:            c.User.Value,
+		Password:        c.Password.Value,
+		Timeout:         timeout,
+		RequestTimeout:  requestTimeout,
+		PoolSize:        poolSize,
+		MaxRetries:      maxRetries,
+		QuantileEpsilon: quantileEpsilon,
+		ScanPartitions:  scanPartitions,
+
+		BootstrapResamples: bootstrapResamples,
+		BootstrapSeed:      bootstrapSeed,
 	}
 }
 
+// iotdbFileConfig is IoTDBConfig as read from a file. Fields for which 0 is a meaningful
+// setting are pointers, so that a field left out of the file can be told apart from 0.
+type iotdbFileConfig struct {
+	IoTDBConfig
+	MaxRetries         *int `json:"max_retries"`
+	BootstrapResamples *int `json:"bootstrap_resamples"`
+}
+
 // loadConfigFromFile loads configuration from a JSON file
-func loadConfigFromFile(filePath string) (*IoTDBConfig, error) {
+func loadConfigFromFile(filePath string) (*iotdbFileConfig, error) {
 	// Check if file exists
 	if _, err := os.Stat(filePath); os.IsNotExist(err) {
 		return nil, err
@@ -167,7 +312,7 @@ func loadConfigFromFile(filePath string) (*IoTDBConfig, error) {
 	}
 
 	// Parse JSON
-	var config IoTDBConfig
+	var config iotdbFileConfig
 	if err := json.Unmarshal(data, &config); err != nil {
 		return nil, err
 	}
@@ -2,6 +2,7 @@ package utils
 
 import (
 	"bdgp2025/src/db_interface"
+	"context"
 	"encoding/csv"
 	"fmt"
 	"io"
@@ -30,8 +31,7 @@ func ReadinCSVOneByOne(args ...interface{}) error {
 		},
 	}
 
-	db_interface.InsertRecordsOfOneDevice(session, deviceId, dataMatrix, ts)
-	return nil
+	return db_interface.InsertRecordsOfOneDevice(session, deviceId, dataMatrix, ts)
 }
 
 // CSVRecord represents a single row from the CSV file
@@ -46,8 +46,8 @@ type CSVRecord struct {
 }
 
 // ImportCSVFile reads the entire CSV file and applies the provided function to each record
-// The file is processed row by row to handle large files efficiently
-func ImportCSVFile(filePath string, processFunc func(...interface{}) error, args ...interface{}) error {
+// The file is processed row by row to handle large files efficiently, and stops early once ctx is done
+func ImportCSVFile(ctx context.Context, filePath string, processFunc func(...interface{}) error, args ...interface{}) error {
 	var session client.Session = args[0].(client.Session)
 	var deviceId string = args[1].(string)
 
@@ -77,6 +77,9 @@ func ImportCSVFile(filePath string, processFunc func(...interface{}) error, args
 	cnt := 0
 	var ts int64 = time.Now().UTC().UnixNano() / 1000000
 	for {
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("import stopped after %d items: %w", cnt, err)
+		}
 		row, err := reader.Read()
 		if err == io.EOF {
 			break
@@ -140,7 +143,7 @@ func ImportCSVFile(filePath string, processFunc func(...interface{}) error, args
 		// Apply the provided function to the record
 		ts++ // Avoid data overwriting
 		if err := processFunc(record, session, deviceId, ts); err != nil {
-			return fmt.Errorf("error processing record: %v", err)
+			return fmt.Errorf("error processing record: %w", err)
 		}
 		cnt++
 	}
@@ -0,0 +1,226 @@
+package filter
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Expression is a parsed filter such as "engine_rpm > 1000 and coolant_temp < 90".
+//
+// Grammar (keywords are case-insensitive):
+//
+//	expr       := orExpr
+//	orExpr     := andExpr { ("or" | "||") andExpr }
+//	andExpr    := notExpr { ("and" | "&&") notExpr }
+//	notExpr    := ("not" | "!") notExpr | primary
+//	primary    := "(" expr ")" | comparison
+//	comparison := measurement op literal
+//	op         := "=" | "==" | "!=" | "<>" | "<" | "<=" | ">" | ">="
+//	literal    := number | 'string' | "string" | true | false
+//
+// Measurements must be plain identifiers, so an expression can never smuggle
+// extra statements into the generated WHERE clause.
+type Expression struct {
+	root node
+}
+
+type node interface {
+	sql(quote func(string) string) string
+	eval(row map[string]interface{}) bool
+	measurements(seen map[string]bool, out []string) []string
+}
+
+type logicalNode struct {
+	op          string // "and" or "or"
+	left, right node
+}
+
+type notNode struct {
+	operand node
+}
+
+type comparisonNode struct {
+	measurement string
+	op          string
+	value       interface{} // float64, string or bool
+}
+
+// Parse parses a filter expression. An empty or blank input yields a nil
+// Expression, which every consumer treats as "no filter".
+func Parse(input string) (*Expression, error) {
+	if strings.TrimSpace(input) == "" {
+		return nil, nil
+	}
+
+	tokens, err := tokenize(input)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.peek().kind != tokenEOF {
+		return nil, fmt.Errorf("filter: unexpected %q at position %d", p.peek().text, p.peek().pos)
+	}
+	return &Expression{root: root}, nil
+}
+
+// SQL renders the expression as the body of an IoTDB WHERE clause.
+func (e *Expression) SQL() string {
+	return e.SQLWithQuote(func(name string) string { return name })
+}
+
+// SQLWithQuote renders the expression, passing every measurement name through quote.
+func (e *Expression) SQLWithQuote(quote func(string) string) string {
+	if e == nil {
+		return ""
+	}
+	return e.root.sql(quote)
+}
+
+// Eval evaluates the expression against one row keyed by measurement name.
+// Comparisons against a missing or null measurement are false. A nil
+// Expression accepts every row.
+func (e *Expression) Eval(row map[string]interface{}) bool {
+	if e == nil {
+		return true
+	}
+	return e.root.eval(row)
+}
+
+// Measurements returns the distinct measurement names referenced by the expression.
+func (e *Expression) Measurements() []string {
+	if e == nil {
+		return nil
+	}
+	return e.root.measurements(map[string]bool{}, nil)
+}
+
+// String returns the canonical form of the expression.
+func (e *Expression) String() string {
+	return e.SQL()
+}
+
+func (n *logicalNode) sql(quote func(string) string) string {
+	return "(" + n.left.sql(quote) + " " + n.op + " " + n.right.sql(quote) + ")"
+}
+
+func (n *logicalNode) eval(row map[string]interface{}) bool {
+	if n.op == "and" {
+		return n.left.eval(row) && n.right.eval(row)
+	}
+	return n.left.eval(row) || n.right.eval(row)
+}
+
+func (n *logicalNode) measurements(seen map[string]bool, out []string) []string {
+	out = n.left.measurements(seen, out)
+	return n.right.measurements(seen, out)
+}
+
+func (n *notNode) sql(quote func(string) string) string {
+	return "not (" + n.operand.sql(quote) + ")"
+}
+
+func (n *notNode) eval(row map[string]interface{}) bool {
+	return !n.operand.eval(row)
+}
+
+func (n *notNode) measurements(seen map[string]bool, out []string) []string {
+	return n.operand.measurements(seen, out)
+}
+
+func (n *comparisonNode) sql(quote func(string) string) string {
+	return quote(n.measurement) + " " + n.op + " " + formatLiteral(n.value)
+}
+
+func (n *comparisonNode) eval(row map[string]interface{}) bool {
+	actual, ok := row[n.measurement]
+	if !ok || actual == nil {
+		return false
+	}
+
+	switch expected := n.value.(type) {
+	case float64:
+		value, ok := toFloat(actual)
+		if !ok {
+			return false
+		}
+		return compareOrdered(value, expected, n.op)
+	case string:
+		value, ok := actual.(string)
+		if !ok {
+			return false
+		}
+		return compareOrdered(value, expected, n.op)
+	case bool:
+		value, ok := actual.(bool)
+		if !ok {
+			return false
+		}
+		switch n.op {
+		case "=":
+			return value == expected
+		case "!=":
+			return value != expected
+		}
+	}
+	return false
+}
+
+func (n *comparisonNode) measurements(seen map[string]bool, out []string) []string {
+	if !seen[n.measurement] {
+		seen[n.measurement] = true
+		out = append(out, n.measurement)
+	}
+	return out
+}
+
+func compareOrdered[T float64 | string](actual, expected T, op string) bool {
+	switch op {
+	case "=":
+		return actual == expected
+	case "!=":
+		return actual != expected
+	case "<":
+		return actual < expected
+	case "<=":
+		return actual <= expected
+	case ">":
+		return actual > expected
+	case ">=":
+		return actual >= expected
+	}
+	return false
+}
+
+func toFloat(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	}
+	return 0, false
+}
+
+func formatLiteral(value interface{}) string {
+	switch v := value.(type) {
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	case string:
+		return "'" + strings.ReplaceAll(v, "'", "''") + "'"
+	case bool:
+		return strconv.FormatBool(v)
+	}
+	return ""
+}
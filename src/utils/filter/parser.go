@@ -0,0 +1,250 @@
+package filter
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenNumber
+	tokenString
+	tokenOperator
+	tokenLParen
+	tokenRParen
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+func tokenize(input string) ([]token, error) {
+	tokens := make([]token, 0)
+	runes := []rune(input)
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(':
+			tokens = append(tokens, token{kind: tokenLParen, text: "(", pos: i})
+			i++
+		case r == ')':
+			tokens = append(tokens, token{kind: tokenRParen, text: ")", pos: i})
+			i++
+		case r == '\'' || r == '"':
+			// Quotes are escaped by doubling them, as in SQL
+			start := i
+			var sb strings.Builder
+			i++
+			closed := false
+			for i < len(runes) {
+				if runes[i] == r {
+					if i+1 < len(runes) && runes[i+1] == r {
+						sb.WriteRune(r)
+						i += 2
+						continue
+					}
+					i++
+					closed = true
+					break
+				}
+				sb.WriteRune(runes[i])
+				i++
+			}
+			if !closed {
+				return nil, fmt.Errorf("filter: unterminated string starting at position %d", start)
+			}
+			tokens = append(tokens, token{kind: tokenString, text: sb.String(), pos: start})
+		case strings.ContainsRune("=!<>&|", r):
+			start := i
+			op := string(r)
+			if i+1 < len(runes) {
+				two := string(runes[i : i+2])
+				switch two {
+				case "==", "!=", "<>", "<=", ">=", "&&", "||":
+					op = two
+				}
+			}
+			if op == "&" || op == "|" {
+				return nil, fmt.Errorf("filter: unexpected %q at position %d", op, start)
+			}
+			tokens = append(tokens, token{kind: tokenOperator, text: op, pos: start})
+			i += len(op)
+		case unicode.IsDigit(r) || r == '.' || ((r == '-' || r == '+') && i+1 < len(runes) && (unicode.IsDigit(runes[i+1]) || runes[i+1] == '.')):
+			start := i
+			i++
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.' || runes[i] == 'e' || runes[i] == 'E' ||
+				((runes[i] == '-' || runes[i] == '+') && (runes[i-1] == 'e' || runes[i-1] == 'E'))) {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenNumber, text: string(runes[start:i]), pos: start})
+		case r == '_' || (r < unicode.MaxASCII && unicode.IsLetter(r)):
+			start := i
+			for i < len(runes) && (runes[i] == '_' || (runes[i] < unicode.MaxASCII && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i])))) {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenIdent, text: string(runes[start:i]), pos: start})
+		default:
+			return nil, fmt.Errorf("filter: unexpected character %q at position %d", r, i)
+		}
+	}
+
+	tokens = append(tokens, token{kind: tokenEOF, text: "end of input", pos: len(runes)})
+	return tokens, nil
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) isKeyword(t token, keyword string, symbol string) bool {
+	if t.kind == tokenIdent && strings.EqualFold(t.text, keyword) {
+		return true
+	}
+	return symbol != "" && t.kind == tokenOperator && t.text == symbol
+}
+
+func (p *parser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.isKeyword(p.peek(), "or", "||") {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &logicalNode{op: "or", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.isKeyword(p.peek(), "and", "&&") {
+		p.next()
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = &logicalNode{op: "and", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseNot() (node, error) {
+	if p.isKeyword(p.peek(), "not", "!") {
+		p.next()
+		operand, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return &notNode{operand: operand}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (node, error) {
+	t := p.next()
+	if t.kind == tokenLParen {
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); closing.kind != tokenRParen {
+			return nil, fmt.Errorf("filter: expected \")\" at position %d, got %q", closing.pos, closing.text)
+		}
+		return inner, nil
+	}
+
+	if t.kind != tokenIdent || isReserved(t.text) {
+		return nil, fmt.Errorf("filter: expected measurement name at position %d, got %q", t.pos, t.text)
+	}
+
+	opToken := p.next()
+	if opToken.kind != tokenOperator {
+		return nil, fmt.Errorf("filter: expected comparison operator at position %d, got %q", opToken.pos, opToken.text)
+	}
+	op, ok := normalizeOperator(opToken.text)
+	if !ok {
+		return nil, fmt.Errorf("filter: unsupported operator %q at position %d", opToken.text, opToken.pos)
+	}
+
+	value, err := p.parseLiteral()
+	if err != nil {
+		return nil, err
+	}
+	if _, isBool := value.(bool); isBool && op != "=" && op != "!=" {
+		return nil, fmt.Errorf("filter: operator %q cannot be used with a boolean at position %d", opToken.text, opToken.pos)
+	}
+
+	return &comparisonNode{measurement: t.text, op: op, value: value}, nil
+}
+
+func (p *parser) parseLiteral() (interface{}, error) {
+	t := p.next()
+	switch t.kind {
+	case tokenNumber:
+		value, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("filter: invalid number %q at position %d", t.text, t.pos)
+		}
+		return value, nil
+	case tokenString:
+		return t.text, nil
+	case tokenIdent:
+		if strings.EqualFold(t.text, "true") {
+			return true, nil
+		}
+		if strings.EqualFold(t.text, "false") {
+			return false, nil
+		}
+	}
+	return nil, fmt.Errorf("filter: expected a number, string or boolean at position %d, got %q", t.pos, t.text)
+}
+
+func normalizeOperator(op string) (string, bool) {
+	switch op {
+	case "=", "==":
+		return "=", true
+	case "!=", "<>":
+		return "!=", true
+	case "<", "<=", ">", ">=":
+		return op, true
+	}
+	return "", false
+}
+
+func isReserved(word string) bool {
+	switch strings.ToLower(word) {
+	case "and", "or", "not", "true", "false":
+		return true
+	}
+	return false
+}
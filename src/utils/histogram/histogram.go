@@ -35,6 +35,14 @@ type HistogramResult struct {
 	Mean        float64         `json:"mean"`
 	StdDev      float64         `json:"std_dev"`
 	DataSummary DataSummary     `json:"data_summary"`
+	Curves      []Curve         `json:"curves,omitempty"`
+}
+
+// Curve is a density drawn over the bars of SaveAsHTML, e.g. a fitted distribution. It is
+// scaled by the number of values and the bin width so that it matches the bar heights.
+type Curve struct {
+	Name string                  `json:"name"`
+	PDF  func(x float64) float64 `json:"-"`
 }
 
 // Bin represents a single histogram bin
@@ -88,21 +96,11 @@ func NewStreamingHistogram(config HistogramConfig) *StreamingHistogram {
 	}
 }
 
-// AddValue adds a single value to the histogram
+// AddValue adds a single value to the histogram. Values are binned by Finalize, once the
+// range of the data is known.
 func (sh *StreamingHistogram) AddValue(value float64) {
 	sh.updateSummary(value)
 	sh.dataPoints = append(sh.dataPoints, value)
-
-	if !sh.initialized && sh.dataSummary.Count > 0 {
-		sh.initializeBins()
-	}
-
-	if sh.initialized {
-		binIndex := sh.findBinIndex(value)
-		if binIndex >= 0 && binIndex < len(sh.bins) {
-			sh.bins[binIndex].Count++
-		}
-	}
 }
 
 // AddBatch adds a batch of values to the histogram
@@ -112,10 +110,21 @@ func (sh *StreamingHistogram) AddBatch(values []float64) {
 	}
 }
 
+// Values returns every value added so far. The slice is shared with the histogram.
+func (sh *StreamingHistogram) Values() []float64 {
+	return sh.dataPoints
+}
+
 // Finalize completes the histogram computation and calculates final statistics
 func (sh *StreamingHistogram) Finalize() *HistogramResult {
 	if !sh.initialized && sh.dataSummary.Count > 0 {
 		sh.initializeBins()
+		for _, value := range sh.dataPoints {
+			binIndex := sh.findBinIndex(value)
+			if binIndex >= 0 && binIndex < len(sh.bins) {
+				sh.bins[binIndex].Count++
+			}
+		}
 	}
 
 	sh.calculateFinalStatistics()
@@ -277,9 +286,31 @@ func (hr *HistogramResult) SaveAsHTML(filename string) error {
 			}),
 		)
 
+	// Expected count of each bin under the curve, evaluated at the bin middle
+	for _, curve := range hr.Curves {
+		lineData := make([]opts.LineData, len(hr.Bins))
+		for i, bin := range hr.Bins {
+			middle := (bin.LowerBound + bin.UpperBound) / 2
+			lineData[i] = opts.LineData{Value: curve.PDF(middle) * float64(hr.TotalCount) * (bin.UpperBound - bin.LowerBound)}
+		}
+		line := charts.NewLine()
+		line.SetXAxis(xAxisData).
+			AddSeries(curve.Name, lineData).
+			SetSeriesOptions(charts.WithLineChartOpts(opts.LineChart{
+				Smooth:     opts.Bool(true),
+				ShowSymbol: opts.Bool(false),
+			}))
+		histogram.Overlap(line)
+	}
+
 	return histogram.Render(file)
 }
 
+// AddCurve overlays a density on the histogram drawn by SaveAsHTML
+func (hr *HistogramResult) AddCurve(name string, pdf func(x float64) float64) {
+	hr.Curves = append(hr.Curves, Curve{Name: name, PDF: pdf})
+}
+
 // GenerateAllFormats generates JSON, HTML, and provides PNG conversion info
 func (hr *HistogramResult) GenerateAllFormats() error {
 	prefix := hr.Config.OutputPrefix
@@ -394,6 +425,11 @@ func (sh *StreamingHistogram) findBinIndex(value float64) int {
 		return -1
 	}
 
+	// The maximum sits on the last edge and belongs to the last bin
+	if value == sh.binEdges[len(sh.binEdges)-1] {
+		return len(sh.bins) - 1
+	}
+
 	// Binary search for the correct bin
 	left, right := 0, len(sh.binEdges)-1
 	for left <= right {
@@ -0,0 +1,233 @@
+package rolling
+
+import (
+	"bdgp2025/src/utils/stats"
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"strconv"
+	"time"
+)
+
+const (
+	KindCount = "count" // Windows of a fixed number of rows
+	KindTime  = "time"  // Windows of a fixed duration
+)
+
+// DefaultPercentiles are reported when no percentiles are requested
+var DefaultPercentiles = []float64{5, 50, 95}
+
+// Config describes the windows. Length and Step are rows for count windows and milliseconds
+// for time windows. A Step equal to Length gives tumbling windows, a smaller one sliding
+// windows that overlap and a larger one hopping windows with gaps between them.
+type Config struct {
+	Kind        string    `json:"kind"`
+	Length      int64     `json:"length"`
+	Step        int64     `json:"step"`
+	Percentiles []float64 `json:"percentiles"` // 0-100
+}
+
+// ParseConfig reads a window and a step given as a row count such as "500" or a duration
+// such as "30s" or "5m", and a comma-separated list of percentiles. An empty step makes the
+// windows tumble and empty percentiles select DefaultPercentiles.
+func ParseConfig(window string, step string, percentiles string) (Config, error) {
+	kind, length, err := parseLength(window)
+	if err != nil {
+		return Config{}, fmt.Errorf("window: %w", err)
+	}
+	config := Config{Kind: kind, Length: length, Step: length, Percentiles: DefaultPercentiles}
+	if step != "" {
+		stepKind, stepLength, err := parseLength(step)
+		if err != nil {
+			return Config{}, fmt.Errorf("step: %w", err)
+		}
+		if stepKind != kind {
+			return Config{}, fmt.Errorf("step %q must be a %s like the window %q", step, unitOf(kind), window)
+		}
+		config.Step = stepLength
+	}
+	if percentiles != "" {
+		if config.Percentiles, err = stats.ParsePercentiles(percentiles); err != nil {
+			return Config{}, err
+		}
+	}
+	return config, nil
+}
+
+func parseLength(s string) (string, int64, error) {
+	if rows, err := strconv.ParseInt(s, 10, 64); err == nil {
+		if rows < 1 {
+			return "", 0, fmt.Errorf("row count must be positive, got %d", rows)
+		}
+		return KindCount, rows, nil
+	}
+	duration, err := time.ParseDuration(s)
+	if err != nil {
+		return "", 0, fmt.Errorf("expected a row count or a duration such as 30s, got %q", s)
+	}
+	if duration < time.Millisecond {
+		return "", 0, fmt.Errorf("duration must be at least 1ms, got %s", s)
+	}
+	return KindTime, duration.Milliseconds(), nil
+}
+
+func unitOf(kind string) string {
+	if kind == KindCount {
+		return "row count"
+	}
+	return "duration"
+}
+
+// Series holds one entry per window in every slice. Start and End bound the window: for
+// time windows the half-open range [Start, End), for count windows the timestamps of its
+// first and last row. Windows without rows are left out.
+type Series struct {
+	Config      Config               `json:"config"`
+	Start       []int64              `json:"start"`
+	End         []int64              `json:"end"`
+	Count       []int                `json:"count"`
+	Mean        []float64            `json:"mean"`
+	StdDev      []float64            `json:"std_dev"`
+	Min         []float64            `json:"min"`
+	Max         []float64            `json:"max"`
+	Percentiles map[string][]float64 `json:"percentiles"` // Keyed by stats.PercentileName
+}
+
+// Len returns the number of windows
+func (s *Series) Len() int {
+	return len(s.Start)
+}
+
+// Roller turns rows arriving in time order into window statistics. It keeps the rows of the
+// current window in arrival order and, for min, max and percentiles, in sorted order.
+type Roller struct {
+	config Config
+	series *Series
+
+	times     []int64
+	values    []float64
+	sorted    []float64
+	started   bool
+	start     int64 // Start of the current time window
+	skip      int64 // Rows to drop before the next count window of hopping windows
+	uncovered int   // Rows added since the last emitted window
+}
+
+// NewRoller validates config and returns a Roller for it
+func NewRoller(config Config) (*Roller, error) {
+	if config.Kind != KindCount && config.Kind != KindTime {
+		return nil, fmt.Errorf("unknown window kind %q, expected %s or %s", config.Kind, KindCount, KindTime)
+	}
+	if config.Length < 1 {
+		return nil, fmt.Errorf("window length must be positive, got %d", config.Length)
+	}
+	if config.Step < 1 {
+		config.Step = config.Length
+	}
+	series := &Series{Config: config, Percentiles: make(map[string][]float64, len(config.Percentiles))}
+	for _, p := range config.Percentiles {
+		series.Percentiles[stats.PercentileName(p)] = []float64{}
+	}
+	series.Start, series.End, series.Count = []int64{}, []int64{}, []int{}
+	series.Mean, series.StdDev, series.Min, series.Max = []float64{}, []float64{}, []float64{}, []float64{}
+	return &Roller{config: config, series: series}, nil
+}
+
+// Add feeds the next row. Timestamps must not decrease.
+func (r *Roller) Add(timestamp int64, value float64) {
+	if r.config.Kind == KindCount {
+		if r.skip > 0 {
+			r.skip--
+			return
+		}
+		r.push(timestamp, value)
+		if int64(len(r.values)) == r.config.Length {
+			r.emit(r.times[0], r.times[len(r.times)-1])
+			r.evict(int(min(r.config.Step, r.config.Length)))
+			r.skip = max(0, r.config.Step-r.config.Length)
+		}
+		return
+	}
+
+	if !r.started {
+		r.start, r.started = timestamp, true
+	}
+	length, step := r.config.Length, r.config.Step
+	for timestamp >= r.start+length {
+		if len(r.values) > 0 {
+			r.emit(r.start, r.start+length)
+		}
+		r.start += step
+		evicted := 0
+		for evicted < len(r.times) && r.times[evicted] < r.start {
+			evicted++
+		}
+		r.evict(evicted)
+		if len(r.values) == 0 && timestamp >= r.start+length {
+			// Jump over the empty windows to the first one that ends after timestamp
+			r.start += ((timestamp-length-r.start)/step + 1) * step
+		}
+	}
+	if timestamp >= r.start { // Otherwise the row falls in a gap between hopping windows
+		r.push(timestamp, value)
+	}
+}
+
+// Finish emits a last, partial window if rows arrived since the previous one and returns
+// the series
+func (r *Roller) Finish() *Series {
+	if r.uncovered > 0 && len(r.values) > 0 {
+		if r.config.Kind == KindCount {
+			r.emit(r.times[0], r.times[len(r.times)-1])
+		} else {
+			r.emit(r.start, r.start+r.config.Length)
+		}
+	}
+	return r.series
+}
+
+func (r *Roller) push(timestamp int64, value float64) {
+	r.times = append(r.times, timestamp)
+	r.values = append(r.values, value)
+	r.sorted = slices.Insert(r.sorted, sort.SearchFloat64s(r.sorted, value), value)
+	r.uncovered++
+}
+
+// evict drops the n oldest rows
+func (r *Roller) evict(n int) {
+	for _, value := range r.values[:n] {
+		i := sort.SearchFloat64s(r.sorted, value)
+		r.sorted = slices.Delete(r.sorted, i, i+1)
+	}
+	r.times = slices.Delete(r.times, 0, n)
+	r.values = slices.Delete(r.values, 0, n)
+}
+
+func (r *Roller) emit(start int64, end int64) {
+	s := r.series
+	n := len(r.sorted)
+	mean, m2 := 0.0, 0.0
+	for i, x := range r.values {
+		delta := x - mean
+		mean += delta / float64(i+1)
+		m2 += delta * (x - mean)
+	}
+	stdDev := 0.0
+	if n > 1 {
+		stdDev = math.Sqrt(m2 / float64(n-1))
+	}
+
+	s.Start = append(s.Start, start)
+	s.End = append(s.End, end)
+	s.Count = append(s.Count, n)
+	s.Mean = append(s.Mean, mean)
+	s.StdDev = append(s.StdDev, stdDev)
+	s.Min = append(s.Min, r.sorted[0])
+	s.Max = append(s.Max, r.sorted[n-1])
+	for _, p := range r.config.Percentiles {
+		name := stats.PercentileName(p)
+		s.Percentiles[name] = append(s.Percentiles[name], stats.LinearQuantile(r.sorted, p/100))
+	}
+	r.uncovered = 0
+}
@@ -0,0 +1,60 @@
+package sketch
+
+// distinctExactLimit is the number of distinct values counted exactly. Beyond it the set
+// would outgrow the 16 KiB of a HyperLogLog many times over, so the counter switches to one.
+const distinctExactLimit = 1 << 14
+
+// Distinct counts the distinct values of a stream, exactly while there are at most
+// distinctExactLimit of them and with a HyperLogLog estimate above. Two counters built over
+// separate parts of the data can be merged. The zero value is not usable, call NewDistinct.
+type Distinct struct {
+	exact map[float64]struct{} // nil once the counter has switched to the estimate
+	hll   *HyperLogLog
+}
+
+// NewDistinct creates an empty counter
+func NewDistinct() *Distinct {
+	return &Distinct{exact: make(map[float64]struct{})}
+}
+
+// Add offers one value to the counter. NaN must not be added.
+func (d *Distinct) Add(x float64) {
+	if d.exact == nil {
+		d.hll.Add(x)
+		return
+	}
+	d.exact[x] = struct{}{}
+	if len(d.exact) > distinctExactLimit {
+		d.switchToEstimate()
+	}
+}
+
+// Merge folds other into d
+func (d *Distinct) Merge(other *Distinct) {
+	if other.exact == nil {
+		if d.exact != nil {
+			d.switchToEstimate()
+		}
+		d.hll.Merge(other.hll)
+		return
+	}
+	for x := range other.exact {
+		d.Add(x)
+	}
+}
+
+// Count returns the number of distinct values and whether it is exact
+func (d *Distinct) Count() (int64, bool) {
+	if d.exact != nil {
+		return int64(len(d.exact)), true
+	}
+	return d.hll.Estimate(), false
+}
+
+func (d *Distinct) switchToEstimate() {
+	d.hll = NewHyperLogLog()
+	for x := range d.exact {
+		d.hll.Add(x)
+	}
+	d.exact = nil
+}
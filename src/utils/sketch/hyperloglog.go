@@ -0,0 +1,70 @@
+package sketch
+
+import (
+	"math"
+	"math/bits"
+)
+
+// hllPrecision sets 2^14 registers, a relative standard error of 1.04 / √16384 ≈ 0.8%
+const hllPrecision = 14
+
+// HyperLogLog estimates the number of distinct values of a stream in a fixed 16 KiB
+// (Flajolet et al.), using linear counting while many registers are still empty. Two
+// sketches built over separate parts of the data merge into the sketch of the union.
+type HyperLogLog struct {
+	registers []uint8
+}
+
+// NewHyperLogLog creates an empty sketch
+func NewHyperLogLog() *HyperLogLog {
+	return &HyperLogLog{registers: make([]uint8, 1<<hllPrecision)}
+}
+
+// Add offers one value to the sketch
+func (h *HyperLogLog) Add(x float64) {
+	hash := hashFloat(x)
+	register := hash >> (64 - hllPrecision)
+	// Position of the first set bit in the remaining bits, which are guarded by a trailing 1
+	rank := uint8(bits.LeadingZeros64(hash<<hllPrecision|1<<(hllPrecision-1)) + 1)
+	if rank > h.registers[register] {
+		h.registers[register] = rank
+	}
+}
+
+// Merge folds other into h
+func (h *HyperLogLog) Merge(other *HyperLogLog) {
+	for j, rank := range other.registers {
+		if rank > h.registers[j] {
+			h.registers[j] = rank
+		}
+	}
+}
+
+// Estimate returns the estimated number of distinct values added
+func (h *HyperLogLog) Estimate() int64 {
+	m := float64(len(h.registers))
+	sum, zeros := 0.0, 0
+	for _, rank := range h.registers {
+		sum += math.Ldexp(1, -int(rank))
+		if rank == 0 {
+			zeros++
+		}
+	}
+	estimate := 0.7213 / (1 + 1.079/m) * m * m / sum
+	if estimate <= 2.5*m && zeros > 0 {
+		estimate = m * math.Log(m/float64(zeros))
+	}
+	return int64(math.Round(estimate))
+}
+
+// hashFloat mixes the bits of x with the splitmix64 finalizer. -0 hashes like 0 because the
+// two compare equal.
+func hashFloat(x float64) uint64 {
+	if x == 0 {
+		x = 0
+	}
+	z := math.Float64bits(x)
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	return z ^ (z >> 31)
+}
@@ -0,0 +1,278 @@
+package sketch
+
+import (
+	"math"
+	"sort"
+)
+
+const (
+	defaultEpsilon = 0.01
+	minK           = 8
+	maxK           = 65535
+	minCapacity    = 8       // Smallest capacity of a single compactor
+	capacityDecay  = 2.0 / 3 // Each lower level holds 2/3 of the level above it
+)
+
+// Config holds configuration for quantile sketches
+type Config struct {
+	Epsilon float64 `json:"epsilon"` // Normalized rank error bound, e.g. 0.01 for ±1% of the row count
+}
+
+// DefaultConfig returns a default sketch configuration
+func DefaultConfig() Config {
+	return Config{Epsilon: defaultEpsilon}
+}
+
+// KLL is the quantile sketch of Karnin, Lang and Liberty. It keeps a few hundred values per
+// level and about log2(n/k) levels, so its memory grows with the error bound rather than with
+// the number of values. Two sketches built over separate parts of the data can be merged.
+type KLL struct {
+	k          int
+	compactors [][]float64 // compactors[h] holds values that each stand for 2^h inputs
+	size       int         // Values retained over all levels
+	maxSize    int
+	count      int64
+	min        float64
+	max        float64
+	seed       uint64
+}
+
+// NewKLL creates a sketch whose quantiles are within config.Epsilon of the true rank
+// with high probability
+func NewKLL(config Config) *KLL {
+	s := &KLL{
+		k:    kForEpsilon(config.Epsilon),
+		min:  math.Inf(1),
+		max:  math.Inf(-1),
+		seed: 0x9e3779b97f4a7c15,
+	}
+	s.grow()
+	return s
+}
+
+// kForEpsilon inverts the empirical error of KLL, eps = 2.296 / k^0.9723, taken from the
+// Apache DataSketches implementation
+func kForEpsilon(epsilon float64) int {
+	if epsilon <= 0 || math.IsNaN(epsilon) {
+		epsilon = defaultEpsilon
+	}
+	k := int(math.Ceil(math.Pow(2.296/epsilon, 1/0.9723)))
+	if k < minK {
+		return minK
+	}
+	if k > maxK {
+		return maxK
+	}
+	return k
+}
+
+// Epsilon returns the normalized rank error this sketch was sized for
+func (s *KLL) Epsilon() float64 {
+	return 2.296 / math.Pow(float64(s.k), 0.9723)
+}
+
+// Count returns the number of values added, including those of merged sketches
+func (s *KLL) Count() int64 {
+	return s.count
+}
+
+// Min returns the exact minimum, or NaN for an empty sketch
+func (s *KLL) Min() float64 {
+	if s.count == 0 {
+		return math.NaN()
+	}
+	return s.min
+}
+
+// Max returns the exact maximum, or NaN for an empty sketch
+func (s *KLL) Max() float64 {
+	if s.count == 0 {
+		return math.NaN()
+	}
+	return s.max
+}
+
+// Retained returns the number of values the sketch currently stores
+func (s *KLL) Retained() int {
+	return s.size
+}
+
+// Add inserts a value. NaN values are ignored.
+func (s *KLL) Add(value float64) {
+	if math.IsNaN(value) {
+		return
+	}
+	s.count++
+	s.min = math.Min(s.min, value)
+	s.max = math.Max(s.max, value)
+
+	s.compactors[0] = append(s.compactors[0], value)
+	s.size++
+	if s.size >= s.maxSize {
+		s.compress()
+	}
+}
+
+// Merge folds other into s. other is left unchanged.
+func (s *KLL) Merge(other *KLL) {
+	if other == nil || other.count == 0 {
+		return
+	}
+	for len(s.compactors) < len(other.compactors) {
+		s.grow()
+	}
+	for h, compactor := range other.compactors {
+		s.compactors[h] = append(s.compactors[h], compactor...)
+	}
+	s.count += other.count
+	s.min = math.Min(s.min, other.min)
+	s.max = math.Max(s.max, other.max)
+
+	s.updateSize()
+	for s.size >= s.maxSize {
+		s.compress()
+	}
+}
+
+// Quantile returns the value at fraction q in [0, 1] of the sorted data, or NaN for an
+// empty sketch. Until the sketch first compacts it interpolates exactly like the default
+// quantile of R and NumPy (Hyndman–Fan type 7).
+func (s *KLL) Quantile(q float64) float64 {
+	return s.Quantiles([]float64{q})[0]
+}
+
+// Quantiles evaluates several fractions with a single sort of the retained values
+func (s *KLL) Quantiles(qs []float64) []float64 {
+	values := make([]float64, len(qs))
+	items := s.weightedItems()
+	for i, q := range qs {
+		values[i] = s.quantile(items, q)
+	}
+	return values
+}
+
+func (s *KLL) quantile(items []weightedItem, q float64) float64 {
+	if s.count == 0 || math.IsNaN(q) {
+		return math.NaN()
+	}
+	if q <= 0 {
+		return s.min
+	}
+	if q >= 1 {
+		return s.max
+	}
+
+	// Every retained value stands for a run of weight consecutive ranks; it is placed at the
+	// middle of that run, so with unit weights positions are plain 0-based ranks.
+	target := q * float64(s.count-1)
+	var cumulative float64
+	prevPosition, prevValue := math.Inf(-1), s.min
+	for _, item := range items {
+		position := cumulative + (item.weight-1)/2
+		if position >= target {
+			if math.IsInf(prevPosition, -1) || position == prevPosition {
+				return item.value
+			}
+			fraction := (target - prevPosition) / (position - prevPosition)
+			return prevValue + fraction*(item.value-prevValue)
+		}
+		cumulative += item.weight
+		prevPosition, prevValue = position, item.value
+	}
+	return s.max
+}
+
+// Rank returns the approximate fraction of values less than or equal to value
+func (s *KLL) Rank(value float64) float64 {
+	if s.count == 0 {
+		return math.NaN()
+	}
+	var weight float64
+	for h, compactor := range s.compactors {
+		for _, v := range compactor {
+			if v <= value {
+				weight += float64(uint64(1) << h)
+			}
+		}
+	}
+	return weight / float64(s.count)
+}
+
+type weightedItem struct {
+	value  float64
+	weight float64
+}
+
+func (s *KLL) weightedItems() []weightedItem {
+	items := make([]weightedItem, 0, s.size)
+	for h, compactor := range s.compactors {
+		weight := float64(uint64(1) << h)
+		for _, v := range compactor {
+			items = append(items, weightedItem{value: v, weight: weight})
+		}
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].value < items[j].value })
+	return items
+}
+
+// capacity of level h shrinks geometrically with its distance from the top level
+func (s *KLL) capacity(h int) int {
+	depth := len(s.compactors) - h - 1
+	capacity := int(math.Ceil(float64(s.k) * math.Pow(capacityDecay, float64(depth))))
+	if capacity < minCapacity {
+		return minCapacity
+	}
+	return capacity
+}
+
+func (s *KLL) grow() {
+	s.compactors = append(s.compactors, nil)
+	s.maxSize = 0
+	for h := range s.compactors {
+		s.maxSize += s.capacity(h)
+	}
+}
+
+func (s *KLL) updateSize() {
+	s.size = 0
+	for _, compactor := range s.compactors {
+		s.size += len(compactor)
+	}
+}
+
+// compress compacts the lowest full level, and the levels above it while the sketch is
+// still over its budget. Compacting sorts a level and promotes every other value, starting
+// at a random offset, to the next level with twice the weight.
+func (s *KLL) compress() {
+	for h := 0; h < len(s.compactors); h++ {
+		if len(s.compactors[h]) < s.capacity(h) {
+			continue
+		}
+		if h+1 >= len(s.compactors) {
+			s.grow()
+		}
+
+		compactor := s.compactors[h]
+		sort.Float64s(compactor)
+		// An odd value out stays behind, so the total weight is preserved exactly
+		keep := len(compactor) % 2
+		offset := int(s.nextRandom() & 1)
+		for i := keep + offset; i < len(compactor); i += 2 {
+			s.compactors[h+1] = append(s.compactors[h+1], compactor[i])
+		}
+		s.compactors[h] = compactor[:keep]
+
+		s.updateSize()
+		if s.size < s.maxSize {
+			return
+		}
+	}
+}
+
+// nextRandom is a xorshift generator; a fixed seed keeps results reproducible
+func (s *KLL) nextRandom() uint64 {
+	s.seed ^= s.seed << 13
+	s.seed ^= s.seed >> 7
+	s.seed ^= s.seed << 17
+	return s.seed
+}
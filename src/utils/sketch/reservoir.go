@@ -0,0 +1,79 @@
+package sketch
+
+import "math/rand/v2"
+
+// Reservoir keeps a uniform random sample of at most size values from a stream (Vitter's
+// algorithm R). Tests that need the empirical distribution itself, rather than quantiles
+// within a rank error, run on the sample; while fewer than size values were added it holds
+// all of them. Two reservoirs built over separate parts of the data can be merged. Values
+// are usually float64, or whole rows when paired values must be sampled together.
+type Reservoir[T any] struct {
+	size   int
+	count  int64
+	values []T
+	rng    *rand.Rand
+}
+
+// NewReservoir creates a reservoir holding up to size values
+func NewReservoir[T any](size int) *Reservoir[T] {
+	if size < 1 {
+		size = 1
+	}
+	// A fixed seed keeps results reproducible
+	return &Reservoir[T]{size: size, rng: rand.New(rand.NewPCG(0x9e3779b97f4a7c15, uint64(size)))}
+}
+
+// Count returns the number of values added, which may exceed the sample size
+func (r *Reservoir[T]) Count() int64 {
+	return r.count
+}
+
+// Values returns the sample in no particular order. The slice is shared with the reservoir.
+func (r *Reservoir[T]) Values() []T {
+	return r.values
+}
+
+// Add offers one value to the sample
+func (r *Reservoir[T]) Add(x T) {
+	r.count++
+	if len(r.values) < r.size {
+		r.values = append(r.values, x)
+		return
+	}
+	if j := r.rng.Int64N(r.count); j < int64(r.size) {
+		r.values[j] = x
+	}
+}
+
+// Merge folds other into r, leaving r a uniform sample of both streams. Each slot is drawn
+// from one side with probability proportional to the values that side has left, which
+// makes the split between the sides hypergeometric, as if the union had been sampled.
+func (r *Reservoir[T]) Merge(other *Reservoir[T]) {
+	if other.count == 0 {
+		return
+	}
+	if r.count+other.count <= int64(r.size) {
+		r.values = append(r.values, other.values...)
+		r.count += other.count
+		return
+	}
+
+	left := append([]T(nil), r.values...)
+	right := append([]T(nil), other.values...)
+	leftRemaining, rightRemaining := r.count, other.count
+	merged := make([]T, 0, r.size)
+	for len(merged) < r.size {
+		side, remaining := &left, &leftRemaining
+		if r.rng.Int64N(leftRemaining+rightRemaining) >= leftRemaining {
+			side, remaining = &right, &rightRemaining
+		}
+		// Take a random value of the chosen side without replacement
+		j := r.rng.IntN(len(*side))
+		merged = append(merged, (*side)[j])
+		(*side)[j] = (*side)[len(*side)-1]
+		*side = (*side)[:len(*side)-1]
+		*remaining--
+	}
+	r.values = merged
+	r.count += other.count
+}
@@ -0,0 +1,158 @@
+package sketch
+
+import "sort"
+
+// FrequentValue is a value with its count from a SpaceSaving sketch. The true count lies in
+// [Count - Error, Count].
+type FrequentValue struct {
+	Value float64 `json:"value"`
+	Count int64   `json:"count"`
+	Error int64   `json:"error"`
+}
+
+// SpaceSaving tracks the most frequent values of a stream with a fixed number of counters
+// (Metwally et al.). Every value occurring more than n / capacity times is guaranteed a
+// counter, and counts are exact while the stream has at most capacity distinct values. Two
+// sketches built over separate parts of the data merge following Agarwal et al.
+type SpaceSaving struct {
+	capacity int
+	count    int64
+	counters []FrequentValue // Min-heap by Count
+	index    map[float64]int // Position of each value in counters
+}
+
+// NewSpaceSaving creates a sketch with capacity counters
+func NewSpaceSaving(capacity int) *SpaceSaving {
+	if capacity < 1 {
+		capacity = 1
+	}
+	return &SpaceSaving{capacity: capacity, index: make(map[float64]int, capacity)}
+}
+
+// Count returns the number of values added, including those of merged sketches
+func (s *SpaceSaving) Count() int64 {
+	return s.count
+}
+
+// Add counts one occurrence of x. NaN must not be added.
+func (s *SpaceSaving) Add(x float64) {
+	s.count++
+	if j, ok := s.index[x]; ok {
+		s.counters[j].Count++
+		s.down(j)
+		return
+	}
+	if len(s.counters) < s.capacity {
+		s.index[x] = len(s.counters)
+		s.counters = append(s.counters, FrequentValue{Value: x, Count: 1})
+		s.up(len(s.counters) - 1)
+		return
+	}
+	// Evict the least frequent value; the newcomer inherits its count as error
+	evicted := s.counters[0]
+	delete(s.index, evicted.Value)
+	s.counters[0] = FrequentValue{Value: x, Count: evicted.Count + 1, Error: evicted.Count}
+	s.index[x] = 0
+	s.down(0)
+}
+
+// Merge folds other into s. A value missing from a full sketch may have occurred up to that
+// sketch's smallest count, which is added to its count and error.
+func (s *SpaceSaving) Merge(other *SpaceSaving) {
+	if other.count == 0 {
+		return
+	}
+	floor, otherFloor := s.floor(), other.floor()
+	merged := make([]FrequentValue, 0, len(s.counters)+len(other.counters))
+	for _, c := range s.counters {
+		if j, ok := other.index[c.Value]; ok {
+			c.Count += other.counters[j].Count
+			c.Error += other.counters[j].Error
+		} else {
+			c.Count += otherFloor
+			c.Error += otherFloor
+		}
+		merged = append(merged, c)
+	}
+	for _, c := range other.counters {
+		if _, ok := s.index[c.Value]; !ok {
+			c.Count += floor
+			c.Error += floor
+			merged = append(merged, c)
+		}
+	}
+	sortFrequent(merged)
+	if len(merged) > s.capacity {
+		merged = merged[:s.capacity]
+	}
+
+	s.count += other.count
+	s.counters = s.counters[:0]
+	s.index = make(map[float64]int, s.capacity)
+	for _, c := range merged {
+		s.index[c.Value] = len(s.counters)
+		s.counters = append(s.counters, c)
+		s.up(len(s.counters) - 1)
+	}
+}
+
+// Top returns the k most frequent values, most frequent first
+func (s *SpaceSaving) Top(k int) []FrequentValue {
+	top := append([]FrequentValue(nil), s.counters...)
+	sortFrequent(top)
+	if k < len(top) {
+		top = top[:k]
+	}
+	return top
+}
+
+// floor returns the largest count a value without a counter can have
+func (s *SpaceSaving) floor() int64 {
+	if len(s.counters) < s.capacity {
+		return 0
+	}
+	return s.counters[0].Count
+}
+
+// sortFrequent orders by count, most frequent first, breaking ties by value
+func sortFrequent(values []FrequentValue) {
+	sort.Slice(values, func(a, b int) bool {
+		if values[a].Count != values[b].Count {
+			return values[a].Count > values[b].Count
+		}
+		return values[a].Value < values[b].Value
+	})
+}
+
+func (s *SpaceSaving) swap(i, j int) {
+	s.counters[i], s.counters[j] = s.counters[j], s.counters[i]
+	s.index[s.counters[i].Value] = i
+	s.index[s.counters[j].Value] = j
+}
+
+func (s *SpaceSaving) up(j int) {
+	for j > 0 {
+		parent := (j - 1) / 2
+		if s.counters[parent].Count <= s.counters[j].Count {
+			return
+		}
+		s.swap(parent, j)
+		j = parent
+	}
+}
+
+func (s *SpaceSaving) down(j int) {
+	for {
+		smallest := j
+		for _, child := range []int{2*j + 1, 2*j + 2} {
+			if child < len(s.counters) && s.counters[child].Count < s.counters[smallest].Count {
+				smallest = child
+			}
+		}
+		if smallest == j {
+			return
+		}
+		s.swap(j, smallest)
+		j = smallest
+	}
+}
@@ -0,0 +1,293 @@
+package stats
+
+import (
+	"math"
+	"math/rand/v2"
+	"runtime"
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+// jackknifeGroups bounds the leave-out evaluations behind the BCa acceleration. Larger
+// samples are split into this many interleaved groups and one group is left out at a time.
+const jackknifeGroups = 100
+
+// BootstrapConfig controls the resampling behind bootstrap confidence intervals
+type BootstrapConfig struct {
+	Resamples  int     `json:"resamples"` // 0 disables bootstrapping
+	Seed       uint64  `json:"seed"`
+	Confidence float64 `json:"confidence"` // Coverage of the two-sided intervals, e.g. 0.95
+	Workers    int     `json:"-"`          // Goroutines drawing resamples, GOMAXPROCS when 0
+}
+
+// DefaultBootstrapConfig returns 1000 resamples for 95% intervals with a fixed seed
+func DefaultBootstrapConfig() BootstrapConfig {
+	return BootstrapConfig{Resamples: 1000, Seed: 1, Confidence: 0.95}
+}
+
+// Interval is a two-sided confidence interval
+type Interval struct {
+	Lower float64 `json:"lower"`
+	Upper float64 `json:"upper"`
+}
+
+// BootstrapInterval holds the percentile and the bias-corrected and accelerated (BCa)
+// interval of one statistic
+type BootstrapInterval struct {
+	Estimate   float64  `json:"estimate"` // Statistic of the resampled data itself
+	StdError   float64  `json:"std_error"`
+	Percentile Interval `json:"percentile"`
+	BCa        Interval `json:"bca"`
+}
+
+// Statistic computes an estimate from data it closes over, where weights[i] is how often
+// item i occurs in the resample and items with weight 0 are left out. It must not keep
+// weights, which is reused between calls.
+type Statistic func(weights []float64) float64
+
+// Bootstrap computes confidence intervals of statistics over m items. Every resample draws
+// m items with replacement and evaluates all statistics on it. When the m items are a
+// uniform sample of a larger data set of n values, the deviations of the replicates from the
+// estimate are scaled by √(m/n), which approximates the bootstrap of the full data at a cost
+// independent of n. Resamples run on config.Workers goroutines, each seeded from
+// config.Seed and its index, so results do not depend on the number of workers.
+// Bootstrap returns nil when bootstrapping is disabled or there are fewer than two items.
+func Bootstrap(m int, n int64, statistics []Statistic, config BootstrapConfig) []BootstrapInterval {
+	if config.Resamples <= 0 || m < 2 {
+		return nil
+	}
+	if config.Confidence <= 0 || config.Confidence >= 1 {
+		config.Confidence = DefaultBootstrapConfig().Confidence
+	}
+	if n < int64(m) {
+		n = int64(m)
+	}
+	workers := config.Workers
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	workers = min(workers, config.Resamples)
+
+	replicates := make([][]float64, len(statistics))
+	for k := range replicates {
+		replicates[k] = make([]float64, config.Resamples)
+	}
+	var nextResample atomic.Int64
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			weights := make([]float64, m)
+			for {
+				b := int(nextResample.Add(1) - 1)
+				if b >= config.Resamples {
+					return
+				}
+				rng := rand.New(rand.NewPCG(config.Seed, uint64(b)))
+				clear(weights)
+				for i := 0; i < m; i++ {
+					weights[rng.IntN(m)]++
+				}
+				for k, statistic := range statistics {
+					replicates[k][b] = statistic(weights)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	ones := make([]float64, m)
+	for i := range ones {
+		ones[i] = 1
+	}
+	scale := math.Sqrt(float64(m) / float64(n)) // Spread of statistics shrinks with 1/√n
+	intervals := make([]BootstrapInterval, len(statistics))
+	for k, statistic := range statistics {
+		estimate := statistic(ones)
+		if scale < 1 {
+			for b, replicate := range replicates[k] {
+				replicates[k][b] = estimate + (replicate-estimate)*scale
+			}
+		}
+		// The acceleration shrinks with 1/√n, so the jackknife over m items is scaled to n
+		acceleration := jackknifeAcceleration(m, statistic) * scale
+		intervals[k] = bootstrapInterval(estimate, replicates[k], acceleration, config.Confidence)
+	}
+	return intervals
+}
+
+// jackknifeAcceleration estimates the BCa acceleration from the skewness of the leave-out
+// estimates: a = Σd³ / (6 (Σd²)^(3/2)), d being the deviations from their mean
+func jackknifeAcceleration(m int, statistic Statistic) float64 {
+	groups := min(m, jackknifeGroups)
+	weights := make([]float64, m)
+	estimates := make([]float64, groups)
+	mean := 0.0
+	for g := range estimates {
+		// Interleaved groups leave out a spread of values even when the items are sorted
+		for i := range weights {
+			weights[i] = 1
+			if i%groups == g {
+				weights[i] = 0
+			}
+		}
+		estimates[g] = statistic(weights)
+		mean += estimates[g] / float64(groups)
+	}
+
+	sum2, sum3 := 0.0, 0.0
+	for _, e := range estimates {
+		d := mean - e
+		sum2 += d * d
+		sum3 += d * d * d
+	}
+	if sum2 == 0 {
+		return 0
+	}
+	return sum3 / (6 * math.Pow(sum2, 1.5))
+}
+
+func bootstrapInterval(estimate float64, replicates []float64, acceleration float64, confidence float64) BootstrapInterval {
+	sorted := make([]float64, 0, len(replicates))
+	for _, r := range replicates {
+		if !math.IsNaN(r) {
+			sorted = append(sorted, r)
+		}
+	}
+	if len(sorted) == 0 {
+		point := Interval{Lower: estimate, Upper: estimate}
+		return BootstrapInterval{Estimate: estimate, Percentile: point, BCa: point}
+	}
+	sort.Float64s(sorted)
+
+	b := float64(len(sorted))
+	mean, m2 := 0.0, 0.0
+	below := 0.0
+	for i, r := range sorted {
+		delta := r - mean
+		mean += delta / float64(i+1)
+		m2 += delta * (r - mean)
+		if r < estimate {
+			below++
+		} else if r == estimate {
+			below += 0.5
+		}
+	}
+
+	// Bias correction from the share of replicates below the estimate, kept off 0 and 1
+	share := math.Min(math.Max(below/b, 1/(b+1)), b/(b+1))
+	z0 := NormalQuantile(share)
+	alpha := (1 - confidence) / 2
+	adjusted := func(p float64) float64 {
+		z := z0 + NormalQuantile(p)
+		return NormalCDF(z0 + z/(1-acceleration*z))
+	}
+
+	result := BootstrapInterval{
+		Estimate:   estimate,
+		Percentile: Interval{Lower: LinearQuantile(sorted, alpha), Upper: LinearQuantile(sorted, 1-alpha)},
+		BCa:        Interval{Lower: LinearQuantile(sorted, adjusted(alpha)), Upper: LinearQuantile(sorted, adjusted(1-alpha))},
+	}
+	if len(sorted) > 1 {
+		result.StdError = math.Sqrt(m2 / (b - 1))
+	}
+	return result
+}
+
+// LinearQuantile returns the q-th quantile (0-1) of sorted values by linear interpolation
+// between the closest ranks (Hyndman-Fan type 7), or NaN without values
+func LinearQuantile(sorted []float64, q float64) float64 {
+	if len(sorted) == 0 {
+		return math.NaN()
+	}
+	position := math.Min(math.Max(q, 0), 1) * float64(len(sorted)-1)
+	i := int(position)
+	if i >= len(sorted)-1 {
+		return sorted[len(sorted)-1]
+	}
+	return sorted[i] + (position-float64(i))*(sorted[i+1]-sorted[i])
+}
+
+// MeanStatistic returns the weighted mean of values
+func MeanStatistic(values []float64) Statistic {
+	return func(weights []float64) float64 {
+		sum, total := 0.0, 0.0
+		for i, w := range weights {
+			sum += w * values[i]
+			total += w
+		}
+		return sum / total
+	}
+}
+
+// StdDevStatistic returns the sample standard deviation of values, each counted weight times
+func StdDevStatistic(values []float64) Statistic {
+	return func(weights []float64) float64 {
+		total, mean, m2 := 0.0, 0.0, 0.0
+		for i, w := range weights {
+			if w == 0 {
+				continue
+			}
+			// Weighted Welford update
+			total += w
+			delta := values[i] - mean
+			mean += delta * w / total
+			m2 += w * delta * (values[i] - mean)
+		}
+		if total < 2 {
+			return 0
+		}
+		return math.Sqrt(m2 / (total - 1))
+	}
+}
+
+// MedianStatistic returns the median of sorted values, each counted weight times
+func MedianStatistic(sorted []float64) Statistic {
+	return func(weights []float64) float64 {
+		total := 0.0
+		for _, w := range weights {
+			total += w
+		}
+		// Positions of the middle value, or of the two middle values, in the expanded data
+		lower, upper := math.Floor((total-1)/2), math.Floor(total/2)
+		lowerValue := math.NaN()
+		cumulative := 0.0
+		for i, w := range weights {
+			cumulative += w
+			if math.IsNaN(lowerValue) && cumulative > lower {
+				lowerValue = sorted[i]
+			}
+			if cumulative > upper {
+				return (lowerValue + sorted[i]) / 2
+			}
+		}
+		return lowerValue
+	}
+}
+
+// CorrelationStatistic returns Pearson's r between x and y, skipping items where either is
+// NaN, or 0 when either side is constant
+func CorrelationStatistic(x []float64, y []float64) Statistic {
+	return func(weights []float64) float64 {
+		total, meanX, meanY, m2X, m2Y, cXY := 0.0, 0.0, 0.0, 0.0, 0.0, 0.0
+		for i, w := range weights {
+			if w == 0 || math.IsNaN(x[i]) || math.IsNaN(y[i]) {
+				continue
+			}
+			total += w
+			dx := x[i] - meanX
+			dy := y[i] - meanY
+			meanX += dx * w / total
+			meanY += dy * w / total
+			m2X += w * dx * (x[i] - meanX)
+			m2Y += w * dy * (y[i] - meanY)
+			cXY += w * dx * (y[i] - meanY)
+		}
+		if m2X == 0 || m2Y == 0 {
+			return 0
+		}
+		return cXY / math.Sqrt(m2X*m2Y)
+	}
+}
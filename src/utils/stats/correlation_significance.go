@@ -0,0 +1,57 @@
+package stats
+
+import "math"
+
+// CorrelationTest holds the significance and confidence interval of one correlation
+// coefficient computed over N pairs
+type CorrelationTest struct {
+	N         int64    `json:"n"`
+	PValue    float64  `json:"p_value"`
+	AdjustedP float64  `json:"adjusted_p"` // Set by the caller across a family of coefficients
+	Interval  Interval `json:"interval"`   // Fisher-z interval
+}
+
+// TestCorrelation tests the coefficient r of method against 0 and gives its Fisher-z
+// confidence interval. Pearson's r and Spearman's rho use t = r √((n-2)/(1-r²)) with n-2
+// degrees of freedom; Kendall's tau uses its normal approximation without tie correction.
+// The standard errors of z are 1/√(n-3) for Pearson, √((1 + r²/2)/(n-3)) for Spearman
+// (Bonett and Wright) and √(0.437/(n-4)) for Kendall (Fieller et al.). Without enough pairs
+// the p-value is 1 and the interval spans [-1, 1].
+func TestCorrelation(method string, r float64, n int64, confidence float64) CorrelationTest {
+	test := CorrelationTest{N: n, PValue: 1, AdjustedP: 1, Interval: Interval{Lower: -1, Upper: 1}}
+	nf := float64(n)
+	if n < 3 {
+		return test
+	}
+
+	switch method {
+	case CorrelationKendall:
+		z := 3 * r * math.Sqrt(nf*(nf-1)) / math.Sqrt(2*(2*nf+5))
+		test.PValue = math.Min(2*NormalSurvival(math.Abs(z)), 1)
+	default:
+		if math.Abs(r) >= 1 {
+			test.PValue = 0
+		} else {
+			t := r * math.Sqrt((nf-2)/(1-r*r))
+			test.PValue = StudentTTwoSided(t, nf-2)
+		}
+	}
+	test.AdjustedP = test.PValue
+
+	var se float64
+	switch method {
+	case CorrelationSpearman:
+		se = math.Sqrt((1 + r*r/2) / (nf - 3))
+	case CorrelationKendall:
+		se = math.Sqrt(0.437 / (nf - 4))
+	default:
+		se = 1 / math.Sqrt(nf-3)
+	}
+	if math.IsNaN(se) || math.IsInf(se, 0) {
+		return test
+	}
+	z := math.Atanh(math.Max(math.Min(r, 1), -1))
+	halfWidth := NormalQuantile(1-(1-confidence)/2) * se
+	test.Interval = Interval{Lower: math.Tanh(z - halfWidth), Upper: math.Tanh(z + halfWidth)}
+	return test
+}
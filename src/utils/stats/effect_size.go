@@ -0,0 +1,121 @@
+package stats
+
+import (
+	"math"
+	"sort"
+)
+
+// Magnitudes of an effect size
+const (
+	EffectNegligible = "negligible"
+	EffectSmall      = "small"
+	EffectMedium     = "medium"
+	EffectLarge      = "large"
+)
+
+// EffectSize is one measure of how far apart two groups are, with its conventional band
+type EffectSize struct {
+	Name      string  `json:"name"`
+	Value     float64 `json:"value"`
+	Magnitude string  `json:"magnitude"`
+}
+
+// EffectSizes compares group a with group b. Cohen's d, Hedges' g and the variance ratio use
+// the moments of the groups, Cliff's delta their samples. Measures that are undefined for
+// the data, such as d between two constant groups, are left out.
+func EffectSizes(a GroupSummary, b GroupSummary, x []float64, y []float64) []EffectSize {
+	sizes := make([]EffectSize, 0, 4)
+	if d, ok := CohensD(a, b); ok {
+		sizes = append(sizes, EffectSize{Name: "Cohen's d", Value: d, Magnitude: standardizedMagnitude(d)})
+		g := d * hedgesCorrection(a.Count+b.Count)
+		sizes = append(sizes, EffectSize{Name: "Hedges' g", Value: g, Magnitude: standardizedMagnitude(g)})
+	}
+	if delta, ok := CliffsDelta(x, y); ok {
+		sizes = append(sizes, EffectSize{Name: "Cliff's delta", Value: delta, Magnitude: cliffsMagnitude(delta)})
+	}
+	if a.Count > 1 && b.Count > 1 && a.Variance > 0 && b.Variance > 0 {
+		ratio := a.Variance / b.Variance
+		sizes = append(sizes, EffectSize{Name: "variance ratio", Value: ratio, Magnitude: varianceRatioMagnitude(ratio)})
+	}
+	return sizes
+}
+
+// CohensD returns the difference of the means in units of the pooled standard deviation
+func CohensD(a GroupSummary, b GroupSummary) (float64, bool) {
+	if a.Count < 2 || b.Count < 2 {
+		return 0, false
+	}
+	pooled := (float64(a.Count-1)*a.Variance + float64(b.Count-1)*b.Variance) / float64(a.Count+b.Count-2)
+	if pooled == 0 {
+		return 0, false
+	}
+	return (a.Mean - b.Mean) / math.Sqrt(pooled), true
+}
+
+// hedgesCorrection removes the small-sample bias of Cohen's d, 1 - 3 / (4(n1 + n2) - 9)
+func hedgesCorrection(n int64) float64 {
+	return 1 - 3/(4*float64(n)-9)
+}
+
+// CliffsDelta returns P(X > Y) - P(X < Y) for X drawn from x and Y from y, counted over all
+// pairs in O((n1 + n2) log(n1 + n2))
+func CliffsDelta(x []float64, y []float64) (float64, bool) {
+	if len(x) == 0 || len(y) == 0 {
+		return 0, false
+	}
+	sortedY := append([]float64(nil), y...)
+	sort.Float64s(sortedY)
+
+	dominance := 0
+	for _, v := range x {
+		// Values of y below v, minus values of y above v
+		below := sort.SearchFloat64s(sortedY, v)
+		above := len(sortedY) - sort.Search(len(sortedY), func(j int) bool { return sortedY[j] > v })
+		dominance += below - above
+	}
+	return float64(dominance) / (float64(len(x)) * float64(len(y))), true
+}
+
+// standardizedMagnitude applies Cohen's bands 0.2, 0.5 and 0.8 to a standardized difference
+func standardizedMagnitude(d float64) string {
+	switch d = math.Abs(d); {
+	case d < 0.2:
+		return EffectNegligible
+	case d < 0.5:
+		return EffectSmall
+	case d < 0.8:
+		return EffectMedium
+	}
+	return EffectLarge
+}
+
+// cliffsMagnitude applies the bands 0.147, 0.33 and 0.474 of Romano et al., which match
+// Cohen's bands for normal data
+func cliffsMagnitude(delta float64) string {
+	switch delta = math.Abs(delta); {
+	case delta < 0.147:
+		return EffectNegligible
+	case delta < 0.33:
+		return EffectSmall
+	case delta < 0.474:
+		return EffectMedium
+	}
+	return EffectLarge
+}
+
+// varianceRatioMagnitude bands the ratio of the larger to the smaller variance at 1.25, 1.5
+// and 2. There is no established convention for variances; these bands are a rule of thumb.
+func varianceRatioMagnitude(ratio float64) string {
+	if ratio < 1 {
+		ratio = 1 / ratio
+	}
+	switch {
+	case ratio < 1.25:
+		return EffectNegligible
+	case ratio < 1.5:
+		return EffectSmall
+	case ratio < 2:
+		return EffectMedium
+	}
+	return EffectLarge
+}
@@ -0,0 +1,45 @@
+package stats
+
+import "math"
+
+// Entropy returns the Shannon entropy in bits of the distribution given by counts
+func Entropy(counts []int64) float64 {
+	total := int64(0)
+	for _, c := range counts {
+		total += c
+	}
+	if total == 0 {
+		return 0
+	}
+	h := 0.0
+	for _, c := range counts {
+		if c > 0 {
+			p := float64(c) / float64(total)
+			h -= p * math.Log2(p)
+		}
+	}
+	return h
+}
+
+// BinnedEntropy returns the entropy in bits of values binned into bins equal-width bins
+// between their minimum and maximum, at most log2(bins). It is 0 for a constant column and
+// low for one stuck at a few values.
+func BinnedEntropy(values []float64, bins int) float64 {
+	if len(values) == 0 || bins < 1 {
+		return 0
+	}
+	lo, hi := values[0], values[0]
+	for _, v := range values {
+		lo, hi = math.Min(lo, v), math.Max(hi, v)
+	}
+	if hi == lo {
+		return 0
+	}
+	counts := make([]int64, bins)
+	width := (hi - lo) / float64(bins)
+	for _, v := range values {
+		// The maximum falls into the last bin
+		counts[min(int((v-lo)/width), bins-1)]++
+	}
+	return Entropy(counts)
+}
@@ -0,0 +1,367 @@
+package stats
+
+import (
+	"math"
+	"sort"
+)
+
+const (
+	fitMinN      = 5
+	fitMaxIters  = 100
+	fitTolerance = 1e-10
+)
+
+// Parameter is one named parameter of a fitted distribution
+type Parameter struct {
+	Name  string  `json:"name"`
+	Value float64 `json:"value"`
+}
+
+// Fit is a distribution fitted to a sample by maximum likelihood. Candidates are compared by
+// AIC and BIC, which penalize the number of parameters, and by the Kolmogorov-Smirnov
+// distance between the fitted CDF and the empirical one; each rank is 1 for the best.
+type Fit struct {
+	Distribution  string      `json:"distribution"`
+	Parameters    []Parameter `json:"parameters"`
+	LogLikelihood float64     `json:"log_likelihood"`
+	AIC           float64     `json:"aic"`
+	BIC           float64     `json:"bic"`
+	KS            float64     `json:"ks_distance"`
+	RankAIC       int         `json:"rank_aic"`
+	RankBIC       int         `json:"rank_bic"`
+	RankKS        int         `json:"rank_ks"`
+	N             int         `json:"n"` // Values the distribution was fitted on
+
+	pdf func(x float64) float64
+	cdf func(x float64) float64
+}
+
+// PDF returns the fitted density at x
+func (f Fit) PDF(x float64) float64 {
+	return f.pdf(x)
+}
+
+// CDF returns the fitted probability of a value at most x
+func (f Fit) CDF(x float64) float64 {
+	return f.cdf(x)
+}
+
+// fitSample holds the sums the maximum likelihood estimators share
+type fitSample struct {
+	sorted   []float64
+	n        float64
+	mean     float64
+	variance float64 // Population variance, the maximum likelihood estimate
+	positive bool    // Every value is > 0, so log-based distributions apply
+	meanLog  float64
+	varLog   float64
+}
+
+// FitDistributions fits the normal, log-normal, exponential, gamma, Weibull and rescaled
+// beta distributions to sample and returns them best AIC first. Distributions whose support
+// does not cover the sample, e.g. log-normal for non-positive values, are left out. It
+// returns nil for fewer than 5 values or a constant sample.
+func FitDistributions(sample []float64) []Fit {
+	if len(sample) < fitMinN {
+		return nil
+	}
+	s := fitSample{sorted: append([]float64(nil), sample...), n: float64(len(sample)), positive: true}
+	sort.Float64s(s.sorted)
+	if s.sorted[0] == s.sorted[len(s.sorted)-1] {
+		return nil
+	}
+
+	var m2 float64
+	for i, x := range s.sorted {
+		delta := x - s.mean
+		s.mean += delta / float64(i+1)
+		m2 += delta * (x - s.mean)
+		if x <= 0 {
+			s.positive = false
+		}
+	}
+	s.variance = m2 / s.n
+	if s.positive {
+		var m2Log float64
+		for i, x := range s.sorted {
+			delta := math.Log(x) - s.meanLog
+			s.meanLog += delta / float64(i+1)
+			m2Log += delta * (math.Log(x) - s.meanLog)
+		}
+		s.varLog = m2Log / s.n
+	}
+
+	fits := []Fit{fitNormal(s)}
+	if s.sorted[0] >= 0 {
+		fits = append(fits, fitExponential(s))
+	}
+	if s.positive && s.varLog > 0 {
+		fits = append(fits, fitLogNormal(s))
+		if fit, ok := fitGamma(s); ok {
+			fits = append(fits, fit)
+		}
+		if fit, ok := fitWeibull(s); ok {
+			fits = append(fits, fit)
+		}
+	}
+	if fit, ok := fitBeta(s); ok {
+		fits = append(fits, fit)
+	}
+
+	for i := range fits {
+		fits[i].N = len(s.sorted)
+		k := float64(len(fits[i].Parameters))
+		fits[i].AIC = 2*k - 2*fits[i].LogLikelihood
+		fits[i].BIC = k*math.Log(s.n) - 2*fits[i].LogLikelihood
+		fits[i].KS = ksDistance(s.sorted, fits[i].cdf)
+	}
+	rank(fits, func(f Fit) float64 { return f.KS }, func(f *Fit, r int) { f.RankKS = r })
+	rank(fits, func(f Fit) float64 { return f.BIC }, func(f *Fit, r int) { f.RankBIC = r })
+	rank(fits, func(f Fit) float64 { return f.AIC }, func(f *Fit, r int) { f.RankAIC = r })
+	return fits
+}
+
+// rank sorts fits by key, smallest first, and records each position with set
+func rank(fits []Fit, key func(f Fit) float64, set func(f *Fit, r int)) {
+	sort.SliceStable(fits, func(a, b int) bool { return key(fits[a]) < key(fits[b]) })
+	for i := range fits {
+		set(&fits[i], i+1)
+	}
+}
+
+func ksDistance(sorted []float64, cdf func(x float64) float64) float64 {
+	n := float64(len(sorted))
+	d := 0.0
+	for i, x := range sorted {
+		f := cdf(x)
+		d = math.Max(d, math.Max(float64(i+1)/n-f, f-float64(i)/n))
+	}
+	return d
+}
+
+func fitNormal(s fitSample) Fit {
+	mean, stdDev := s.mean, math.Sqrt(s.variance)
+	return Fit{
+		Distribution:  "normal",
+		Parameters:    []Parameter{{"mean", mean}, {"std_dev", stdDev}},
+		LogLikelihood: -s.n / 2 * (math.Log(2*math.Pi*s.variance) + 1),
+		pdf:           func(x float64) float64 { return NormalPDF((x-mean)/stdDev) / stdDev },
+		cdf:           func(x float64) float64 { return NormalCDF((x - mean) / stdDev) },
+	}
+}
+
+func fitLogNormal(s fitSample) Fit {
+	mu, sigma := s.meanLog, math.Sqrt(s.varLog)
+	return Fit{
+		Distribution:  "log-normal",
+		Parameters:    []Parameter{{"mu", mu}, {"sigma", sigma}},
+		LogLikelihood: -s.n*s.meanLog - s.n/2*(math.Log(2*math.Pi*s.varLog)+1),
+		pdf: func(x float64) float64 {
+			if x <= 0 {
+				return 0
+			}
+			return NormalPDF((math.Log(x)-mu)/sigma) / (sigma * x)
+		},
+		cdf: func(x float64) float64 {
+			if x <= 0 {
+				return 0
+			}
+			return NormalCDF((math.Log(x) - mu) / sigma)
+		},
+	}
+}
+
+func fitExponential(s fitSample) Fit {
+	rate := 1 / s.mean
+	return Fit{
+		Distribution:  "exponential",
+		Parameters:    []Parameter{{"rate", rate}},
+		LogLikelihood: s.n*math.Log(rate) - s.n,
+		pdf: func(x float64) float64 {
+			if x < 0 {
+				return 0
+			}
+			return rate * math.Exp(-rate*x)
+		},
+		cdf: func(x float64) float64 {
+			if x < 0 {
+				return 0
+			}
+			return -math.Expm1(-rate * x)
+		},
+	}
+}
+
+// fitGamma solves ln k - ψ(k) = ln(mean) - mean(ln x) for the shape k by Newton's method,
+// starting from the approximation of Minka
+func fitGamma(s fitSample) (Fit, bool) {
+	target := math.Log(s.mean) - s.meanLog
+	if target <= 0 {
+		return Fit{}, false
+	}
+	shape := (3 - target + math.Sqrt((target-3)*(target-3)+24*target)) / (12 * target)
+	for i := 0; i < fitMaxIters; i++ {
+		step := (math.Log(shape) - Digamma(shape) - target) / (1/shape - Trigamma(shape))
+		next := shape - step
+		if next <= 0 {
+			next = shape / 2
+		}
+		converged := math.Abs(next-shape) < fitTolerance*shape
+		shape = next
+		if converged {
+			break
+		}
+	}
+	scale := s.mean / shape
+	lgammaShape, _ := math.Lgamma(shape)
+	return Fit{
+		Distribution:  "gamma",
+		Parameters:    []Parameter{{"shape", shape}, {"scale", scale}},
+		LogLikelihood: s.n * ((shape-1)*s.meanLog - s.mean/scale - shape*math.Log(scale) - lgammaShape),
+		pdf: func(x float64) float64 {
+			if x <= 0 {
+				return 0
+			}
+			return math.Exp((shape-1)*math.Log(x) - x/scale - shape*math.Log(scale) - lgammaShape)
+		},
+		cdf: func(x float64) float64 { return RegularizedGammaP(shape, x/scale) },
+	}, true
+}
+
+// fitWeibull solves the profile likelihood equation of the shape k by Newton's method. The
+// values are divided by their maximum first so that x^k cannot overflow.
+func fitWeibull(s fitSample) (Fit, bool) {
+	maxValue := s.sorted[len(s.sorted)-1]
+	logs := make([]float64, len(s.sorted))
+	for i, x := range s.sorted {
+		logs[i] = math.Log(x / maxValue)
+	}
+	meanLogScaled := s.meanLog - math.Log(maxValue)
+
+	sums := func(k float64) (b, a, c float64) {
+		for _, l := range logs {
+			p := math.Exp(k * l)
+			b += p
+			a += p * l
+			c += p * l * l
+		}
+		return b, a, c
+	}
+
+	// The log of a Weibull variable has standard deviation π / (k √6)
+	shape := math.Pi / (math.Sqrt(6 * s.varLog))
+	for i := 0; i < fitMaxIters; i++ {
+		b, a, c := sums(shape)
+		f := a/b - 1/shape - meanLogScaled
+		df := (c*b-a*a)/(b*b) + 1/(shape*shape)
+		next := shape - f/df
+		if next <= 0 || math.IsNaN(next) {
+			next = shape / 2
+		}
+		converged := math.Abs(next-shape) < fitTolerance*shape
+		shape = next
+		if converged {
+			break
+		}
+	}
+	b, _, _ := sums(shape)
+	scale := maxValue * math.Pow(b/s.n, 1/shape)
+	if math.IsNaN(scale) || math.IsInf(scale, 0) || scale <= 0 {
+		return Fit{}, false
+	}
+
+	var sumPow float64
+	for _, x := range s.sorted {
+		sumPow += math.Pow(x/scale, shape)
+	}
+	return Fit{
+		Distribution:  "weibull",
+		Parameters:    []Parameter{{"shape", shape}, {"scale", scale}},
+		LogLikelihood: s.n*(math.Log(shape)-shape*math.Log(scale)+(shape-1)*s.meanLog) - sumPow,
+		pdf: func(x float64) float64 {
+			if x <= 0 {
+				return 0
+			}
+			z := x / scale
+			return shape / scale * math.Pow(z, shape-1) * math.Exp(-math.Pow(z, shape))
+		},
+		cdf: func(x float64) float64 {
+			if x <= 0 {
+				return 0
+			}
+			return -math.Expm1(-math.Pow(x/scale, shape))
+		},
+	}, true
+}
+
+// fitBeta fits a beta distribution to the sample rescaled onto (0, 1). The bounds are
+// placed one n-th of the range outside the observed extremes, since their maximum likelihood
+// estimates would sit on the extremes where the density may be infinite; they still count as
+// parameters for AIC and BIC. The shapes are found by Newton's method from the method of
+// moments estimates.
+func fitBeta(s fitSample) (Fit, bool) {
+	minValue, maxValue := s.sorted[0], s.sorted[len(s.sorted)-1]
+	pad := (maxValue - minValue) / s.n
+	lower, upper := minValue-pad, maxValue+pad
+	width := upper - lower
+
+	var meanLogY, meanLog1Y float64
+	for _, x := range s.sorted {
+		y := (x - lower) / width
+		meanLogY += math.Log(y)
+		meanLog1Y += math.Log1p(-y)
+	}
+	meanLogY /= s.n
+	meanLog1Y /= s.n
+
+	m := (s.mean - lower) / width
+	v := s.variance / (width * width)
+	common := m*(1-m)/v - 1
+	alpha, beta := 1.0, 1.0
+	if common > 0 {
+		alpha, beta = m*common, (1-m)*common
+	}
+
+	for i := 0; i < fitMaxIters; i++ {
+		psiSum, triSum := Digamma(alpha+beta), Trigamma(alpha+beta)
+		g1 := Digamma(alpha) - psiSum - meanLogY
+		g2 := Digamma(beta) - psiSum - meanLog1Y
+		j11, j22, j12 := Trigamma(alpha)-triSum, Trigamma(beta)-triSum, -triSum
+		det := j11*j22 - j12*j12
+		if det == 0 {
+			return Fit{}, false
+		}
+		stepAlpha := (j22*g1 - j12*g2) / det
+		stepBeta := (j11*g2 - j12*g1) / det
+
+		// Halve the step until both shapes stay positive
+		scale := 1.0
+		for alpha-scale*stepAlpha <= 0 || beta-scale*stepBeta <= 0 {
+			scale /= 2
+		}
+		nextAlpha, nextBeta := alpha-scale*stepAlpha, beta-scale*stepBeta
+		converged := math.Abs(nextAlpha-alpha) < fitTolerance*alpha && math.Abs(nextBeta-beta) < fitTolerance*beta
+		alpha, beta = nextAlpha, nextBeta
+		if converged {
+			break
+		}
+	}
+
+	lgammaA, _ := math.Lgamma(alpha)
+	lgammaB, _ := math.Lgamma(beta)
+	lgammaAB, _ := math.Lgamma(alpha + beta)
+	logBeta := lgammaA + lgammaB - lgammaAB
+	return Fit{
+		Distribution:  "beta",
+		Parameters:    []Parameter{{"alpha", alpha}, {"beta", beta}, {"lower", lower}, {"upper", upper}},
+		LogLikelihood: s.n * ((alpha-1)*meanLogY + (beta-1)*meanLog1Y - logBeta - math.Log(width)),
+		pdf: func(x float64) float64 {
+			y := (x - lower) / width
+			if y <= 0 || y >= 1 {
+				return 0
+			}
+			return math.Exp((alpha-1)*math.Log(y)+(beta-1)*math.Log1p(-y)-logBeta) / width
+		},
+		cdf: func(x float64) float64 { return RegularizedBeta((x-lower)/width, alpha, beta) },
+	}, true
+}
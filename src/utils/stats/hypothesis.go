@@ -0,0 +1,201 @@
+package stats
+
+import (
+	"math"
+	"sort"
+)
+
+// GroupSummary holds the moments of one group that the parametric tests need
+type GroupSummary struct {
+	Count    int64
+	Mean     float64
+	Variance float64 // Sample variance
+}
+
+// WelchTTest tests whether two groups have the same mean without assuming equal variances.
+// The degrees of freedom follow Welch-Satterthwaite.
+func WelchTTest(a GroupSummary, b GroupSummary) (TestResult, bool) {
+	if a.Count < 2 || b.Count < 2 {
+		return TestResult{}, false
+	}
+	va := a.Variance / float64(a.Count)
+	vb := b.Variance / float64(b.Count)
+	if va+vb == 0 {
+		return TestResult{}, false
+	}
+	t := (a.Mean - b.Mean) / math.Sqrt(va+vb)
+	df := (va + vb) * (va + vb) / (va*va/float64(a.Count-1) + vb*vb/float64(b.Count-1))
+	return TestResult{Name: "Welch t", Statistic: t, PValue: StudentTTwoSided(t, df), N: a.Count + b.Count}, true
+}
+
+// OneWayANOVA tests whether all groups have the same mean, assuming equal variances
+func OneWayANOVA(groups []GroupSummary) (TestResult, bool) {
+	n, k := int64(0), 0
+	sum := 0.0
+	for _, g := range groups {
+		if g.Count == 0 {
+			continue
+		}
+		n += g.Count
+		sum += g.Mean * float64(g.Count)
+		k++
+	}
+	if k < 2 || n <= int64(k) {
+		return TestResult{}, false
+	}
+	grandMean := sum / float64(n)
+
+	between, within := 0.0, 0.0
+	for _, g := range groups {
+		if g.Count == 0 {
+			continue
+		}
+		between += float64(g.Count) * (g.Mean - grandMean) * (g.Mean - grandMean)
+		within += float64(g.Count-1) * g.Variance
+	}
+	if within == 0 {
+		return TestResult{}, false
+	}
+	d1, d2 := float64(k-1), float64(n-int64(k))
+	f := (between / d1) / (within / d2)
+	return TestResult{Name: "ANOVA F", Statistic: f, PValue: FSurvival(f, d1, d2), N: n}, true
+}
+
+// rankedValue is a value with the group it came from
+type rankedValue struct {
+	value float64
+	group int
+}
+
+// midranks ranks the values of all groups together, giving tied values the mean of their
+// ranks. It returns the rank sum of each group and Σ(t³ - t) over the ties.
+func midranks(groups [][]float64) ([]float64, float64) {
+	var all []rankedValue
+	for g, values := range groups {
+		for _, v := range values {
+			all = append(all, rankedValue{value: v, group: g})
+		}
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].value < all[j].value })
+
+	rankSums := make([]float64, len(groups))
+	ties := 0.0
+	for i := 0; i < len(all); {
+		j := i
+		for j < len(all) && all[j].value == all[i].value {
+			j++
+		}
+		rank := float64(i+j+1) / 2 // Mean of the ranks i+1 .. j
+		for _, v := range all[i:j] {
+			rankSums[v.group] += rank
+		}
+		t := float64(j - i)
+		ties += t*t*t - t
+		i = j
+	}
+	return rankSums, ties
+}
+
+// MannWhitneyU tests whether values of one group tend to be larger than those of the other.
+// The statistic is U of x; the p-value uses the normal approximation with tie and continuity
+// corrections.
+func MannWhitneyU(x []float64, y []float64) (TestResult, bool) {
+	if len(x) == 0 || len(y) == 0 {
+		return TestResult{}, false
+	}
+	rankSums, ties := midranks([][]float64{x, y})
+	n1, n2 := float64(len(x)), float64(len(y))
+	n := n1 + n2
+	u := rankSums[0] - n1*(n1+1)/2
+
+	variance := n1 * n2 / 12 * ((n + 1) - ties/(n*(n-1)))
+	if variance <= 0 {
+		return TestResult{}, false
+	}
+	deviation := math.Max(math.Abs(u-n1*n2/2)-0.5, 0)
+	z := deviation / math.Sqrt(variance)
+	return TestResult{Name: "Mann-Whitney U", Statistic: u, PValue: math.Min(2*NormalSurvival(z), 1), N: int64(n)}, true
+}
+
+// KruskalWallis tests whether the groups come from the same distribution, the rank analogue
+// of one-way ANOVA. H is corrected for ties and compared with a chi-square distribution.
+func KruskalWallis(groups [][]float64) (TestResult, bool) {
+	nonEmpty := make([][]float64, 0, len(groups))
+	for _, g := range groups {
+		if len(g) > 0 {
+			nonEmpty = append(nonEmpty, g)
+		}
+	}
+	if len(nonEmpty) < 2 {
+		return TestResult{}, false
+	}
+	rankSums, ties := midranks(nonEmpty)
+	n := 0.0
+	for _, g := range nonEmpty {
+		n += float64(len(g))
+	}
+	correction := 1 - ties/(n*n*n-n)
+	if correction <= 0 {
+		return TestResult{}, false
+	}
+
+	h := 0.0
+	for g, values := range nonEmpty {
+		h += rankSums[g] * rankSums[g] / float64(len(values))
+	}
+	h = (12/(n*(n+1))*h - 3*(n+1)) / correction
+	df := float64(len(nonEmpty) - 1)
+	return TestResult{Name: "Kruskal-Wallis H", Statistic: h, PValue: ChiSquareSurvival(h, df), N: int64(n)}, true
+}
+
+// KolmogorovSmirnov2 tests whether two samples come from the same distribution. The
+// statistic is the largest distance between their empirical CDFs; the p-value uses the
+// asymptotic distribution with Stephens' small-sample adjustment.
+func KolmogorovSmirnov2(x []float64, y []float64) (TestResult, bool) {
+	if len(x) == 0 || len(y) == 0 {
+		return TestResult{}, false
+	}
+	a := append([]float64(nil), x...)
+	b := append([]float64(nil), y...)
+	sort.Float64s(a)
+	sort.Float64s(b)
+
+	n1, n2 := float64(len(a)), float64(len(b))
+	d := 0.0
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		// Step past every copy of the next value on both sides before comparing
+		value := math.Min(a[i], b[j])
+		for i < len(a) && a[i] == value {
+			i++
+		}
+		for j < len(b) && b[j] == value {
+			j++
+		}
+		d = math.Max(d, math.Abs(float64(i)/n1-float64(j)/n2))
+	}
+
+	en := math.Sqrt(n1 * n2 / (n1 + n2))
+	p := KolmogorovSurvival((en + 0.12 + 0.11/en) * d)
+	return TestResult{Name: "Kolmogorov-Smirnov", Statistic: d, PValue: p, N: int64(n1 + n2)}, true
+}
+
+// BenjaminiHochberg adjusts p-values for the false discovery rate across a family of tests.
+// An adjusted p-value below q marks a discovery at false discovery rate q.
+func BenjaminiHochberg(pValues []float64) []float64 {
+	m := len(pValues)
+	order := make([]int, m)
+	for i := range order {
+		order[i] = i
+	}
+	sort.Slice(order, func(a, b int) bool { return pValues[order[a]] < pValues[order[b]] })
+
+	adjusted := make([]float64, m)
+	running := 1.0
+	for k := m - 1; k >= 0; k-- {
+		i := order[k]
+		running = math.Min(running, pValues[i]*float64(m)/float64(k+1))
+		adjusted[i] = running
+	}
+	return adjusted
+}
@@ -0,0 +1,134 @@
+package stats
+
+import (
+	"math"
+	"math/rand/v2"
+	"sort"
+)
+
+// BinnedMutualInformation returns the mutual information in bits between values and their
+// class labels (0 .. classes-1), with values binned into bins equal-width bins:
+// I = H(X) + H(Y) - H(X, Y). The plug-in estimate is biased upwards by about
+// (bins-1)(classes-1) / (2n ln 2) bits.
+func BinnedMutualInformation(values []float64, labels []int, classes int, bins int) float64 {
+	if len(values) == 0 || bins < 1 || classes < 1 {
+		return 0
+	}
+	lo, hi := values[0], values[0]
+	for _, v := range values {
+		lo, hi = math.Min(lo, v), math.Max(hi, v)
+	}
+	if hi == lo {
+		return 0
+	}
+
+	width := (hi - lo) / float64(bins)
+	joint := make([]int64, bins*classes)
+	binCounts := make([]int64, bins)
+	classCounts := make([]int64, classes)
+	for i, v := range values {
+		bin := min(int((v-lo)/width), bins-1) // The maximum falls into the last bin
+		joint[bin*classes+labels[i]]++
+		binCounts[bin]++
+		classCounts[labels[i]]++
+	}
+	return math.Max(Entropy(binCounts)+Entropy(classCounts)-Entropy(joint), 0)
+}
+
+// KSGMutualInformation returns the mutual information in bits between continuous values
+// and their class labels, estimated from k-nearest-neighbour distances as in Ross (2014),
+// the mixed discrete-continuous form of the Kraskov-Stögbauer-Grassberger estimator. Ties
+// would make the distances 0, so the values are jittered by a negligible amount with a fixed
+// seed, as scikit-learn does. Points whose class has no other member are left out.
+func KSGMutualInformation(values []float64, labels []int, k int) float64 {
+	// Scale to unit variance, then jitter
+	mean, m2 := 0.0, 0.0
+	for i, v := range values {
+		delta := v - mean
+		mean += delta / float64(i+1)
+		m2 += delta * (v - mean)
+	}
+	scale := 1.0
+	if len(values) > 1 && m2 > 0 {
+		scale = math.Sqrt(m2 / float64(len(values)-1))
+	}
+	meanAbs := 0.0
+	for _, v := range values {
+		meanAbs += math.Abs(v / scale)
+	}
+	meanAbs /= math.Max(float64(len(values)), 1)
+	rng := rand.New(rand.NewPCG(0x9e3779b97f4a7c15, uint64(len(values))))
+	jitter := 1e-10 * math.Max(meanAbs, 1)
+
+	byClass := make(map[int][]float64)
+	points := make([]labelledValue, len(values))
+	for i, v := range values {
+		x := v/scale + jitter*rng.NormFloat64()
+		points[i] = labelledValue{value: x, label: labels[i]}
+		byClass[labels[i]] = append(byClass[labels[i]], x)
+	}
+	for _, class := range byClass {
+		sort.Float64s(class)
+	}
+
+	// Points of classes with a single member carry no neighbour information
+	kept := points[:0]
+	for _, p := range points {
+		if len(byClass[p.label]) > 1 {
+			kept = append(kept, p)
+		}
+	}
+	if len(kept) < 2 {
+		return 0
+	}
+	all := make([]float64, len(kept))
+	for i, p := range kept {
+		all[i] = p.value
+	}
+	sort.Float64s(all)
+
+	sum := 0.0
+	for _, p := range kept {
+		class := byClass[p.label]
+		kc := min(k, len(class)-1)
+		radius := kthNeighbourDistance(class, p.value, kc)
+		// Points strictly within the radius over all classes, the point itself included
+		lower := sort.Search(len(all), func(j int) bool { return all[j] > p.value-radius })
+		upper := sort.Search(len(all), func(j int) bool { return all[j] >= p.value+radius })
+		m := max(upper-lower, 1)
+		sum += Digamma(float64(kc)) - Digamma(float64(len(class))) - Digamma(float64(m))
+	}
+	mi := Digamma(float64(len(kept))) + sum/float64(len(kept))
+	return math.Max(mi, 0) / math.Ln2
+}
+
+type labelledValue struct {
+	value float64
+	label int
+}
+
+// kthNeighbourDistance returns the distance from x, a member of sorted, to its k-th nearest
+// other member
+func kthNeighbourDistance(sorted []float64, x float64, k int) float64 {
+	// Start from one copy of x and widen towards the closer side k times
+	self := sort.SearchFloat64s(sorted, x)
+	left, right := self-1, self+1
+	distance := 0.0
+	for step := 0; step < k; step++ {
+		switch {
+		case left < 0:
+			distance = sorted[right] - x
+			right++
+		case right >= len(sorted):
+			distance = x - sorted[left]
+			left--
+		case x-sorted[left] <= sorted[right]-x:
+			distance = x - sorted[left]
+			left--
+		default:
+			distance = sorted[right] - x
+			right++
+		}
+	}
+	return distance
+}
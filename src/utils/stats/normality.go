@@ -0,0 +1,223 @@
+package stats
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// NormalityAlpha is the significance level of the normality verdict
+const NormalityAlpha = 0.05
+
+// TestResult is the outcome of one hypothesis test
+type TestResult struct {
+	Name      string  `json:"name"`
+	Statistic float64 `json:"statistic"`
+	PValue    float64 `json:"p_value"`
+	N         int64   `json:"n"` // Values the test was computed on
+}
+
+// Rejects reports whether the null hypothesis is rejected at level alpha
+func (r TestResult) Rejects(alpha float64) bool {
+	return r.PValue < alpha
+}
+
+// NormalityReport collects the normality tests of one column with a plain-language verdict
+type NormalityReport struct {
+	Tests   []TestResult `json:"tests"`
+	Verdict string       `json:"verdict"`
+}
+
+// TestNormality runs every normality test that has enough data. Jarque-Bera and D'Agostino's
+// K² use the moments of all n values (skewness g1, excess kurtosis g2); Kolmogorov-Smirnov
+// and Anderson-Darling need the values themselves and run on sample, which may be a uniform
+// subsample of them. Callers should not test constant columns, whose moment ratios are undefined.
+func TestNormality(n int64, skewness float64, kurtosis float64, sample []float64) NormalityReport {
+	report := NormalityReport{Tests: make([]TestResult, 0, 4)}
+	if test, ok := JarqueBera(n, skewness, kurtosis); ok {
+		report.Tests = append(report.Tests, test)
+	}
+	if test, ok := DAgostinoK2(n, skewness, kurtosis); ok {
+		report.Tests = append(report.Tests, test)
+	}
+	if test, ok := LillieforsNormal(sample); ok {
+		report.Tests = append(report.Tests, test)
+	}
+	if test, ok := AndersonDarlingNormal(sample); ok {
+		report.Tests = append(report.Tests, test)
+	}
+	report.Verdict = normalityVerdict(report.Tests, skewness, kurtosis)
+	return report
+}
+
+func normalityVerdict(tests []TestResult, skewness float64, kurtosis float64) string {
+	if len(tests) == 0 {
+		return "not enough values to test"
+	}
+	rejected := 0
+	for _, test := range tests {
+		if test.Rejects(NormalityAlpha) {
+			rejected++
+		}
+	}
+	if rejected == 0 {
+		return fmt.Sprintf("consistent with a normal distribution (no test rejects at the %g%% level)", NormalityAlpha*100)
+	}
+
+	var shape []string
+	switch {
+	case skewness > 0.5:
+		shape = append(shape, "right-skewed")
+	case skewness < -0.5:
+		shape = append(shape, "left-skewed")
+	}
+	switch {
+	case kurtosis > 1:
+		shape = append(shape, "heavy-tailed")
+	case kurtosis < -1:
+		shape = append(shape, "light-tailed")
+	}
+	description := ""
+	if len(shape) > 0 {
+		description = ", " + strings.Join(shape, " and ")
+	}
+
+	if rejected == len(tests) {
+		return fmt.Sprintf("not normal%s (all %d tests reject at the %g%% level)", description, rejected, NormalityAlpha*100)
+	}
+	return fmt.Sprintf("borderline%s (%d of %d tests reject normality at the %g%% level)", description, rejected, len(tests), NormalityAlpha*100)
+}
+
+// JarqueBera tests whether skewness and excess kurtosis are jointly zero. The statistic is
+// asymptotically chi-square with two degrees of freedom.
+func JarqueBera(n int64, skewness float64, kurtosis float64) (TestResult, bool) {
+	if n < 3 {
+		return TestResult{}, false
+	}
+	statistic := float64(n) / 6 * (skewness*skewness + kurtosis*kurtosis/4)
+	return TestResult{Name: "Jarque-Bera", Statistic: statistic, PValue: ChiSquareSurvival(statistic, 2), N: n}, true
+}
+
+// DAgostinoK2 combines the transformed skewness test of D'Agostino and the kurtosis test of
+// Anscombe and Glynn into an omnibus statistic, chi-square with two degrees of freedom
+// under normality. It needs at least 8 values.
+func DAgostinoK2(n int64, skewness float64, kurtosis float64) (TestResult, bool) {
+	if n < 8 {
+		return TestResult{}, false
+	}
+	zSkew := skewnessZ(float64(n), skewness)
+	zKurt := kurtosisZ(float64(n), kurtosis)
+	statistic := zSkew*zSkew + zKurt*zKurt
+	return TestResult{Name: "D'Agostino K²", Statistic: statistic, PValue: ChiSquareSurvival(statistic, 2), N: n}, true
+}
+
+func skewnessZ(n float64, g1 float64) float64 {
+	y := g1 * math.Sqrt((n+1)*(n+3)/(6*(n-2)))
+	beta2 := 3 * (n*n + 27*n - 70) * (n + 1) * (n + 3) / ((n - 2) * (n + 5) * (n + 7) * (n + 9))
+	w2 := -1 + math.Sqrt(2*(beta2-1))
+	delta := 1 / math.Sqrt(0.5*math.Log(w2))
+	alpha := math.Sqrt(2 / (w2 - 1))
+	if y == 0 {
+		y = 1
+	}
+	return delta * math.Asinh(y/alpha)
+}
+
+func kurtosisZ(n float64, g2 float64) float64 {
+	b2 := g2 + 3
+	mean := 3 * (n - 1) / (n + 1)
+	variance := 24 * n * (n - 2) * (n - 3) / ((n + 1) * (n + 1) * (n + 3) * (n + 5))
+	x := (b2 - mean) / math.Sqrt(variance)
+	sqrtBeta1 := 6 * (n*n - 5*n + 2) / ((n + 7) * (n + 9)) * math.Sqrt(6*(n+3)*(n+5)/(n*(n-2)*(n-3)))
+	a := 6 + 8/sqrtBeta1*(2/sqrtBeta1+math.Sqrt(1+4/(sqrtBeta1*sqrtBeta1)))
+	term1 := 1 - 2/(9*a)
+	denom := 1 + x*math.Sqrt(2/(a-4))
+	term2 := math.Cbrt((1 - 2/a) / denom)
+	return (term1 - term2) / math.Sqrt(2/(9*a))
+}
+
+// LillieforsNormal is the Kolmogorov-Smirnov test against a normal distribution whose mean
+// and standard deviation are estimated from the sample, which is Lilliefors' test. The
+// p-value uses the approximation of Dallal and Wilkinson, accurate below 0.1 and only
+// indicative above it, which is enough for a verdict at 5%.
+func LillieforsNormal(sample []float64) (TestResult, bool) {
+	sorted, mean, stdDev, ok := standardize(sample, 5)
+	if !ok {
+		return TestResult{}, false
+	}
+
+	n := float64(len(sorted))
+	d := 0.0
+	for i, x := range sorted {
+		f := NormalCDF((x - mean) / stdDev)
+		d = math.Max(d, math.Max(float64(i+1)/n-f, f-float64(i)/n))
+	}
+
+	// Beyond 100 values the statistic is rescaled to n = 100
+	dAdjusted, nAdjusted := d, n
+	if n > 100 {
+		dAdjusted = d * math.Pow(n/100, 0.49)
+		nAdjusted = 100
+	}
+	p := math.Exp(-7.01256*dAdjusted*dAdjusted*(nAdjusted+2.78019) + 2.99587*dAdjusted*math.Sqrt(nAdjusted+2.78019) -
+		0.122119 + 0.974598/math.Sqrt(nAdjusted) + 1.67997/nAdjusted)
+	return TestResult{Name: "Kolmogorov-Smirnov (Lilliefors)", Statistic: d, PValue: math.Min(p, 1), N: int64(len(sorted))}, true
+}
+
+// AndersonDarlingNormal tests the sample against a normal distribution with estimated mean
+// and standard deviation. It weighs the tails more than Kolmogorov-Smirnov. The statistic is
+// reported with the small-sample correction of D'Agostino and Stephens, whose p-value
+// approximation it uses.
+func AndersonDarlingNormal(sample []float64) (TestResult, bool) {
+	sorted, mean, stdDev, ok := standardize(sample, 8)
+	if !ok {
+		return TestResult{}, false
+	}
+
+	n := len(sorted)
+	sum := 0.0
+	for i := 0; i < n; i++ {
+		lower := NormalCDF((sorted[i] - mean) / stdDev)
+		upper := NormalSurvival((sorted[n-1-i] - mean) / stdDev)
+		sum += float64(2*i+1) * (math.Log(math.Max(lower, math.SmallestNonzeroFloat64)) + math.Log(math.Max(upper, math.SmallestNonzeroFloat64)))
+	}
+	nf := float64(n)
+	a2 := -nf - sum/nf
+	a2 *= 1 + 0.75/nf + 2.25/(nf*nf)
+
+	var p float64
+	switch {
+	case a2 >= 0.6:
+		p = math.Exp(1.2937 - 5.709*a2 + 0.0186*a2*a2)
+	case a2 >= 0.34:
+		p = math.Exp(0.9177 - 4.279*a2 - 1.38*a2*a2)
+	case a2 >= 0.2:
+		p = 1 - math.Exp(-8.318+42.796*a2-59.938*a2*a2)
+	default:
+		p = 1 - math.Exp(-13.436+101.14*a2-223.73*a2*a2)
+	}
+	return TestResult{Name: "Anderson-Darling", Statistic: a2, PValue: math.Min(math.Max(p, 0), 1), N: int64(n)}, true
+}
+
+// standardize sorts a copy of sample and estimates its mean and sample standard deviation.
+// It fails with fewer than minN values or when they are all equal.
+func standardize(sample []float64, minN int) ([]float64, float64, float64, bool) {
+	if len(sample) < minN {
+		return nil, 0, 0, false
+	}
+	sorted := append([]float64(nil), sample...)
+	sort.Float64s(sorted)
+
+	mean, m2 := 0.0, 0.0
+	for i, x := range sorted {
+		delta := x - mean
+		mean += delta / float64(i+1)
+		m2 += delta * (x - mean)
+	}
+	stdDev := math.Sqrt(m2 / float64(len(sorted)-1))
+	if stdDev == 0 {
+		return nil, 0, 0, false
+	}
+	return sorted, mean, stdDev, true
+}
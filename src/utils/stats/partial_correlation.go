@@ -0,0 +1,140 @@
+package stats
+
+import "math"
+
+// singularTolerance is the relative size below which a pivot counts as zero
+const singularTolerance = 1e-12
+
+// InvertMatrix returns the inverse of the square matrix a by Gauss-Jordan elimination with
+// partial pivoting, and false when a is singular. a is left unchanged.
+func InvertMatrix(a [][]float64) ([][]float64, bool) {
+	n := len(a)
+	work := make([][]float64, n)
+	inverse := make([][]float64, n)
+	scale := 0.0
+	for i := range a {
+		work[i] = append([]float64(nil), a[i]...)
+		inverse[i] = make([]float64, n)
+		inverse[i][i] = 1
+		for _, v := range a[i] {
+			scale = math.Max(scale, math.Abs(v))
+		}
+	}
+
+	for col := 0; col < n; col++ {
+		pivot := col
+		for row := col + 1; row < n; row++ {
+			if math.Abs(work[row][col]) > math.Abs(work[pivot][col]) {
+				pivot = row
+			}
+		}
+		if math.Abs(work[pivot][col]) <= singularTolerance*scale {
+			return nil, false
+		}
+		work[col], work[pivot] = work[pivot], work[col]
+		inverse[col], inverse[pivot] = inverse[pivot], inverse[col]
+
+		p := work[col][col]
+		for j := 0; j < n; j++ {
+			work[col][j] /= p
+			inverse[col][j] /= p
+		}
+		for row := 0; row < n; row++ {
+			if row == col || work[row][col] == 0 {
+				continue
+			}
+			f := work[row][col]
+			for j := 0; j < n; j++ {
+				work[row][j] -= f * work[col][j]
+				inverse[row][j] -= f * inverse[col][j]
+			}
+		}
+	}
+	return inverse, true
+}
+
+// PartialCorrelation returns the correlation of every pair of variables with all other
+// variables held fixed, -Ω_ij / √(Ω_ii Ω_jj) for the precision matrix Ω, the inverse of the
+// covariance matrix cov. A pairwise covariance matrix that is not positive definite can give
+// a non-positive Ω_ii, in which case the coefficient is 0. It returns false when cov is
+// singular.
+func PartialCorrelation(cov [][]float64) ([][]float64, bool) {
+	precision, ok := InvertMatrix(cov)
+	if !ok {
+		return nil, false
+	}
+	n := len(cov)
+	partial := make([][]float64, n)
+	for i := range partial {
+		partial[i] = make([]float64, n)
+		for j := range partial[i] {
+			if i == j {
+				partial[i][j] = 1
+			} else if d := precision[i][i] * precision[j][j]; d > 0 {
+				partial[i][j] = clampCorrelation(-precision[i][j] / math.Sqrt(d))
+			}
+		}
+	}
+	return partial, true
+}
+
+// PartialCorrelationGiven returns the correlation of every pair of variables with only the
+// variables controls held fixed, from the residual covariance cov_XX - cov_XC cov_CC⁻¹ cov_CX.
+// Rows and columns of the controls are 0 apart from the diagonal. It returns false when the
+// covariance of the controls is singular.
+func PartialCorrelationGiven(cov [][]float64, controls []int) ([][]float64, bool) {
+	n := len(cov)
+	isControl := make([]bool, n)
+	for _, c := range controls {
+		isControl[c] = true
+	}
+	controlCov := make([][]float64, len(controls))
+	for a, c := range controls {
+		controlCov[a] = make([]float64, len(controls))
+		for b, d := range controls {
+			controlCov[a][b] = cov[c][d]
+		}
+	}
+	controlInverse, ok := InvertMatrix(controlCov)
+	if !ok {
+		return nil, false
+	}
+
+	residual := func(i int, j int) float64 {
+		r := cov[i][j]
+		for a, c := range controls {
+			for b, d := range controls {
+				r -= cov[i][c] * controlInverse[a][b] * cov[d][j]
+			}
+		}
+		return r
+	}
+	variances := make([]float64, n)
+	for i := range variances {
+		if !isControl[i] {
+			variances[i] = residual(i, i)
+		}
+	}
+
+	partial := make([][]float64, n)
+	for i := range partial {
+		partial[i] = make([]float64, n)
+		partial[i][i] = 1
+	}
+	for i := 0; i < n; i++ {
+		for j := i + 1; j < n; j++ {
+			if isControl[i] || isControl[j] || variances[i] <= 0 || variances[j] <= 0 {
+				continue
+			}
+			r := clampCorrelation(residual(i, j) / math.Sqrt(variances[i]*variances[j]))
+			partial[i][j] = r
+			partial[j][i] = r
+		}
+	}
+	return partial, true
+}
+
+// clampCorrelation keeps a coefficient within [-1, 1] against rounding
+func clampCorrelation(r float64) float64 {
+	return math.Max(-1, math.Min(1, r))
+}
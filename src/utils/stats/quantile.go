@@ -0,0 +1,114 @@
+package stats
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// QuantileMethod selects one of the nine sample quantile definitions of Hyndman and Fan
+// (1996). Type 7, linear interpolation between the closest ranks, is the default of numpy
+// and R; type 6 is the one of Minitab and SPSS; type 1 is the inverse of the empirical CDF.
+type QuantileMethod int
+
+// DefaultQuantileMethod matches numpy.quantile and R's quantile
+const DefaultQuantileMethod QuantileMethod = 7
+
+// quantileFuzz absorbs the rounding of n·q relative to its size, so that e.g. 0.07 · 100,
+// which evaluates to 7.000000000000001, counts as the integer 7
+const quantileFuzz = 4 * 2.220446049250313e-16
+
+// ParseQuantileMethod reads a Hyndman-Fan type from 1 to 9
+func ParseQuantileMethod(s string) (QuantileMethod, error) {
+	method, err := strconv.Atoi(strings.TrimSpace(s))
+	if err != nil || method < 1 || method > 9 {
+		return 0, fmt.Errorf("quantile method %q must be a Hyndman-Fan type from 1 to 9", s)
+	}
+	return QuantileMethod(method), nil
+}
+
+// ParsePercentiles reads a comma-separated list of percentiles between 0 and 100
+func ParsePercentiles(s string) ([]float64, error) {
+	var percentiles []float64
+	for _, field := range strings.Split(s, ",") {
+		p, err := strconv.ParseFloat(strings.TrimSpace(field), 64)
+		if err != nil || p < 0 || p > 100 {
+			return nil, fmt.Errorf("percentile %q must be a number between 0 and 100", field)
+		}
+		percentiles = append(percentiles, p)
+	}
+	return percentiles, nil
+}
+
+// PercentileName returns the label of percentile p, e.g. "p95"
+func PercentileName(p float64) string {
+	return "p" + strconv.FormatFloat(p, 'g', -1, 64)
+}
+
+// SampleQuantile returns the q-th quantile (0-1) of sorted values by method, or NaN
+// without values
+func SampleQuantile(sorted []float64, q float64, method QuantileMethod) float64 {
+	n := len(sorted)
+	if n == 0 {
+		return math.NaN()
+	}
+	q = math.Min(math.Max(q, 0), 1)
+	// at returns the j-th order statistic, counting from 1 and clamped to the sample
+	at := func(j float64) float64 {
+		return sorted[int(math.Min(math.Max(j, 1), float64(n)))-1]
+	}
+
+	np := float64(n) * q
+	switch method {
+	case 1, 2, 3:
+		// Discontinuous types pick an order statistic, or average two for type 2
+		h := np
+		if method == 3 {
+			h -= 0.5
+		}
+		fuzz := quantileFuzz * math.Max(math.Abs(h), 1)
+		j := math.Floor(h + fuzz)
+		g := h - j
+		if math.Abs(g) <= fuzz {
+			g = 0
+		}
+		switch {
+		case method == 1 && g == 0:
+			return at(j)
+		case method == 2 && g == 0:
+			return (at(j) + at(j+1)) / 2
+		case method == 3 && g == 0 && math.Mod(j, 2) == 0:
+			return at(j)
+		}
+		return at(j + 1)
+	}
+
+	// Continuous types interpolate at h = n·q + m between the neighbouring order statistics
+	var m float64
+	switch method {
+	case 4:
+		m = 0
+	case 5:
+		m = 0.5
+	case 6:
+		m = q
+	case 8:
+		m = (q + 1) / 3
+	case 9:
+		m = q/4 + 3.0/8
+	default:
+		return LinearQuantile(sorted, q) // Type 7, m = 1 - q
+	}
+	h := np + m
+	fuzz := quantileFuzz * math.Max(math.Abs(h), 1)
+	j := math.Floor(h + fuzz)
+	g := math.Max(h-j, 0)
+	if j < 1 {
+		return sorted[0]
+	}
+	if j >= float64(n) {
+		return sorted[n-1]
+	}
+	return at(j) + g*(at(j+1)-at(j))
+}
@@ -0,0 +1,199 @@
+package stats
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// Correlation methods
+const (
+	CorrelationPearson  = "pearson"
+	CorrelationSpearman = "spearman"
+	CorrelationKendall  = "kendall"
+)
+
+// CorrelationMethods lists every method in the order they are reported
+var CorrelationMethods = []string{CorrelationPearson, CorrelationSpearman, CorrelationKendall}
+
+// ParseCorrelationMethods reads a comma-separated list of correlation methods, or "all".
+// The methods are returned in the order of CorrelationMethods; empty input selects Pearson.
+func ParseCorrelationMethods(s string) ([]string, error) {
+	if strings.TrimSpace(s) == "" {
+		return []string{CorrelationPearson}, nil
+	}
+	selected := make(map[string]bool)
+	for _, field := range strings.Split(s, ",") {
+		method := strings.ToLower(strings.TrimSpace(field))
+		switch method {
+		case "all":
+			for _, m := range CorrelationMethods {
+				selected[m] = true
+			}
+		case CorrelationPearson, CorrelationSpearman, CorrelationKendall:
+			selected[method] = true
+		default:
+			return nil, fmt.Errorf("correlation method %q must be pearson, spearman, kendall or all", field)
+		}
+	}
+	methods := make([]string, 0, len(selected))
+	for _, m := range CorrelationMethods {
+		if selected[m] {
+			methods = append(methods, m)
+		}
+	}
+	return methods, nil
+}
+
+// Ranks returns the ranks of values from 1, giving tied values the mean of their ranks
+func Ranks(values []float64) []float64 {
+	order := make([]int, len(values))
+	for i := range order {
+		order[i] = i
+	}
+	sort.Slice(order, func(a, b int) bool { return values[order[a]] < values[order[b]] })
+
+	ranks := make([]float64, len(values))
+	for i := 0; i < len(order); {
+		j := i
+		for j < len(order) && values[order[j]] == values[order[i]] {
+			j++
+		}
+		rank := float64(i+j+1) / 2 // Mean of the ranks i+1 .. j
+		for _, k := range order[i:j] {
+			ranks[k] = rank
+		}
+		i = j
+	}
+	return ranks
+}
+
+// Spearman returns Spearman's rho, the Pearson correlation of the midranks, which stays
+// exact with ties. It is 0 when either side is constant.
+func Spearman(x []float64, y []float64) float64 {
+	if len(x) < 2 {
+		return 0
+	}
+	return pearson(Ranks(x), Ranks(y))
+}
+
+// pearson returns the correlation of x and y, 0 when either side is constant
+func pearson(x []float64, y []float64) float64 {
+	n := float64(len(x))
+	meanX, meanY := 0.0, 0.0
+	for i := range x {
+		meanX += x[i]
+		meanY += y[i]
+	}
+	meanX /= n
+	meanY /= n
+	sxx, syy, sxy := 0.0, 0.0, 0.0
+	for i := range x {
+		dx, dy := x[i]-meanX, y[i]-meanY
+		sxx += dx * dx
+		syy += dy * dy
+		sxy += dx * dy
+	}
+	if sxx == 0 || syy == 0 {
+		return 0
+	}
+	return sxy / math.Sqrt(sxx*syy)
+}
+
+// KendallTauB returns Kendall's tau-b, which corrects tau for ties on either side. It uses
+// Knight's algorithm: after sorting the pairs by x, the discordant pairs are the inversions
+// of y, counted by a merge sort in O(n log n). It is 0 when either side is constant.
+func KendallTauB(x []float64, y []float64) float64 {
+	n := len(x)
+	if n < 2 {
+		return 0
+	}
+	order := make([]int, n)
+	for i := range order {
+		order[i] = i
+	}
+	sort.Slice(order, func(a, b int) bool {
+		if x[order[a]] != x[order[b]] {
+			return x[order[a]] < x[order[b]]
+		}
+		return y[order[a]] < y[order[b]]
+	})
+
+	// Pairs tied in x, and tied in both x and y, from the runs of the sorted order
+	xTies, jointTies := int64(0), int64(0)
+	for i := 0; i < n; {
+		j := i
+		for j < n && x[order[j]] == x[order[i]] {
+			j++
+		}
+		xTies += tiedPairs(j - i)
+		for k := i; k < j; {
+			l := k
+			for l < j && y[order[l]] == y[order[k]] {
+				l++
+			}
+			jointTies += tiedPairs(l - k)
+			k = l
+		}
+		i = j
+	}
+
+	sortedY := make([]float64, n)
+	for i, k := range order {
+		sortedY[i] = y[k]
+	}
+	discordant := mergeCountInversions(sortedY, make([]float64, n))
+
+	// sortedY is now sorted, so the runs give the pairs tied in y
+	yTies := int64(0)
+	for i := 0; i < n; {
+		j := i
+		for j < n && sortedY[j] == sortedY[i] {
+			j++
+		}
+		yTies += tiedPairs(j - i)
+		i = j
+	}
+
+	total := tiedPairs(n)
+	if total == xTies || total == yTies {
+		return 0
+	}
+	difference := float64(total - xTies - yTies + jointTies - 2*discordant)
+	return difference / math.Sqrt(float64(total-xTies)*float64(total-yTies))
+}
+
+// tiedPairs returns the number of pairs among k tied values
+func tiedPairs(k int) int64 {
+	return int64(k) * int64(k-1) / 2
+}
+
+// mergeCountInversions sorts values and returns the number of pairs i < j with
+// values[i] > values[j]. Equal values are not inversions. buffer must be as long as values.
+func mergeCountInversions(values []float64, buffer []float64) int64 {
+	n := len(values)
+	if n < 2 {
+		return 0
+	}
+	mid := n / 2
+	inversions := mergeCountInversions(values[:mid], buffer[:mid]) + mergeCountInversions(values[mid:], buffer[mid:])
+
+	i, j, k := 0, mid, 0
+	for i < mid && j < n {
+		if values[j] < values[i] {
+			// values[j] jumps ahead of every value left in the first half
+			inversions += int64(mid - i)
+			buffer[k] = values[j]
+			j++
+		} else {
+			buffer[k] = values[i]
+			i++
+		}
+		k++
+	}
+	k += copy(buffer[k:], values[i:mid])
+	copy(buffer[k:], values[j:])
+	copy(values, buffer[:n])
+	return inversions
+}
@@ -0,0 +1,168 @@
+package stats
+
+import "math"
+
+const (
+	specialEpsilon  = 1e-15
+	specialMaxIters = 500
+)
+
+// Digamma returns ψ(x), the derivative of ln Γ(x), for x > 0
+func Digamma(x float64) float64 {
+	result := 0.0
+	// Shift x up until the asymptotic series is accurate
+	for ; x < 10; x++ {
+		result -= 1 / x
+	}
+	inv2 := 1 / (x * x)
+	return result + math.Log(x) - 0.5/x -
+		inv2*(1.0/12-inv2*(1.0/120-inv2*(1.0/252-inv2*(1.0/240-inv2/132))))
+}
+
+// Trigamma returns ψ'(x), the derivative of Digamma, for x > 0
+func Trigamma(x float64) float64 {
+	result := 0.0
+	for ; x < 10; x++ {
+		result += 1 / (x * x)
+	}
+	inv := 1 / x
+	inv2 := inv * inv
+	return result + inv + inv2/2 + inv*inv2*(1.0/6-inv2*(1.0/30-inv2*(1.0/42-inv2/30)))
+}
+
+// RegularizedGammaP returns the regularized lower incomplete gamma function P(a, x), the
+// CDF of a gamma distribution with shape a and unit scale
+func RegularizedGammaP(a float64, x float64) float64 {
+	p, q := regularizedGamma(a, x)
+	if p < 0 {
+		return 1 - q
+	}
+	return p
+}
+
+// RegularizedGammaQ returns 1 - P(a, x), accurate far into the upper tail where the
+// difference would lose every digit
+func RegularizedGammaQ(a float64, x float64) float64 {
+	p, q := regularizedGamma(a, x)
+	if q < 0 {
+		return 1 - p
+	}
+	return q
+}
+
+// regularizedGamma computes whichever of P(a, x) and Q(a, x) converges fast and returns -1
+// for the other one
+func regularizedGamma(a float64, x float64) (float64, float64) {
+	if x <= 0 {
+		return 0, 1
+	}
+	if math.IsInf(x, 1) {
+		return 1, 0
+	}
+	lgammaA, _ := math.Lgamma(a)
+	logPrefix := a*math.Log(x) - x - lgammaA
+
+	if x < a+1 {
+		// Series expansion
+		term := 1 / a
+		sum := term
+		for n := 1; n < specialMaxIters; n++ {
+			term *= x / (a + float64(n))
+			sum += term
+			if math.Abs(term) < math.Abs(sum)*specialEpsilon {
+				break
+			}
+		}
+		return sum * math.Exp(logPrefix), -1
+	}
+
+	// Continued fraction for Q(a, x), evaluated with the modified Lentz method
+	tiny := 1e-300
+	b := x + 1 - a
+	c := 1 / tiny
+	d := 1 / b
+	h := d
+	for n := 1; n < specialMaxIters; n++ {
+		an := -float64(n) * (float64(n) - a)
+		b += 2
+		d = an*d + b
+		if math.Abs(d) < tiny {
+			d = tiny
+		}
+		c = b + an/c
+		if math.Abs(c) < tiny {
+			c = tiny
+		}
+		d = 1 / d
+		delta := d * c
+		h *= delta
+		if math.Abs(delta-1) < specialEpsilon {
+			break
+		}
+	}
+	return -1, math.Exp(logPrefix) * h
+}
+
+// RegularizedBeta returns the regularized incomplete beta function I_x(a, b), the CDF of a
+// beta distribution
+func RegularizedBeta(x float64, a float64, b float64) float64 {
+	if x <= 0 {
+		return 0
+	}
+	if x >= 1 {
+		return 1
+	}
+	lgammaAB, _ := math.Lgamma(a + b)
+	lgammaA, _ := math.Lgamma(a)
+	lgammaB, _ := math.Lgamma(b)
+	front := math.Exp(lgammaAB - lgammaA - lgammaB + a*math.Log(x) + b*math.Log1p(-x))
+
+	// The continued fraction converges fast only on this side of the mean
+	if x < (a+1)/(a+b+2) {
+		return front * betaContinuedFraction(x, a, b) / a
+	}
+	return 1 - front*betaContinuedFraction(1-x, b, a)/b
+}
+
+func betaContinuedFraction(x float64, a float64, b float64) float64 {
+	tiny := 1e-300
+	c := 1.0
+	d := 1 - (a+b)*x/(a+1)
+	if math.Abs(d) < tiny {
+		d = tiny
+	}
+	d = 1 / d
+	h := d
+	for m := 1; m < specialMaxIters; m++ {
+		mf := float64(m)
+		// Even step
+		an := mf * (b - mf) * x / ((a + 2*mf - 1) * (a + 2*mf))
+		d = 1 + an*d
+		if math.Abs(d) < tiny {
+			d = tiny
+		}
+		c = 1 + an/c
+		if math.Abs(c) < tiny {
+			c = tiny
+		}
+		d = 1 / d
+		h *= d * c
+		// Odd step
+		an = -(a + mf) * (a + b + mf) * x / ((a + 2*mf) * (a + 2*mf + 1))
+		d = 1 + an*d
+		if math.Abs(d) < tiny {
+			d = tiny
+		}
+		c = 1 + an/c
+		if math.Abs(c) < tiny {
+			c = tiny
+		}
+		d = 1 / d
+		delta := d * c
+		h *= delta
+		if math.Abs(delta-1) < specialEpsilon {
+			break
+		}
+	}
+	return h
+}
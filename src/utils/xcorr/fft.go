@@ -0,0 +1,47 @@
+package xcorr
+
+import (
+	"math"
+	"math/bits"
+	"math/cmplx"
+)
+
+// fft transforms a in place with the iterative radix-2 Cooley-Tukey algorithm; len(a) must
+// be a power of two. The inverse transform is not scaled by 1/len(a).
+func fft(a []complex128, inverse bool) {
+	n := len(a)
+	if n < 2 {
+		return
+	}
+	shift := 64 - bits.TrailingZeros(uint(n))
+	for i := range a {
+		if j := int(bits.Reverse64(uint64(i)) >> shift); i < j {
+			a[i], a[j] = a[j], a[i]
+		}
+	}
+
+	sign := -1.0
+	if inverse {
+		sign = 1
+	}
+	for size := 2; size <= n; size <<= 1 {
+		step := cmplx.Rect(1, sign*2*math.Pi/float64(size))
+		for start := 0; start < n; start += size {
+			w := complex(1, 0)
+			for k := 0; k < size/2; k++ {
+				even, odd := a[start+k], w*a[start+k+size/2]
+				a[start+k] = even + odd
+				a[start+k+size/2] = even - odd
+				w *= step
+			}
+		}
+	}
+}
+
+// nextPowerOfTwo returns the smallest power of two not below n
+func nextPowerOfTwo(n int) int {
+	if n <= 1 {
+		return 1
+	}
+	return 1 << bits.Len(uint(n-1))
+}
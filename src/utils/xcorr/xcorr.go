@@ -0,0 +1,178 @@
+package xcorr
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/cmplx"
+	"os"
+
+	"github.com/go-echarts/go-echarts/v2/charts"
+	"github.com/go-echarts/go-echarts/v2/opts"
+)
+
+// DefaultMaxLag is the largest lag, in rows, computed when none is requested
+const DefaultMaxLag = 100
+
+// bandZ is the normal quantile of the 95% band around 0 drawn with the function
+const bandZ = 1.959964
+
+// Result is the cross-correlation function of two series sampled at the same rows.
+// Coefficients[i] is the correlation of x at row t with y at row t+Lags[i], so a peak at a
+// positive lag means changes in y follow those in x.
+type Result struct {
+	N               int       `json:"n"`       // Rows in which both series are present
+	MaxLag          int       `json:"max_lag"` // At most N-1
+	Lags            []int     `json:"lags"`    // -MaxLag .. MaxLag
+	Coefficients    []float64 `json:"coefficients"`
+	PeakLag         int       `json:"peak_lag"` // Lag of the largest absolute coefficient, the shortest on ties
+	PeakCoefficient float64   `json:"peak_coefficient"`
+	// Band is the half-width of the 95% band around 0 of coefficients of two independent
+	// white-noise series, 1.96/√N; autocorrelated series exceed it more often
+	Band float64 `json:"band"`
+}
+
+// Compute returns the cross-correlation of x and y for lags from -maxLag to maxLag. Both
+// series are centred and the products for every lag are summed with one FFT of each series
+// zero-padded against wrap-around, then divided by N σx σy as in the usual biased estimator.
+func Compute(x []float64, y []float64, maxLag int) (*Result, error) {
+	n := len(x)
+	if len(y) != n {
+		return nil, fmt.Errorf("series have %d and %d values", len(x), len(y))
+	}
+	if n < 2 {
+		return nil, fmt.Errorf("need at least 2 rows, got %d", n)
+	}
+	if maxLag < 0 {
+		return nil, fmt.Errorf("lag must not be negative, got %d", maxLag)
+	}
+	maxLag = min(maxLag, n-1)
+
+	size := nextPowerOfTwo(n + maxLag)
+	fx, sx := centred(x, size)
+	fy, sy := centred(y, size)
+	if sx == 0 || sy == 0 {
+		return nil, errors.New("a constant series has no cross-correlation")
+	}
+	fft(fx, false)
+	fft(fy, false)
+	for i := range fx {
+		fx[i] = cmplx.Conj(fx[i]) * fy[i]
+	}
+	fft(fx, true) // fx[k] is now size times the sum over t of x[t] y[t+k], negative k wrapping to the end
+
+	result := &Result{
+		N:            n,
+		MaxLag:       maxLag,
+		Lags:         make([]int, 0, 2*maxLag+1),
+		Coefficients: make([]float64, 0, 2*maxLag+1),
+		Band:         bandZ / math.Sqrt(float64(n)),
+	}
+	scale := float64(size) * math.Sqrt(sx*sy)
+	for lag := -maxLag; lag <= maxLag; lag++ {
+		r := real(fx[(lag+size)%size]) / scale
+		r = math.Max(-1, math.Min(1, r))
+		result.Lags = append(result.Lags, lag)
+		result.Coefficients = append(result.Coefficients, r)
+		if math.Abs(r) > math.Abs(result.PeakCoefficient) ||
+			(math.Abs(r) == math.Abs(result.PeakCoefficient) && abs(lag) < abs(result.PeakLag)) {
+			result.PeakLag, result.PeakCoefficient = lag, r
+		}
+	}
+	return result, nil
+}
+
+// centred returns values less their mean, zero-padded to size, and their sum of squares
+func centred(values []float64, size int) ([]complex128, float64) {
+	mean := 0.0
+	for _, v := range values {
+		mean += v
+	}
+	mean /= float64(len(values))
+	padded := make([]complex128, size)
+	squares := 0.0
+	for i, v := range values {
+		d := v - mean
+		padded[i] = complex(d, 0)
+		squares += d * d
+	}
+	return padded, squares
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// SaveAsHTML saves the function as an ECharts line chart with the 95% band around 0
+func (r *Result) SaveAsHTML(filename string, xName string, yName string) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	line := charts.NewLine()
+	line.SetGlobalOptions(
+		charts.WithTitleOpts(opts.Title{
+			Title:    fmt.Sprintf("Cross-correlation of %s and %s (n=%d)", xName, yName, r.N),
+			Subtitle: fmt.Sprintf("Peak at lag %d: %.4f; positive lags mean %s follows %s", r.PeakLag, r.PeakCoefficient, yName, xName),
+		}),
+		charts.WithXAxisOpts(opts.XAxis{
+			Name: "Lag (rows)",
+		}),
+		charts.WithYAxisOpts(opts.YAxis{
+			Name: "Correlation",
+			Min:  -1,
+			Max:  1,
+		}),
+		charts.WithTooltipOpts(opts.Tooltip{
+			Show:      opts.Bool(true),
+			Trigger:   "axis",
+			TriggerOn: "mousemove",
+		}),
+		charts.WithInitializationOpts(opts.Initialization{
+			Width:  "1200px",
+			Height: "800px",
+		}),
+		charts.WithToolboxOpts(opts.Toolbox{
+			Show: opts.Bool(true),
+			Feature: &opts.ToolBoxFeature{
+				SaveAsImage: &opts.ToolBoxFeatureSaveAsImage{
+					Show: opts.Bool(true),
+					Type: "png",
+				},
+				DataView: &opts.ToolBoxFeatureDataView{
+					Show: opts.Bool(true),
+				},
+				Restore: &opts.ToolBoxFeatureRestore{
+					Show: opts.Bool(true),
+				},
+			},
+		}),
+	)
+
+	xAxisData := make([]int, len(r.Lags))
+	lineData := make([]opts.LineData, len(r.Lags))
+	upper := make([]opts.LineData, len(r.Lags))
+	lower := make([]opts.LineData, len(r.Lags))
+	for i, lag := range r.Lags {
+		xAxisData[i] = lag
+		lineData[i] = opts.LineData{Value: r.Coefficients[i]}
+		upper[i] = opts.LineData{Value: r.Band}
+		lower[i] = opts.LineData{Value: -r.Band}
+	}
+
+	band := []charts.SeriesOpts{
+		charts.WithLineChartOpts(opts.LineChart{ShowSymbol: opts.Bool(false)}),
+		charts.WithLineStyleOpts(opts.LineStyle{Type: "dashed", Color: "#999999"}),
+	}
+	line.SetXAxis(xAxisData).
+		AddSeries("Cross-correlation", lineData, charts.WithLineChartOpts(opts.LineChart{ShowSymbol: opts.Bool(false)})).
+		AddSeries("95% band", upper, band...).
+		AddSeries("-95% band", lower, band...)
+
+	return line.Render(file)
+}
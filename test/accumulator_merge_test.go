@@ -0,0 +1,95 @@
+package test
+
+import (
+	"math"
+	"math/rand/v2"
+	"testing"
+
+	"bdgp2025/src/db_interface"
+	"bdgp2025/src/utils/sketch"
+)
+
+// Accumulators of a split scan must merge into what a single pass over the whole data yields
+func TestMomentsMergeMatchesSinglePass(t *testing.T) {
+	rng := rand.New(rand.NewPCG(3, 4))
+	xs := make([]float64, 10_000)
+	ys := make([]float64, len(xs))
+	for i := range xs {
+		xs[i] = 1000 + rng.ExpFloat64()*50 // Large offset and skew stress the cancellation in M3 and M4
+		ys[i] = 0.3*xs[i] + rng.NormFloat64()
+	}
+
+	var whole db_interface.Moments
+	var wholeCo db_interface.CoMoments
+	for i := range xs {
+		whole.Add(xs[i])
+		wholeCo.Add(xs[i], ys[i])
+	}
+
+	// Uneven partitions, including an empty one
+	bounds := []int{0, 1, 1, 2500, 7777, len(xs)}
+	var merged db_interface.Moments
+	var mergedCo db_interface.CoMoments
+	for p := 1; p < len(bounds); p++ {
+		var part db_interface.Moments
+		var partCo db_interface.CoMoments
+		for i := bounds[p-1]; i < bounds[p]; i++ {
+			part.Add(xs[i])
+			partCo.Add(xs[i], ys[i])
+		}
+		merged.Merge(part)
+		mergedCo.Merge(partCo)
+	}
+
+	relClose := func(got, expected float64) bool {
+		return math.Abs(got-expected) <= 1e-9*math.Max(1, math.Abs(expected))
+	}
+	checks := []struct {
+		stat          string
+		got, expected float64
+	}{
+		{"Count", float64(merged.Count), float64(whole.Count)},
+		{"Mean", merged.Mean, whole.Mean},
+		{"M2", merged.M2, whole.M2},
+		{"M3", merged.M3, whole.M3},
+		{"M4", merged.M4, whole.M4},
+		{"Min", merged.Min, whole.Min},
+		{"Max", merged.Max, whole.Max},
+		{"Skewness", merged.Skewness(), whole.Skewness()},
+		{"Kurtosis", merged.Kurtosis(), whole.Kurtosis()},
+		{"Covariance", mergedCo.Covariance(), wholeCo.Covariance()},
+		{"Correlation", mergedCo.Correlation(), wholeCo.Correlation()},
+	}
+	for _, c := range checks {
+		if !relClose(c.got, c.expected) {
+			t.Errorf("%s: merged %v, single pass %v", c.stat, c.got, c.expected)
+		}
+	}
+}
+
+func TestColumnAccumulatorMerge(t *testing.T) {
+	left, right := db_interface.NewColumnAccumulator(sketch.DefaultConfig()), db_interface.NewColumnAccumulator(sketch.DefaultConfig())
+	for _, v := range []float64{2, 4, 4, 4} {
+		left.Add(v)
+	}
+	left.AddNull()
+	for _, v := range []float64{5, 5, 7, 9} {
+		right.Add(v)
+	}
+	right.AddNull()
+	left.Merge(right)
+
+	summary := left.Summary()
+	if summary.Count != 8 || summary.NullCount != 2 || summary.DistinctCount != 5 {
+		t.Errorf("Count/NullCount/DistinctCount = %d/%d/%d, expected 8/2/5", summary.Count, summary.NullCount, summary.DistinctCount)
+	}
+	if !closeTo(summary.Sum, 40) || !closeTo(summary.Mean, 5) || !closeTo(summary.Variance, 32.0/7) {
+		t.Errorf("Sum/Mean/Variance = %v/%v/%v, expected 40/5/%v", summary.Sum, summary.Mean, summary.Variance, 32.0/7)
+	}
+	if summary.Min != 2 || summary.Max != 9 {
+		t.Errorf("Min/Max = %v/%v, expected 2/9", summary.Min, summary.Max)
+	}
+	if summary.Mode != 4 || summary.ModeCount != 3 || !summary.DistinctExact {
+		t.Errorf("Mode/ModeCount/DistinctExact = %v/%d/%v, expected 4/3/true", summary.Mode, summary.ModeCount, summary.DistinctExact)
+	}
+}
@@ -28,7 +28,7 @@ echo ""
 # 测试2: 统计计算功能
 echo "Test 2: Statistical Calculation Functionality"
 response=$(curl -s -X GET "${SERVER}/statistic?deviceId=${DEVICE_ID}")
-if [[ $response == *"Cnt:"* ]] && [[ $response == *"Sum:"* ]] && [[ $response == *"Average:"* ]]; then
+if [[ $response == *"Rows:"* ]] && [[ $response == *"Sum:"* ]] && [[ $response == *"Mean:"* ]] && [[ $response == *"Median:"* ]] && [[ $response == *"Jarque-Bera:"* ]] && [[ $response == *"Most frequent values"* ]] && [[ $response == *"Entropy:"* ]]; then
     echo "✓ Statistical Calculation test passed"
 else
     echo "✗ Statistical Calculation test failed"
@@ -38,11 +38,19 @@ echo ""
 # 测试3: 相关性计算功能
 echo "Test 3: Correlation Calculation Functionality"
 response=$(curl -s -X GET "${SERVER}/correlation?deviceId=${DEVICE_ID}")
-if [[ $response == *"1.0000"* ]]; then
+json_response=$(curl -s -X GET "${SERVER}/correlation?deviceId=${DEVICE_ID}&format=json")
+if [[ $response == *"1.0000"* ]] && [[ $response == *"Confidence Intervals (BCa"* ]] && [[ $response == *"Pair Tests:"* ]] && [[ $json_response == *"\"adjusted_p\""* ]]; then
     echo "✓ Correlation Calculation test passed"
 else
     echo "✗ Correlation Calculation test failed"
 fi
+response=$(curl -s -X GET "${SERVER}/correlation?deviceId=${DEVICE_ID}&method=all")
+error_response=$(curl -s -X GET "${SERVER}/correlation?deviceId=${DEVICE_ID}&method=distance")
+if [[ $response == *"Spearman's rho:"* ]] && [[ $response == *"Kendall's tau-b:"* ]] && [[ $response == *"Side-by-side Comparison:"* ]] && [[ $error_response == *"invalid_request"* ]]; then
+    echo "✓ Rank Correlation test passed"
+else
+    echo "✗ Rank Correlation test failed"
+fi
 echo ""
 
 # 测试4: 统计图表生成功能
@@ -58,11 +66,131 @@ echo ""
 # 测试5: 条件分析功能
 echo "Test 5: Condition Analysis Functionality"
 response=$(curl -s -X GET "${SERVER}/condition?deviceId=${DEVICE_ID}")
-if [[ $response == *"Engine Condition Analysis"* ]] && [[ $response == *"Condition 1:"* ]] && [[ $response == *"Condition 0:"* ]]; then
+if [[ $response == *"Engine Condition Analysis"* ]] && [[ $response == *"Condition 1:"* ]] && [[ $response == *"Condition 0:"* ]] && [[ $response == *"Bootstrap 95% Confidence Intervals"* ]] && [[ $response == *"Most Discriminating Attributes"* ]] && [[ $response == *"Effect sizes (0 vs 1)"* ]]; then
     echo "✓ Condition Analysis test passed"
 else
     echo "✗ Condition Analysis test failed"
 fi
+response=$(curl -s -X GET "${SERVER}/condition?deviceId=${DEVICE_ID}&format=json")
+if [[ $response == *"\"effect_sizes\""* ]] && [[ $response == *"Cohen's d"* ]]; then
+    echo "✓ Condition Analysis JSON test passed"
+else
+    echo "✗ Condition Analysis JSON test failed"
+fi
+echo ""
+
+# 测试6: 原始数据分页浏览功能
+echo "Test 6: Raw Data Paging Functionality"
+response=$(curl -s -X GET "${SERVER}/data?device=${DEVICE_ID}&limit=10")
+if [[ $response == *"\"rows\""* ]] && [[ $response == *"\"next_cursor\""* ]]; then
+    echo "✓ Raw Data Paging test passed"
+else
+    echo "✗ Raw Data Paging test failed"
+fi
+echo ""
+
+# 测试7: 设备发现功能
+echo "Test 7: Device Discovery Functionality"
+response=$(curl -s -X GET "${SERVER}/devices")
+if [[ $response == *"\"path\":\"${DEVICE_ID}\""* ]]; then
+    echo "✓ Device Discovery test passed"
+else
+    echo "✗ Device Discovery test failed"
+fi
+echo ""
+
+# 测试8: 时间序列发现功能
+echo "Test 8: Timeseries Discovery Functionality"
+response=$(curl -s -X GET "${SERVER}/devices/${DEVICE_ID}/timeseries")
+if [[ $response == *"\"data_type\""* ]] && [[ $response == *"\"row_count\""* ]]; then
+    echo "✓ Timeseries Discovery test passed"
+else
+    echo "✗ Timeseries Discovery test failed"
+fi
+echo ""
+
+# 测试9: 分组分析功能
+echo "Test 9: Group Analysis Functionality"
+response=$(curl -s -X GET "${SERVER}/group?deviceId=${DEVICE_ID}&column=engine_rpm&bin=500")
+json_response=$(curl -s -X GET "${SERVER}/group?deviceId=${DEVICE_ID}&column=engine_condition&format=json")
+if [[ $response == *"Group Analysis by engine_rpm"* ]] && [[ $response == *"Group [500, 1000):"* ]] && [[ $json_response == *"\"group\":\"1\""* ]]; then
+    echo "✓ Group Analysis test passed"
+else
+    echo "✗ Group Analysis test failed"
+fi
+echo ""
+
+# 测试10: 分布拟合功能
+echo "Test 10: Distribution Fit Functionality"
+response=$(curl -s -X GET "${SERVER}/fit?deviceId=${DEVICE_ID}")
+json_response=$(curl -s -X GET "${SERVER}/fit?deviceId=${DEVICE_ID}&format=json")
+if [[ $response == *"Best fit:"* ]] && [[ $json_response == *"\"rank_aic\":1"* ]]; then
+    echo "✓ Distribution Fit test passed"
+else
+    echo "✗ Distribution Fit test failed"
+fi
+echo ""
+
+echo "Test 11: Kernel Density Estimation Functionality"
+response=$(curl -s -X GET "${SERVER}/kde?deviceId=${DEVICE_ID}&measurement=engine_rpm&kernel=epanechnikov&grid=128")
+error_response=$(curl -s -X GET "${SERVER}/kde?deviceId=${DEVICE_ID}&measurement=engine_rpm&kernel=box")
+if [[ $response == *"\"density\":["* ]] && [[ $response == *"\"kernel\":\"epanechnikov\""* ]] && [[ $error_response == *"invalid_request"* ]]; then
+    echo "✓ KDE test passed"
+else
+    echo "✗ KDE test failed"
+fi
+echo ""
+
+echo "Test 12: Rolling Statistics Functionality"
+response=$(curl -s -X GET "${SERVER}/rolling?deviceId=${DEVICE_ID}&measurement=coolant_temp&window=500&step=250")
+json_response=$(curl -s -X GET "${SERVER}/rolling?deviceId=${DEVICE_ID}&measurement=coolant_temp&window=60s&p=5,95&format=json")
+if [[ $response == *"Rolling Statistics of coolant_temp"* ]] && [[ $json_response == *"\"p95\":["* ]]; then
+    echo "✓ Rolling Statistics test passed"
+else
+    echo "✗ Rolling Statistics test failed"
+fi
+echo ""
+
+echo "Test 13: Percentiles Functionality"
+response=$(curl -s -X GET "${SERVER}/percentiles?deviceId=${DEVICE_ID}&measurements=engine_rpm,fuel_pressure&p=1,5,95,99&groupBy=engine_condition")
+json_response=$(curl -s -X GET "${SERVER}/percentiles?deviceId=${DEVICE_ID}&measurements=engine_rpm&method=6&format=json")
+error_response=$(curl -s -X GET "${SERVER}/percentiles?deviceId=${DEVICE_ID}&method=10")
+if [[ $response == *"Hyndman-Fan type 7"* ]] && [[ $response == *"p99"* ]] && [[ $json_response == *"\"method\":6"* ]] && [[ $error_response == *"invalid_request"* ]]; then
+    echo "✓ Percentiles test passed"
+else
+    echo "✗ Percentiles test failed"
+fi
+echo ""
+
+echo "Test 14: Feature Relevance Functionality"
+response=$(curl -s -X GET "${SERVER}/relevance?deviceId=${DEVICE_ID}&target=engine_condition")
+json_response=$(curl -s -X GET "${SERVER}/relevance?deviceId=${DEVICE_ID}&target=engine_condition&format=json")
+if [[ $response == *"Feature Relevance for engine_condition"* ]] && [[ $response == *"Point-biserial r"* ]] && [[ $json_response == *"\"mi_ksg\""* ]]; then
+    echo "✓ Feature relevance test passed"
+else
+    echo "✗ Feature relevance test failed"
+fi
+echo ""
+
+echo "Test 15: Partial Correlation Functionality"
+response=$(curl -s -X GET "${SERVER}/correlation?deviceId=${DEVICE_ID}&partial=true")
+controlled_response=$(curl -s -X GET "${SERVER}/correlation?deviceId=${DEVICE_ID}&controls=engine_rpm&format=json")
+if [[ $response == *"Partial Correlation (controlling for all other columns)"* ]] && [[ $controlled_response == *"\"controls\""* ]]; then
+    echo "✓ Partial correlation test passed"
+else
+    echo "✗ Partial correlation test failed"
+fi
+echo ""
+
+echo "Test 16: Cross-correlation Functionality"
+response=$(curl -s -X GET "${SERVER}/xcorr?deviceId=${DEVICE_ID}&x=fuel_pressure&y=engine_rpm&maxLag=50")
+json_response=$(curl -s -X GET "${SERVER}/xcorr?deviceId=${DEVICE_ID}&x=fuel_pressure&y=engine_rpm&format=json")
+error_response=$(curl -s -X GET "${SERVER}/xcorr?deviceId=${DEVICE_ID}&x=fuel_pressure")
+if [[ $response == *"Peak: lag"* ]] && [[ $response == *"Generated graph"* ]] && [[ $json_response == *"\"peak_lag\""* ]] && [[ $error_response == *"invalid_request"* ]]; then
+    echo "✓ Cross-correlation test passed"
+else
+    echo "✗ Cross-correlation test failed"
+fi
 echo ""
 
 echo "API tests completed!"
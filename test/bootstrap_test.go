@@ -0,0 +1,114 @@
+package test
+
+import (
+	"math"
+	"math/rand/v2"
+	"testing"
+	"time"
+
+	"bdgp2025/src/utils/stats"
+)
+
+func TestBootstrapMeanInterval(t *testing.T) {
+	rng := rand.New(rand.NewPCG(13, 14))
+	values := make([]float64, 2000)
+	for i := range values {
+		values[i] = 50 + 10*rng.NormFloat64()
+	}
+
+	config := stats.DefaultBootstrapConfig()
+	intervals := stats.Bootstrap(len(values), int64(len(values)), []stats.Statistic{stats.MeanStatistic(values)}, config)
+	mean := intervals[0]
+	// The standard error of the mean is σ/√n ≈ 0.224, so the 95% interval is about 0.88 wide
+	if math.Abs(mean.StdError-10/math.Sqrt(2000)) > 0.03 {
+		t.Errorf("standard error = %v, expected about %v", mean.StdError, 10/math.Sqrt(2000))
+	}
+	for name, interval := range map[string]stats.Interval{"percentile": mean.Percentile, "BCa": mean.BCa} {
+		if interval.Lower > 50 || interval.Upper < 50 {
+			t.Errorf("%s interval %+v misses the true mean", name, interval)
+		}
+		if width := interval.Upper - interval.Lower; math.Abs(width-0.877) > 0.15 {
+			t.Errorf("%s interval %+v is %v wide, expected about 0.877", name, interval, width)
+		}
+	}
+
+	// Resampling four times the sample size narrows the interval by half
+	quadrupled := stats.Bootstrap(len(values), 4*int64(len(values)), []stats.Statistic{stats.MeanStatistic(values)}, config)
+	if ratio := quadrupled[0].StdError / mean.StdError; math.Abs(ratio-0.5) > 0.05 {
+		t.Errorf("standard error ratio = %v, expected about 0.5", ratio)
+	}
+
+	config.Resamples = 0
+	if intervals := stats.Bootstrap(len(values), int64(len(values)), []stats.Statistic{stats.MeanStatistic(values)}, config); intervals != nil {
+		t.Errorf("0 resamples should disable bootstrapping, got %+v", intervals)
+	}
+}
+
+func TestBootstrapCostIndependentOfRowCount(t *testing.T) {
+	rng := rand.New(rand.NewPCG(15, 16))
+	values := make([]float64, 1000)
+	for i := range values {
+		values[i] = 50 + 10*rng.NormFloat64()
+	}
+
+	// A billion rows must not mean a billion draws per resample
+	const n = 1_000_000_000
+	start := time.Now()
+	intervals := stats.Bootstrap(len(values), n, []stats.Statistic{stats.MeanStatistic(values)}, stats.DefaultBootstrapConfig())
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("bootstrapping %d rows took %v", n, elapsed)
+	}
+	if expected := 10 / math.Sqrt(n); math.Abs(intervals[0].StdError-expected) > 0.1*expected {
+		t.Errorf("standard error = %v, expected about %v", intervals[0].StdError, expected)
+	}
+}
+
+func TestBootstrapReproducibleAcrossWorkers(t *testing.T) {
+	values := []float64{3, 1, 4, 1, 5, 9, 2, 6, 5, 3, 5, 8, 9, 7, 9, 3, 2, 3, 8, 4}
+	statistics := []stats.Statistic{stats.MeanStatistic(values), stats.StdDevStatistic(values)}
+	config := stats.DefaultBootstrapConfig()
+	config.Workers = 1
+	single := stats.Bootstrap(len(values), int64(len(values)), statistics, config)
+	config.Workers = 8
+	parallel := stats.Bootstrap(len(values), int64(len(values)), statistics, config)
+	for k := range single {
+		if single[k] != parallel[k] {
+			t.Errorf("statistic %d: %+v with one worker, %+v with eight", k, single[k], parallel[k])
+		}
+	}
+}
+
+func TestBootstrapBCaFollowsSkew(t *testing.T) {
+	rng := rand.New(rand.NewPCG(15, 16))
+	values := make([]float64, 100)
+	for i := range values {
+		values[i] = math.Exp(1.5 * rng.NormFloat64())
+	}
+	mean := stats.Bootstrap(len(values), int64(len(values)), []stats.Statistic{stats.MeanStatistic(values)}, stats.DefaultBootstrapConfig())[0]
+	// The mean of a log-normal sample has a right-skewed sampling distribution
+	if mean.BCa.Upper <= mean.Percentile.Upper {
+		t.Errorf("BCa %+v should lie right of percentile %+v", mean.BCa, mean.Percentile)
+	}
+}
+
+func TestWeightedStatistics(t *testing.T) {
+	sorted := []float64{1, 2, 3, 4}
+	median := stats.MedianStatistic(sorted)
+	checks := []struct {
+		name          string
+		got, expected float64
+	}{
+		{"median of 1..4", median([]float64{1, 1, 1, 1}), 2.5},
+		{"median of 3", median([]float64{0, 0, 1, 0}), 3},
+		{"median of 1,1,4", median([]float64{2, 0, 0, 1}), 1},
+		{"mean of 1,1,4", stats.MeanStatistic(sorted)([]float64{2, 0, 0, 1}), 2},
+		{"std dev of 1,1,4", stats.StdDevStatistic(sorted)([]float64{2, 0, 0, 1}), math.Sqrt(3)},
+		{"correlation skipping NaN", stats.CorrelationStatistic([]float64{1, 2, 3, math.NaN()}, []float64{2, 4, 6, 0})([]float64{1, 1, 1, 1}), 1},
+		{"NormalQuantile(0.975)", stats.NormalQuantile(0.975), 1.959963984540054},
+	}
+	for _, c := range checks {
+		if math.Abs(c.got-c.expected) > 1e-9 {
+			t.Errorf("%s = %v, expected %v", c.name, c.got, c.expected)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"bdgp2025/src/utils"
+)
+
+func writeConfigFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "iotdb.json")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestConfigFileExplicitZero(t *testing.T) {
+	// An explicit 0 turns retries and bootstrap intervals off instead of falling back to the default
+	config, err := utils.LoadIoTDBConfigFile(writeConfigFile(t, `{"host": "10.0.0.1", "max_retries": 0, "bootstrap_resamples": 0}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.MaxRetries.Value != "0" || config.MaxRetries.Source != utils.FileValue {
+		t.Errorf("max_retries = %s (%s), expected 0 (file)", config.MaxRetries.Value, config.MaxRetries.Source)
+	}
+	if config.BootstrapResamples.Value != "0" || config.BootstrapResamples.Source != utils.FileValue {
+		t.Errorf("bootstrap_resamples = %s (%s), expected 0 (file)", config.BootstrapResamples.Value, config.BootstrapResamples.Source)
+	}
+	if config.Host.Value != "10.0.0.1" || config.Port.Source != utils.DefaultValue {
+		t.Errorf("host = %s, port source = %s, expected 10.0.0.1 and default", config.Host.Value, config.Port.Source)
+	}
+
+	// Left out of the file, the default applies
+	config, err = utils.LoadIoTDBConfigFile(writeConfigFile(t, `{"max_retries": 5, "bootstrap_resamples": 200}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resolved := config.ToIoTDBConfig(); resolved.MaxRetries != 5 || resolved.BootstrapResamples != 200 {
+		t.Errorf("max_retries = %d, bootstrap_resamples = %d, expected 5 and 200", resolved.MaxRetries, resolved.BootstrapResamples)
+	}
+	config, err = utils.LoadIoTDBConfigFile(writeConfigFile(t, `{}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.MaxRetries.Value != "3" || config.MaxRetries.Source != utils.DefaultValue {
+		t.Errorf("absent max_retries = %s (%s), expected 3 (default)", config.MaxRetries.Value, config.MaxRetries.Source)
+	}
+	if config.BootstrapResamples.Value != "1000" || config.BootstrapResamples.Source != utils.DefaultValue {
+		t.Errorf("absent bootstrap_resamples = %s (%s), expected 1000 (default)", config.BootstrapResamples.Value, config.BootstrapResamples.Source)
+	}
+}
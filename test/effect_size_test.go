@@ -0,0 +1,81 @@
+package test
+
+import (
+	"math"
+	"testing"
+
+	"bdgp2025/src/utils/stats"
+)
+
+func TestCohensD(t *testing.T) {
+	a := stats.GroupSummary{Count: 10, Mean: 5, Variance: 4}
+	b := stats.GroupSummary{Count: 10, Mean: 3, Variance: 4}
+	if d, ok := stats.CohensD(a, b); !ok || !closeTo(d, 1) {
+		t.Errorf("CohensD = %v, expected 1", d)
+	}
+	if _, ok := stats.CohensD(stats.GroupSummary{Count: 5, Mean: 1}, stats.GroupSummary{Count: 5, Mean: 2}); ok {
+		t.Error("CohensD of two constant groups should be undefined")
+	}
+
+	// Hedges' g shrinks d by 1 - 3 / (4 * 20 - 9)
+	sizes := stats.EffectSizes(a, b, []float64{4, 5, 6}, []float64{2, 3, 4})
+	expected := map[string]struct {
+		value     float64
+		magnitude string
+	}{
+		"Cohen's d":      {1, stats.EffectLarge},
+		"Hedges' g":      {1 - 3.0/71, stats.EffectLarge},
+		"Cliff's delta":  {8.0 / 9, stats.EffectLarge},
+		"variance ratio": {1, stats.EffectNegligible},
+	}
+	if len(sizes) != len(expected) {
+		t.Fatalf("EffectSizes = %+v, expected %d sizes", sizes, len(expected))
+	}
+	for _, size := range sizes {
+		e, found := expected[size.Name]
+		if !found || math.Abs(size.Value-e.value) > 1e-9 || size.Magnitude != e.magnitude {
+			t.Errorf("%s = %v (%s), expected %v (%s)", size.Name, size.Value, size.Magnitude, e.value, e.magnitude)
+		}
+	}
+}
+
+func TestCliffsDelta(t *testing.T) {
+	checks := []struct {
+		x, y     []float64
+		expected float64
+	}{
+		{[]float64{1, 2, 3}, []float64{4, 5, 6}, -1},
+		{[]float64{4, 5, 6}, []float64{1, 2, 3}, 1},
+		{[]float64{2, 2}, []float64{2, 2, 2}, 0},
+		{[]float64{1, 3}, []float64{2, 2}, 0},
+	}
+	for _, c := range checks {
+		if delta, ok := stats.CliffsDelta(c.x, c.y); !ok || delta != c.expected {
+			t.Errorf("CliffsDelta(%v, %v) = %v, expected %v", c.x, c.y, delta, c.expected)
+		}
+	}
+	if _, ok := stats.CliffsDelta(nil, []float64{1}); ok {
+		t.Error("CliffsDelta of an empty group should be undefined")
+	}
+}
+
+func TestEffectSizeMagnitudes(t *testing.T) {
+	// Variances 1 and 1.69 give d = 0.3 after pooling and a variance ratio of 1.69
+	a := stats.GroupSummary{Count: 1001, Mean: 0.39, Variance: 1.69}
+	b := stats.GroupSummary{Count: 1001, Mean: 0, Variance: 1}
+	magnitudes := make(map[string]string)
+	for _, size := range stats.EffectSizes(a, b, []float64{0, 1, 2, 3}, []float64{-0.5, 1, 1.5, 2.5}) {
+		magnitudes[size.Name] = size.Magnitude
+	}
+	expected := map[string]string{
+		"Cohen's d":      stats.EffectSmall,
+		"Hedges' g":      stats.EffectSmall,
+		"Cliff's delta":  stats.EffectSmall,
+		"variance ratio": stats.EffectMedium,
+	}
+	for name, magnitude := range expected {
+		if magnitudes[name] != magnitude {
+			t.Errorf("%s magnitude = %q, expected %q", name, magnitudes[name], magnitude)
+		}
+	}
+}
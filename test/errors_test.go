@@ -0,0 +1,59 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"bdgp2025/src/db_interface"
+
+	"github.com/apache/iotdb-client-go/v2/client"
+	"github.com/apache/iotdb-client-go/v2/common"
+)
+
+func TestCheckErrorClassification(t *testing.T) {
+	message := "Path [root.nowhere] does not exist"
+	err := db_interface.CheckError(&common.TSStatus{Code: 508, Message: &message}, nil)
+	var statusErr *db_interface.StatusError
+	if !errors.As(err, &statusErr) || statusErr.Code != 508 {
+		t.Fatalf("expected a StatusError with code 508, got %v", err)
+	}
+	if !errors.Is(err, db_interface.ErrDeviceNotFound) {
+		t.Errorf("status 508 should match ErrDeviceNotFound, got %v", err)
+	}
+
+	parseMessage := "line 1:7 mismatched input"
+	if err := db_interface.CheckError(&common.TSStatus{Code: 700, Message: &parseMessage}, nil); !errors.Is(err, db_interface.ErrInvalidQuery) {
+		t.Errorf("status 700 should match ErrInvalidQuery, got %v", err)
+	}
+
+	if err := db_interface.CheckError(&common.TSStatus{Code: 200}, nil); err != nil {
+		t.Errorf("a success status should not produce an error, got %v", err)
+	}
+
+	if err := db_interface.CheckError(nil, io.EOF); !errors.Is(err, db_interface.ErrConnectionLost) {
+		t.Errorf("EOF from the transport should match ErrConnectionLost, got %v", err)
+	}
+
+	if _, err := (db_interface.SelectQuery{Device: "root.sg.dev;drop"}).Build(); !errors.Is(err, db_interface.ErrInvalidQuery) {
+		t.Errorf("builder validation failures should match ErrInvalidQuery, got %v", err)
+	}
+}
+
+func TestDoneContextStopsBeforeQuerying(t *testing.T) {
+	// The session is never opened, so any attempt to reach IoTDB would fail differently
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := db_interface.GetStatisticsResult(ctx, client.Session{}, "root.example.exampledev", nil, 1000, db_interface.ScanOptions{})
+	if !errors.Is(err, db_interface.ErrCanceled) {
+		t.Errorf("a cancelled context should yield ErrCanceled, got %v", err)
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), 0)
+	defer cancel()
+	_, err = db_interface.GetRawDataPage(ctx, client.Session{}, "root.example.exampledev", 0, 0, 10, "", 1000)
+	if !errors.Is(err, db_interface.ErrTimeout) {
+		t.Errorf("an expired deadline should yield ErrTimeout, got %v", err)
+	}
+}
@@ -0,0 +1,77 @@
+package test
+
+import (
+	"testing"
+
+	"bdgp2025/src/utils/filter"
+)
+
+func TestFilterParseSQL(t *testing.T) {
+	cases := []struct {
+		input    string
+		expected string
+	}{
+		{"engine_rpm > 1000", "engine_rpm > 1000"},
+		{"engine_rpm > 1000 and coolant_temp < 90", "(engine_rpm > 1000 and coolant_temp < 90)"},
+		{"a = 1 or b == 2 and c <> 3", "(a = 1 or (b = 2 and c != 3))"},
+		{"not (engine_condition = 1)", "not (engine_condition = 1)"},
+		{"fault_code = 'it''s'", "fault_code = 'it''s'"},
+		{"flag != true && x >= -1.5e2", "(flag != true and x >= -150)"},
+	}
+
+	for _, c := range cases {
+		expr, err := filter.Parse(c.input)
+		if err != nil {
+			t.Fatalf("Parse(%q) failed: %v", c.input, err)
+		}
+		if got := expr.SQL(); got != c.expected {
+			t.Errorf("Parse(%q).SQL() = %q, expected %q", c.input, got, c.expected)
+		}
+	}
+}
+
+func TestFilterRejectsInjection(t *testing.T) {
+	inputs := []string{
+		"engine_rpm > 1; delete from root.example.exampledev",
+		"engine_rpm > 1 -- comment",
+		"root.example.exampledev.engine_rpm > 1",
+		"engine_rpm > (select 1)",
+		"engine_rpm",
+		"and > 1",
+		"x > 'unterminated",
+	}
+
+	for _, input := range inputs {
+		if _, err := filter.Parse(input); err == nil {
+			t.Errorf("Parse(%q) should have failed", input)
+		}
+	}
+}
+
+func TestFilterEval(t *testing.T) {
+	expr, err := filter.Parse("engine_rpm > 1000 and (coolant_temp < 90 or engine_condition = 0)")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rows := []struct {
+		row      map[string]interface{}
+		expected bool
+	}{
+		{map[string]interface{}{"engine_rpm": int64(1200), "coolant_temp": 80.0, "engine_condition": int64(1)}, true},
+		{map[string]interface{}{"engine_rpm": int64(1200), "coolant_temp": 95.0, "engine_condition": int64(0)}, true},
+		{map[string]interface{}{"engine_rpm": int64(1200), "coolant_temp": 95.0, "engine_condition": int64(1)}, false},
+		{map[string]interface{}{"engine_rpm": int64(900), "coolant_temp": 80.0, "engine_condition": int64(0)}, false},
+		{map[string]interface{}{"coolant_temp": 80.0}, false},
+	}
+
+	for i, r := range rows {
+		if got := expr.Eval(r.row); got != r.expected {
+			t.Errorf("row %d: Eval() = %v, expected %v", i, got, r.expected)
+		}
+	}
+
+	if empty, err := filter.Parse("  "); err != nil || empty != nil || !empty.Eval(nil) {
+		t.Errorf("an empty filter should parse to nil and accept every row")
+	}
+}
@@ -0,0 +1,92 @@
+package test
+
+import (
+	"math"
+	"math/rand/v2"
+	"testing"
+
+	"bdgp2025/src/utils/stats"
+)
+
+func TestSpecialFunctions(t *testing.T) {
+	checks := []struct {
+		name          string
+		got, expected float64
+	}{
+		{"Digamma(1)", stats.Digamma(1), -0.5772156649015329},
+		{"Digamma(0.5)", stats.Digamma(0.5), -1.9635100260214235},
+		{"Trigamma(1)", stats.Trigamma(1), math.Pi * math.Pi / 6},
+		{"P(1, 2)", stats.RegularizedGammaP(1, 2), 1 - math.Exp(-2)},
+		{"P(3, 10)", stats.RegularizedGammaP(3, 10), 1 - math.Exp(-10)*(1+10+50)},
+		{"I_0.3(1, 1)", stats.RegularizedBeta(0.3, 1, 1), 0.3},
+		{"I_0.5(2, 2)", stats.RegularizedBeta(0.5, 2, 2), 0.5},
+		{"I_0.2(2, 3)", stats.RegularizedBeta(0.2, 2, 3), 0.1808},
+	}
+	for _, c := range checks {
+		if math.Abs(c.got-c.expected) > 1e-10 {
+			t.Errorf("%s = %.15g, expected %.15g", c.name, c.got, c.expected)
+		}
+	}
+}
+
+func parameter(t *testing.T, fits []stats.Fit, distribution string, name string) float64 {
+	for _, fit := range fits {
+		if fit.Distribution != distribution {
+			continue
+		}
+		for _, p := range fit.Parameters {
+			if p.Name == name {
+				return p.Value
+			}
+		}
+	}
+	t.Fatalf("no %s parameter %s in %+v", distribution, name, fits)
+	return 0
+}
+
+func TestFitDistributionsRecoversParameters(t *testing.T) {
+	rng := rand.New(rand.NewPCG(7, 8))
+	const n = 20000
+	samples := map[string][]float64{}
+	for i := 0; i < n; i++ {
+		samples["normal"] = append(samples["normal"], 50+10*rng.NormFloat64())
+		samples["log-normal"] = append(samples["log-normal"], math.Exp(1+0.5*rng.NormFloat64()))
+		// Gamma with shape 3 and scale 2 as a sum of exponentials
+		samples["gamma"] = append(samples["gamma"], 2*(rng.ExpFloat64()+rng.ExpFloat64()+rng.ExpFloat64()))
+		// Weibull with shape 1.5 and scale 4 by inversion
+		samples["weibull"] = append(samples["weibull"], 4*math.Pow(rng.ExpFloat64(), 1/1.5))
+	}
+
+	expected := []struct {
+		distribution, parameter string
+		value                   float64
+	}{
+		{"normal", "mean", 50}, {"normal", "std_dev", 10},
+		{"log-normal", "mu", 1}, {"log-normal", "sigma", 0.5},
+		{"gamma", "shape", 3}, {"gamma", "scale", 2},
+		{"weibull", "shape", 1.5}, {"weibull", "scale", 4},
+	}
+	for _, e := range expected {
+		fits := stats.FitDistributions(samples[e.distribution])
+		if got := parameter(t, fits, e.distribution, e.parameter); math.Abs(got-e.value) > 0.03*e.value {
+			t.Errorf("%s %s = %v, expected about %v", e.distribution, e.parameter, got, e.value)
+		}
+	}
+
+	// Distributions with a single mode and no bounds should not lose to the four-parameter beta
+	for _, distribution := range []string{"log-normal", "gamma", "weibull"} {
+		fits := stats.FitDistributions(samples[distribution])
+		if fits[0].Distribution != distribution || fits[0].RankAIC != 1 {
+			t.Errorf("best AIC for a %s sample is %s", distribution, fits[0].Distribution)
+		}
+		for _, fit := range fits {
+			if fit.Distribution == distribution && fit.KS > 0.02 {
+				t.Errorf("%s fit of its own sample has KS distance %v", distribution, fit.KS)
+			}
+		}
+	}
+
+	if fits := stats.FitDistributions([]float64{-1, 0, 1, 2, 3}); len(fits) != 2 {
+		t.Errorf("a sample with negative values should only fit normal and beta, got %d fits", len(fits))
+	}
+}
@@ -2,8 +2,10 @@ package test
 
 import (
 	"bdgp2025/src/db_interface"
+	"context"
 	"fmt"
 	"log"
+	"reflect"
 	"testing"
 
 	"github.com/apache/iotdb-client-go/v2/client"
@@ -35,7 +37,7 @@ func TestAlwaysPasses(t *testing.T) {
 	} else {
 		log.Fatal(err)
 	}
-	result, err := db_interface.GetStatisticsResult(session, deviceId, timeout)
+	result, err := db_interface.GetStatisticsResult(context.Background(), session, deviceId, nil, timeout, db_interface.ScanOptions{})
 	if err != nil {
 		log.Fatal(err)
 		t.Fail()
@@ -43,3 +45,16 @@ func TestAlwaysPasses(t *testing.T) {
 	fmt.Print(result)
 
 }
+
+func TestNumericColumnIndicesSkipsText(t *testing.T) {
+	// Correlation, statistics and graphs only read these columns, so a TEXT measurement
+	// must not reach fetchNullableData and fail the whole request
+	columnTypes := []string{"INT64", "DOUBLE", "TEXT", "BOOLEAN", "STRING", "INT32", "FLOAT"} // Index 0 is Time
+	expected := []int{1, 3, 5, 6}
+	if got := db_interface.NumericColumnIndices(columnTypes); !reflect.DeepEqual(got, expected) {
+		t.Errorf("NumericColumnIndices(%v) = %v, expected %v", columnTypes, got, expected)
+	}
+	if got := db_interface.NumericColumnIndices([]string{"INT64", "TEXT"}); len(got) != 0 {
+		t.Errorf("a device with only a TEXT measurement has numeric columns %v, expected none", got)
+	}
+}
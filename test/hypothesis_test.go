@@ -0,0 +1,70 @@
+package test
+
+import (
+	"math"
+	"testing"
+
+	"bdgp2025/src/utils/stats"
+)
+
+func TestTailProbabilities(t *testing.T) {
+	checks := []struct {
+		name          string
+		got, expected float64
+	}{
+		{"t(10) at 2.228139", stats.StudentTTwoSided(2.228139, 10), 0.05},
+		{"F(2, 10) at 4.102821", stats.FSurvival(4.102821, 2, 10), 0.05},
+		{"F(2, 6) at 27", stats.FSurvival(27, 2, 6), 0.001},
+		{"chi2(1) at 3.841459", stats.ChiSquareSurvival(3.841459, 1), 0.05},
+		{"chi2(5) at 11.0705", stats.ChiSquareSurvival(11.0705, 5), 0.05},
+		{"chi2(2) at 7.2", stats.ChiSquareSurvival(7.2, 2), math.Exp(-3.6)},
+		{"Kolmogorov at 1.358099", stats.KolmogorovSurvival(1.358099), 0.05},
+	}
+	for _, c := range checks {
+		if math.Abs(c.got-c.expected) > 1e-5 {
+			t.Errorf("%s = %.8g, expected %.8g", c.name, c.got, c.expected)
+		}
+	}
+	// The upper tail stays accurate where 1 - P(a, x) would round to 0
+	if q := stats.ChiSquareSurvival(200, 2); math.Abs(q/math.Exp(-100)-1) > 1e-9 {
+		t.Errorf("chi2(2) at 200 = %v, expected %v", q, math.Exp(-100))
+	}
+}
+
+func TestTwoSampleTests(t *testing.T) {
+	x, y := []float64{1, 2, 3}, []float64{4, 5, 6}
+	if test, ok := stats.MannWhitneyU(x, y); !ok || test.Statistic != 0 || math.Abs(test.PValue-0.0808556) > 1e-6 {
+		t.Errorf("MannWhitneyU = %+v, expected U 0 and p 0.0808556", test)
+	}
+	if test, ok := stats.KolmogorovSmirnov2([]float64{1, 2, 3, 4}, []float64{3, 4, 5, 6}); !ok || test.Statistic != 0.5 {
+		t.Errorf("KolmogorovSmirnov2 = %+v, expected D 0.5", test)
+	}
+	if test, ok := stats.KruskalWallis([][]float64{{1, 2, 3}, {4, 5, 6}, {7, 8, 9}}); !ok || !closeTo(test.Statistic, 7.2) || !closeTo(test.PValue, math.Exp(-3.6)) {
+		t.Errorf("KruskalWallis = %+v, expected H 7.2 and p exp(-3.6)", test)
+	}
+
+	groups := []stats.GroupSummary{{Count: 3, Mean: 2, Variance: 1}, {Count: 3, Mean: 5, Variance: 1}, {Count: 3, Mean: 8, Variance: 1}}
+	if test, ok := stats.OneWayANOVA(groups); !ok || !closeTo(test.Statistic, 27) || !closeTo(test.PValue, 0.001) {
+		t.Errorf("OneWayANOVA = %+v, expected F 27 and p 0.001", test)
+	}
+	// t = 2 / √(4/10 + 9/12), df by Welch-Satterthwaite
+	test, ok := stats.WelchTTest(stats.GroupSummary{Count: 10, Mean: 5, Variance: 4}, stats.GroupSummary{Count: 12, Mean: 3, Variance: 9})
+	df := 1.15 * 1.15 / (0.4*0.4/9 + 0.75*0.75/11)
+	if !ok || !closeTo(test.Statistic, 2/math.Sqrt(1.15)) || !closeTo(test.PValue, stats.StudentTTwoSided(2/math.Sqrt(1.15), df)) {
+		t.Errorf("WelchTTest = %+v", test)
+	}
+	if _, ok := stats.WelchTTest(stats.GroupSummary{Count: 1, Mean: 5}, groups[0]); ok {
+		t.Error("WelchTTest needs two values per group")
+	}
+}
+
+func TestBenjaminiHochberg(t *testing.T) {
+	adjusted := stats.BenjaminiHochberg([]float64{0.01, 0.04, 0.03, 0.005})
+	expected := []float64{0.02, 0.04, 0.04, 0.02}
+	for i := range expected {
+		if !closeTo(adjusted[i], expected[i]) {
+			t.Errorf("adjusted = %v, expected %v", adjusted, expected)
+			break
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package test
+
+import (
+	"math"
+	"math/rand/v2"
+	"testing"
+
+	"bdgp2025/src/utils/kde"
+)
+
+func integrate(result *kde.Result) float64 {
+	step := result.X[1] - result.X[0]
+	total := 0.0
+	for _, d := range result.Density {
+		total += d * step
+	}
+	return total
+}
+
+func TestKDEIntegratesToOne(t *testing.T) {
+	rng := rand.New(rand.NewPCG(9, 10))
+	values := make([]float64, 2000)
+	for i := range values {
+		values[i] = 20 + 3*rng.NormFloat64()
+	}
+
+	for _, kernel := range []string{kde.KernelGaussian, kde.KernelEpanechnikov} {
+		config := kde.DefaultConfig()
+		config.Kernel = kernel
+		result, err := kde.Estimate(values, config)
+		if err != nil {
+			t.Fatalf("%s: %v", kernel, err)
+		}
+		if total := integrate(result); math.Abs(total-1) > 0.01 {
+			t.Errorf("%s density integrates to %v", kernel, total)
+		}
+		// The density of N(20, 3²) peaks at 1/(3√(2π)) ≈ 0.133
+		if peak := result.PDF(20); math.Abs(peak-0.133) > 0.015 {
+			t.Errorf("%s density at the mean is %v, expected about 0.133", kernel, peak)
+		}
+	}
+}
+
+func TestKDEBinnedMatchesExact(t *testing.T) {
+	rng := rand.New(rand.NewPCG(11, 12))
+	values := make([]float64, 20000)
+	for i := range values {
+		values[i] = rng.ExpFloat64()
+	}
+
+	exactConfig := kde.DefaultConfig()
+	exactConfig.BinnedThreshold = len(values)
+	exact, err := kde.Estimate(values, exactConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	binned, err := kde.Estimate(values, kde.DefaultConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exact.Binned || !binned.Binned {
+		t.Fatalf("Binned = %v/%v, expected false/true", exact.Binned, binned.Binned)
+	}
+	for j := range exact.Density {
+		if math.Abs(exact.Density[j]-binned.Density[j]) > 0.01 {
+			t.Fatalf("density at %v differs: exact %v, binned %v", exact.X[j], exact.Density[j], binned.Density[j])
+		}
+	}
+}
+
+func TestKDEBandwidth(t *testing.T) {
+	values := []float64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+	config := kde.DefaultConfig()
+	result, err := kde.Estimate(values, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// σ = 3.0277, IQR = 4.5 → 0.9 · min(3.0277, 3.3582) · 10^(-1/5)
+	stdDev := math.Sqrt(82.5 / 9)
+	if expected := 0.9 * stdDev * math.Pow(10, -0.2); !closeTo(result.Bandwidth, expected) {
+		t.Errorf("Silverman bandwidth = %v, expected %v", result.Bandwidth, expected)
+	}
+
+	if err := config.SetBandwidth("0.5"); err != nil {
+		t.Fatal(err)
+	}
+	if result, _ := kde.Estimate(values, config); result.Bandwidth != 0.5 {
+		t.Errorf("fixed bandwidth = %v, expected 0.5", result.Bandwidth)
+	}
+	for _, bad := range []string{"-1", "0", "wide"} {
+		if err := config.SetBandwidth(bad); err == nil {
+			t.Errorf("SetBandwidth(%q) should fail", bad)
+		}
+	}
+
+	config.Kernel = "box"
+	if err := config.Validate(); err == nil {
+		t.Error("an unknown kernel should not validate")
+	}
+	if _, err := kde.Estimate([]float64{4, 4, 4}, kde.DefaultConfig()); err != kde.ErrTooFewValues {
+		t.Errorf("constant values gave %v, expected ErrTooFewValues", err)
+	}
+}
@@ -0,0 +1,41 @@
+package test
+
+import (
+	"math"
+	"math/rand/v2"
+	"testing"
+
+	"bdgp2025/src/utils/stats"
+)
+
+func TestMutualInformation(t *testing.T) {
+	// Two equally likely classes with unit-variance normals 2 apart share 0.4859 bits
+	rng := rand.New(rand.NewPCG(7, 11))
+	values := make([]float64, 4000)
+	labels := make([]int, len(values))
+	for i := range values {
+		labels[i] = i % 2
+		values[i] = rng.NormFloat64() + 2*float64(labels[i])
+	}
+	if mi := stats.KSGMutualInformation(values, labels, 3); math.Abs(mi-0.4859) > 0.03 {
+		t.Errorf("KSG mutual information = %v, expected about 0.4859", mi)
+	}
+	if mi := stats.BinnedMutualInformation(values, labels, 2, 16); math.Abs(mi-0.4859) > 0.05 {
+		t.Errorf("binned mutual information = %v, expected about 0.4859", mi)
+	}
+
+	// Independent labels carry no information
+	for i := range values {
+		values[i] = rng.NormFloat64()
+	}
+	if mi := stats.KSGMutualInformation(values, labels, 3); mi > 0.02 {
+		t.Errorf("KSG mutual information of independent data = %v, expected about 0", mi)
+	}
+
+	// Perfectly separated classes share the full bit of the label
+	separated := []float64{0, 0.1, 0.2, 0.3, 9.7, 9.8, 9.9, 10}
+	classes := []int{0, 0, 0, 0, 1, 1, 1, 1}
+	if mi := stats.BinnedMutualInformation(separated, classes, 2, 4); !closeTo(mi, 1) {
+		t.Errorf("binned mutual information of separated classes = %v, expected 1", mi)
+	}
+}
@@ -0,0 +1,99 @@
+package test
+
+import (
+	"math"
+	"math/rand/v2"
+	"sort"
+	"strings"
+	"testing"
+
+	"bdgp2025/src/db_interface"
+	"bdgp2025/src/utils/sketch"
+	"bdgp2025/src/utils/stats"
+)
+
+func normalityOf(values []float64) stats.NormalityReport {
+	acc := db_interface.NewColumnAccumulator(sketch.DefaultConfig())
+	for _, v := range values {
+		acc.Add(v)
+	}
+	return acc.Normality()
+}
+
+func TestNormalityTests(t *testing.T) {
+	rng := rand.New(rand.NewPCG(5, 6))
+	normal := make([]float64, 3000)
+	exponential := make([]float64, 3000)
+	for i := range normal {
+		normal[i] = 50 + 10*rng.NormFloat64()
+		exponential[i] = rng.ExpFloat64()
+	}
+
+	report := normalityOf(normal)
+	if len(report.Tests) != 4 {
+		t.Fatalf("expected 4 tests, got %+v", report.Tests)
+	}
+	for _, test := range report.Tests {
+		if test.PValue < 0.01 {
+			t.Errorf("%s rejects a normal sample: statistic %v, p %v", test.Name, test.Statistic, test.PValue)
+		}
+	}
+	if !strings.HasPrefix(report.Verdict, "consistent with a normal") {
+		t.Errorf("normal sample got verdict %q", report.Verdict)
+	}
+
+	report = normalityOf(exponential)
+	for _, test := range report.Tests {
+		if test.PValue > 1e-6 {
+			t.Errorf("%s accepts an exponential sample: statistic %v, p %v", test.Name, test.Statistic, test.PValue)
+		}
+	}
+	if !strings.HasPrefix(report.Verdict, "not normal, right-skewed") {
+		t.Errorf("exponential sample got verdict %q", report.Verdict)
+	}
+
+	if report := normalityOf([]float64{3, 3, 3, 3, 3, 3, 3, 3, 3}); len(report.Tests) != 0 {
+		t.Errorf("a constant column should not be tested, got %+v", report.Tests)
+	}
+}
+
+func TestJarqueBera(t *testing.T) {
+	// JB = n/6 * (S² + K²/4) = 600/6 * (0.25 + 1/4) = 50, p = exp(-25)
+	test, ok := stats.JarqueBera(600, 0.5, 1)
+	if !ok || !closeTo(test.Statistic, 50) || math.Abs(test.PValue-math.Exp(-25)) > 1e-20 {
+		t.Errorf("JarqueBera = %+v, expected statistic 50 and p exp(-25)", test)
+	}
+}
+
+func TestReservoirMerge(t *testing.T) {
+	const n, size = 100_000, 2000
+	left, right := sketch.NewReservoir[float64](size), sketch.NewReservoir[float64](size)
+	for i := 0; i < n; i++ {
+		if i < n/4 {
+			left.Add(float64(i))
+		} else {
+			right.Add(float64(i))
+		}
+	}
+	left.Merge(right)
+	if left.Count() != n || len(left.Values()) != size {
+		t.Fatalf("Count/len = %d/%d, expected %d/%d", left.Count(), len(left.Values()), n, size)
+	}
+
+	// A quarter of the merged sample should come from the left stream
+	values := append([]float64(nil), left.Values()...)
+	sort.Float64s(values)
+	fromLeft := sort.SearchFloat64s(values, n/4)
+	if expected := size / 4; math.Abs(float64(fromLeft-expected)) > 4*math.Sqrt(size*0.25*0.75) {
+		t.Errorf("%d of %d sampled values come from the left stream, expected about %d", fromLeft, size, expected)
+	}
+
+	small := sketch.NewReservoir[float64](size)
+	small.Add(1)
+	other := sketch.NewReservoir[float64](size)
+	other.Add(2)
+	small.Merge(other)
+	if len(small.Values()) != 2 {
+		t.Errorf("a reservoir below its size should keep every value, got %v", small.Values())
+	}
+}
@@ -0,0 +1,72 @@
+package test
+
+import (
+	"math"
+	"testing"
+
+	"bdgp2025/src/utils/stats"
+)
+
+func TestInvertMatrix(t *testing.T) {
+	a := [][]float64{{4, 2, 0.6}, {2, 3, 0.4}, {0.6, 0.4, 2}}
+	inverse, ok := stats.InvertMatrix(a)
+	if !ok {
+		t.Fatal("InvertMatrix reported a regular matrix as singular")
+	}
+	for i := range a {
+		for j := range a {
+			product := 0.0
+			for k := range a {
+				product += a[i][k] * inverse[k][j]
+			}
+			expected := 0.0
+			if i == j {
+				expected = 1
+			}
+			if math.Abs(product-expected) > 1e-12 {
+				t.Errorf("(A A⁻¹)[%d][%d] = %v, expected %v", i, j, product, expected)
+			}
+		}
+	}
+
+	if _, ok := stats.InvertMatrix([][]float64{{1, 2}, {2, 4}}); ok {
+		t.Error("InvertMatrix should report a singular matrix")
+	}
+}
+
+func TestPartialCorrelation(t *testing.T) {
+	// x and y both follow z; r_xy.z = (r_xy - r_xz r_yz) / √((1 - r_xz²)(1 - r_yz²))
+	rxy, rxz, ryz := 0.5, 0.8, 0.9 // Positively correlated, negatively once z is held fixed
+	expected := (rxy - rxz*ryz) / math.Sqrt((1-rxz*rxz)*(1-ryz*ryz))
+	sx, sy, sz := 2.0, 0.5, 3.0 // Partial correlation does not depend on the scale
+	cov := [][]float64{
+		{sx * sx, rxy * sx * sy, rxz * sx * sz},
+		{rxy * sx * sy, sy * sy, ryz * sy * sz},
+		{rxz * sx * sz, ryz * sy * sz, sz * sz},
+	}
+
+	partial, ok := stats.PartialCorrelation(cov)
+	if !ok {
+		t.Fatal("PartialCorrelation reported a regular matrix as singular")
+	}
+	if !closeTo(partial[0][1], expected) || !closeTo(partial[1][0], expected) {
+		t.Errorf("partial r_xy.z = %v, expected %v", partial[0][1], expected)
+	}
+
+	given, ok := stats.PartialCorrelationGiven(cov, []int{2})
+	if !ok {
+		t.Fatal("PartialCorrelationGiven reported a regular control as singular")
+	}
+	if !closeTo(given[0][1], expected) {
+		t.Errorf("r_xy given z = %v, expected %v", given[0][1], expected)
+	}
+	if given[0][2] != 0 || given[2][2] != 1 {
+		t.Errorf("control row = %v, expected zeros with 1 on the diagonal", given[2])
+	}
+
+	// Without controls the residual covariance is the covariance itself
+	plain, _ := stats.PartialCorrelationGiven(cov, nil)
+	if !closeTo(plain[0][1], rxy) || !closeTo(plain[1][2], ryz) {
+		t.Errorf("unconditioned correlations = %v, %v, expected %v, %v", plain[0][1], plain[1][2], rxy, ryz)
+	}
+}
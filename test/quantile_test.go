@@ -0,0 +1,67 @@
+package test
+
+import (
+	"math"
+	"testing"
+
+	"bdgp2025/src/utils/stats"
+)
+
+func TestSampleQuantileMethods(t *testing.T) {
+	x := []float64{10, 20, 30, 40, 50}
+	checks := []struct {
+		method   stats.QuantileMethod
+		q        float64
+		expected float64
+	}{
+		// Type 7 interpolates at (n - 1)q + 1, like numpy's default
+		{7, 0.1, 14}, {7, 0.25, 20}, {7, 0.95, 48}, {7, 0, 10}, {7, 1, 50},
+		// Type 6 interpolates at (n + 1)q and clamps outside the sample
+		{6, 0.1, 10}, {6, 0.25, 15}, {6, 0.5, 30}, {6, 0.95, 50},
+		// Type 1 inverts the empirical CDF
+		{1, 0.2, 10}, {1, 0.25, 20}, {1, 1, 50}, {1, 0, 10},
+		{2, 0.2, 15}, {2, 0.5, 30},
+		{3, 0.5, 20}, {3, 0.3, 20},
+		{5, 0.5, 30}, {5, 0.25, 17.5},
+	}
+	for _, c := range checks {
+		if got := stats.SampleQuantile(x, c.q, c.method); math.Abs(got-c.expected) > 1e-9 {
+			t.Errorf("type %d at %v = %v, expected %v", c.method, c.q, got, c.expected)
+		}
+	}
+
+	// 0.07 · 100 rounds to 7.000000000000001, which must still select the 7th value
+	values := make([]float64, 100)
+	for i := range values {
+		values[i] = float64(i + 1)
+	}
+	if got := stats.SampleQuantile(values, 0.07, 1); got != 7 {
+		t.Errorf("type 1 at 0.07 of 1..100 = %v, expected 7", got)
+	}
+	if !math.IsNaN(stats.SampleQuantile(nil, 0.5, 7)) {
+		t.Error("the quantile of no values should be NaN")
+	}
+}
+
+func TestParseQuantileOptions(t *testing.T) {
+	if method, err := stats.ParseQuantileMethod("6"); err != nil || method != 6 {
+		t.Errorf("ParseQuantileMethod(6) = %v, %v", method, err)
+	}
+	for _, bad := range []string{"0", "10", "linear"} {
+		if _, err := stats.ParseQuantileMethod(bad); err == nil {
+			t.Errorf("ParseQuantileMethod(%q) should fail", bad)
+		}
+	}
+	percentiles, err := stats.ParsePercentiles("1, 5,95,99.9")
+	if err != nil || len(percentiles) != 4 || percentiles[3] != 99.9 {
+		t.Errorf("ParsePercentiles = %v, %v", percentiles, err)
+	}
+	if _, err := stats.ParsePercentiles("5,,95"); err == nil {
+		t.Error("ParsePercentiles should reject an empty entry")
+	}
+	for p, name := range map[float64]string{5: "p5", 95: "p95", 99.9: "p99.9"} {
+		if got := stats.PercentileName(p); got != name {
+			t.Errorf("PercentileName(%v) = %q, expected %q", p, got, name)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package test
+
+import (
+	"encoding/base64"
+	"errors"
+	"math"
+	"testing"
+
+	"bdgp2025/src/db_interface"
+	"bdgp2025/src/utils/filter"
+)
+
+func TestParseDevicePath(t *testing.T) {
+	valid := map[string]string{
+		"root.example.exampledev": "root.example.exampledev",
+		"root.ln.dev7":            "root.ln.dev7",
+		"root.sg.123":             "root.sg.`123`",
+		"root.sg.time":            "root.sg.`time`",
+		"root.sg.`a.b`":           "root.sg.`a.b`",
+		"root.sg.`it``s`":         "root.sg.`it``s`",
+		"root.工厂.设备1":             "root.工厂.设备1",
+	}
+	for input, expected := range valid {
+		path, err := db_interface.ParseDevicePath(input)
+		if err != nil {
+			t.Errorf("ParseDevicePath(%q) failed: %v", input, err)
+			continue
+		}
+		if got := path.String(); got != expected {
+			t.Errorf("ParseDevicePath(%q).String() = %q, expected %q", input, got, expected)
+		}
+	}
+
+	invalid := []string{
+		"",
+		"root",
+		"example.dev",
+		"root.sg.*",
+		"root.sg.**",
+		"root..dev",
+		"root.dev.",
+		"root.sg.dev; delete timeseries root.**",
+		"root.sg.dev where 1=1",
+		"root.sg.`unterminated",
+		"root.sg.`a`b",
+	}
+	for _, input := range invalid {
+		if _, err := db_interface.ParseDevicePath(input); err == nil {
+			t.Errorf("ParseDevicePath(%q) should have failed", input)
+		}
+	}
+}
+
+func TestSelectQueryBuild(t *testing.T) {
+	where, err := filter.Parse("engine_rpm > 1000 and time < 5")
+	if err != nil {
+		t.Fatal(err)
+	}
+	after := int64(42)
+
+	cases := []struct {
+		query    db_interface.SelectQuery
+		expected string
+	}{
+		{
+			db_interface.SelectQuery{Device: "root.example.exampledev"},
+			"select * from root.example.exampledev",
+		},
+		{
+			db_interface.SelectQuery{Device: "root.example.exampledev", Where: where, Limit: 10, Offset: 20},
+			"select * from root.example.exampledev where (engine_rpm > 1000 and time < 5) limit 10 offset 20",
+		},
+		{
+			db_interface.SelectQuery{Device: "root.sg.dev", Measurements: []string{"engine_rpm", "select"}, StartTime: 1, EndTime: 100, AfterTime: &after},
+			"select engine_rpm, `select` from root.sg.dev where time >= 1 and time < 100 and time > 42",
+		},
+		{
+			db_interface.SelectQuery{Device: "root.sg.dev", Aggregations: []string{"count", "max_time"}},
+			"select count(*), max_time(*) from root.sg.dev",
+		},
+		{
+			db_interface.SelectQuery{
+				Device:       "root.sg.dev",
+				Measurements: []string{"coolant_temp"},
+				Aggregations: []string{"avg"},
+				GroupBy:      &db_interface.GroupByTime{StartTime: 0, EndTime: 3600000, Interval: "1m", SlidingStep: "30s"},
+			},
+			"select avg(coolant_temp) from root.sg.dev group by ([0, 3600000), 1m, 30s)",
+		},
+	}
+
+	for _, c := range cases {
+		got, err := c.query.Build()
+		if err != nil {
+			t.Errorf("Build() failed: %v", err)
+			continue
+		}
+		if got != c.expected {
+			t.Errorf("Build() = %q, expected %q", got, c.expected)
+		}
+	}
+
+	rejected := []db_interface.SelectQuery{
+		{Device: "root.sg.dev;drop"},
+		{Device: "root.sg.dev", Aggregations: []string{"count(*) from root.**;"}},
+		{Device: "root.sg.dev", Aggregations: []string{"avg"}, GroupBy: &db_interface.GroupByTime{StartTime: 0, EndTime: 10, Interval: "1m); delete"}},
+		{Device: "root.sg.dev", Limit: -1},
+	}
+	for _, query := range rejected {
+		if sql, err := query.Build(); err == nil {
+			t.Errorf("Build() should have failed, got %q", sql)
+		}
+	}
+}
+
+func TestRawDataCursor(t *testing.T) {
+	for _, timestamp := range []int64{0, 1700000000123, -5, math.MaxInt64} {
+		cursor := db_interface.EncodeRawDataCursor(timestamp)
+		got, err := db_interface.DecodeRawDataCursor(cursor)
+		if err != nil || got != timestamp {
+			t.Errorf("DecodeRawDataCursor(EncodeRawDataCursor(%d)) = %d, %v", timestamp, got, err)
+		}
+	}
+
+	tampered := []string{
+		"not base64!",
+		base64.RawURLEncoding.EncodeToString([]byte("1700000000123")),   // Missing prefix
+		base64.RawURLEncoding.EncodeToString([]byte("t:17000000x0123")), // Not a timestamp
+		base64.RawURLEncoding.EncodeToString([]byte("t:")),
+	}
+	for _, cursor := range tampered {
+		if _, err := db_interface.DecodeRawDataCursor(cursor); !errors.Is(err, db_interface.ErrInvalidQuery) {
+			t.Errorf("DecodeRawDataCursor(%q) should match ErrInvalidQuery, got %v", cursor, err)
+		}
+	}
+}
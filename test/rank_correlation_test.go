@@ -0,0 +1,109 @@
+package test
+
+import (
+	"math"
+	"math/rand/v2"
+	"reflect"
+	"testing"
+
+	"bdgp2025/src/utils/stats"
+)
+
+func TestRanksWithTies(t *testing.T) {
+	if ranks := stats.Ranks([]float64{10, 30, 20, 30, 10}); !reflect.DeepEqual(ranks, []float64{1.5, 4.5, 3, 4.5, 1.5}) {
+		t.Errorf("Ranks = %v, expected [1.5 4.5 3 4.5 1.5]", ranks)
+	}
+}
+
+func TestSpearmanAndKendall(t *testing.T) {
+	// Reference values from scipy.stats.spearmanr and kendalltau
+	if rho := stats.Spearman([]float64{1, 2, 3, 4, 5}, []float64{5, 6, 7, 8, 7}); math.Abs(rho-0.8207826816681233) > 1e-12 {
+		t.Errorf("Spearman = %v, expected 0.8207826816681233", rho)
+	}
+	if tau := stats.KendallTauB([]float64{12, 2, 1, 12, 2}, []float64{1, 4, 7, 1, 0}); math.Abs(tau+0.47140452079103173) > 1e-12 {
+		t.Errorf("KendallTauB = %v, expected -0.47140452079103173", tau)
+	}
+	// A monotone but non-linear relation is perfect for both rank methods
+	x := []float64{1, 2, 3, 4, 5, 6}
+	y := []float64{1, 8, 27, 64, 125, 1e6}
+	if rho, tau := stats.Spearman(x, y), stats.KendallTauB(x, y); rho != 1 || tau != 1 {
+		t.Errorf("Spearman/KendallTauB of a monotone relation = %v/%v, expected 1/1", rho, tau)
+	}
+	if tau := stats.KendallTauB(x, []float64{3, 3, 3, 3, 3, 3}); tau != 0 {
+		t.Errorf("KendallTauB against a constant = %v, expected 0", tau)
+	}
+}
+
+// kendallTauBQuadratic counts concordant and discordant pairs directly
+func kendallTauBQuadratic(x []float64, y []float64) float64 {
+	var concordant, discordant, xOnly, yOnly float64
+	for i := range x {
+		for j := i + 1; j < len(x); j++ {
+			dx, dy := x[i]-x[j], y[i]-y[j]
+			switch {
+			case dx == 0 && dy == 0:
+			case dx == 0:
+				xOnly++
+			case dy == 0:
+				yOnly++
+			case dx*dy > 0:
+				concordant++
+			default:
+				discordant++
+			}
+		}
+	}
+	return (concordant - discordant) / math.Sqrt((concordant+discordant+yOnly)*(concordant+discordant+xOnly))
+}
+
+func TestKendallMatchesPairCounting(t *testing.T) {
+	rng := rand.New(rand.NewPCG(11, 11))
+	x, y := make([]float64, 500), make([]float64, 500)
+	for i := range x {
+		// Rounding leaves plenty of ties on both sides
+		x[i] = math.Round(rng.NormFloat64() * 3)
+		y[i] = math.Round(x[i] + rng.NormFloat64()*4)
+	}
+	if fast, slow := stats.KendallTauB(x, y), kendallTauBQuadratic(x, y); math.Abs(fast-slow) > 1e-12 {
+		t.Errorf("KendallTauB = %v, pair counting gives %v", fast, slow)
+	}
+}
+
+func TestParseCorrelationMethods(t *testing.T) {
+	checks := map[string][]string{
+		"":                 {"pearson"},
+		"kendall,Spearman": {"spearman", "kendall"},
+		"all":              {"pearson", "spearman", "kendall"},
+	}
+	for input, expected := range checks {
+		if methods, err := stats.ParseCorrelationMethods(input); err != nil || !reflect.DeepEqual(methods, expected) {
+			t.Errorf("ParseCorrelationMethods(%q) = %v, %v, expected %v", input, methods, err, expected)
+		}
+	}
+	if _, err := stats.ParseCorrelationMethods("distance"); err == nil {
+		t.Error("ParseCorrelationMethods should reject an unknown method")
+	}
+}
+
+func TestCorrelationSignificance(t *testing.T) {
+	// r = 0.5 over 30 pairs: t = 3.055 on 28 degrees of freedom, Fisher-z interval [0.170, 0.729]
+	test := stats.TestCorrelation(stats.CorrelationPearson, 0.5, 30, 0.95)
+	if math.Abs(test.PValue-0.004906) > 1e-5 {
+		t.Errorf("p-value = %v, expected 0.004906", test.PValue)
+	}
+	if math.Abs(test.Interval.Lower-0.17043) > 1e-4 || math.Abs(test.Interval.Upper-0.72896) > 1e-4 {
+		t.Errorf("interval = %+v, expected [0.17043, 0.72896]", test.Interval)
+	}
+	// Kendall's z = 3τ√(n(n-1)) / √(2(2n+5))
+	if test := stats.TestCorrelation(stats.CorrelationKendall, 0.3, 20, 0.95); math.Abs(test.PValue-0.06440) > 1e-4 {
+		t.Errorf("Kendall p-value = %v, expected 0.0644", test.PValue)
+	}
+	// Spearman's interval is wider than Pearson's for the same coefficient
+	spearman := stats.TestCorrelation(stats.CorrelationSpearman, 0.5, 30, 0.95)
+	if spearman.Interval.Upper-spearman.Interval.Lower <= test.Interval.Upper-test.Interval.Lower {
+		t.Errorf("Spearman interval %+v should be wider than Pearson's %+v", spearman.Interval, test.Interval)
+	}
+	if test := stats.TestCorrelation(stats.CorrelationPearson, 0.9, 2, 0.95); test.PValue != 1 || test.Interval.Lower != -1 || test.Interval.Upper != 1 {
+		t.Errorf("two pairs should give p = 1 and [-1, 1], got %+v", test)
+	}
+}
@@ -0,0 +1,93 @@
+package test
+
+import (
+	"reflect"
+	"testing"
+
+	"bdgp2025/src/utils/rolling"
+)
+
+func roll(t *testing.T, config rolling.Config, timestamps []int64, values []float64) *rolling.Series {
+	t.Helper()
+	roller, err := rolling.NewRoller(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range timestamps {
+		roller.Add(timestamps[i], values[i])
+	}
+	return roller.Finish()
+}
+
+func TestRollingCountWindows(t *testing.T) {
+	timestamps := []int64{10, 20, 30, 40, 50, 60, 70}
+	values := []float64{1, 2, 3, 4, 5, 6, 7}
+
+	// Tumbling windows of three rows, the last one partial
+	series := roll(t, rolling.Config{Kind: rolling.KindCount, Length: 3, Step: 3, Percentiles: []float64{50}}, timestamps, values)
+	if !reflect.DeepEqual(series.Mean, []float64{2, 5, 7}) || !reflect.DeepEqual(series.Count, []int{3, 3, 1}) {
+		t.Errorf("tumbling mean/count = %v/%v, expected [2 5 7]/[3 3 1]", series.Mean, series.Count)
+	}
+	if !reflect.DeepEqual(series.Start, []int64{10, 40, 70}) || !reflect.DeepEqual(series.End, []int64{30, 60, 70}) {
+		t.Errorf("tumbling bounds = %v-%v", series.Start, series.End)
+	}
+
+	// Sliding windows of three rows moving by one
+	series = roll(t, rolling.Config{Kind: rolling.KindCount, Length: 3, Step: 1, Percentiles: []float64{50}}, timestamps, values)
+	if !reflect.DeepEqual(series.Percentiles["p50"], []float64{2, 3, 4, 5, 6}) {
+		t.Errorf("sliding median = %v, expected [2 3 4 5 6]", series.Percentiles["p50"])
+	}
+	if !reflect.DeepEqual(series.Min, []float64{1, 2, 3, 4, 5}) || !reflect.DeepEqual(series.Max, []float64{3, 4, 5, 6, 7}) {
+		t.Errorf("sliding min/max = %v/%v", series.Min, series.Max)
+	}
+	if series.StdDev[0] != 1 {
+		t.Errorf("std dev of 1, 2, 3 = %v, expected 1", series.StdDev[0])
+	}
+
+	// Hopping windows of two rows every three rows skip a row between windows
+	series = roll(t, rolling.Config{Kind: rolling.KindCount, Length: 2, Step: 3}, timestamps, values)
+	if !reflect.DeepEqual(series.Mean, []float64{1.5, 4.5, 7}) {
+		t.Errorf("hopping mean = %v, expected [1.5 4.5 7]", series.Mean)
+	}
+}
+
+func TestRollingTimeWindows(t *testing.T) {
+	// A gap between 2500 and 9000 leaves windows without rows
+	timestamps := []int64{1000, 1500, 2000, 2500, 9000, 9500}
+	values := []float64{1, 3, 5, 7, 10, 20}
+
+	series := roll(t, rolling.Config{Kind: rolling.KindTime, Length: 1000, Step: 1000}, timestamps, values)
+	if !reflect.DeepEqual(series.Start, []int64{1000, 2000, 9000}) || !reflect.DeepEqual(series.End, []int64{2000, 3000, 10000}) {
+		t.Errorf("tumbling windows = %v-%v", series.Start, series.End)
+	}
+	if !reflect.DeepEqual(series.Mean, []float64{2, 6, 15}) {
+		t.Errorf("tumbling mean = %v, expected [2 6 15]", series.Mean)
+	}
+
+	series = roll(t, rolling.Config{Kind: rolling.KindTime, Length: 1000, Step: 500}, timestamps, values)
+	if !reflect.DeepEqual(series.Start, []int64{1000, 1500, 2000, 2500, 8500, 9000}) {
+		t.Errorf("sliding window starts = %v, expected [1000 1500 2000 2500 8500 9000]", series.Start)
+	}
+	if !reflect.DeepEqual(series.Count, []int{2, 2, 2, 1, 1, 2}) {
+		t.Errorf("sliding window counts = %v, expected [2 2 2 1 1 2]", series.Count)
+	}
+}
+
+func TestRollingParseConfig(t *testing.T) {
+	config, err := rolling.ParseConfig("60s", "15s", "1, 99")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := rolling.Config{Kind: rolling.KindTime, Length: 60000, Step: 15000, Percentiles: []float64{1, 99}}
+	if !reflect.DeepEqual(config, expected) {
+		t.Errorf("ParseConfig = %+v, expected %+v", config, expected)
+	}
+	if config, err := rolling.ParseConfig("500", "", ""); err != nil || config.Kind != rolling.KindCount || config.Step != 500 {
+		t.Errorf("ParseConfig(500) = %+v, %v", config, err)
+	}
+	for _, bad := range [][3]string{{"0", "", ""}, {"soon", "", ""}, {"500", "10s", ""}, {"60s", "", "101"}} {
+		if _, err := rolling.ParseConfig(bad[0], bad[1], bad[2]); err == nil {
+			t.Errorf("ParseConfig(%q, %q, %q) should fail", bad[0], bad[1], bad[2])
+		}
+	}
+}
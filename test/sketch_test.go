@@ -0,0 +1,156 @@
+package test
+
+import (
+	"math"
+	"math/rand/v2"
+	"reflect"
+	"testing"
+
+	"bdgp2025/src/utils/sketch"
+	"bdgp2025/src/utils/stats"
+)
+
+func TestKLLExactForSmallInputs(t *testing.T) {
+	s := sketch.NewKLL(sketch.DefaultConfig())
+	for _, v := range []float64{7, 1, 3, 9, 5} {
+		s.Add(v)
+	}
+	// Matches numpy.quantile with its default linear interpolation
+	expected := map[float64]float64{0: 1, 0.1: 1.8, 0.25: 3, 0.5: 5, 0.6: 5.8, 1: 9}
+	for q, want := range expected {
+		if got := s.Quantile(q); math.Abs(got-want) > 1e-12 {
+			t.Errorf("Quantile(%v) = %v, expected %v", q, got, want)
+		}
+	}
+	if !math.IsNaN(sketch.NewKLL(sketch.DefaultConfig()).Quantile(0.5)) {
+		t.Error("an empty sketch should return NaN")
+	}
+}
+
+func TestKLLRankErrorAndMemory(t *testing.T) {
+	const n = 1_000_000
+	config := sketch.Config{Epsilon: 0.01}
+	values := rand.New(rand.NewPCG(1, 2)).Perm(n)
+
+	// Build half of the data in each sketch to exercise Merge as well
+	left, right := sketch.NewKLL(config), sketch.NewKLL(config)
+	for i, v := range values {
+		if i%2 == 0 {
+			left.Add(float64(v))
+		} else {
+			right.Add(float64(v))
+		}
+	}
+	left.Merge(right)
+
+	if left.Count() != n {
+		t.Fatalf("Count() = %d, expected %d", left.Count(), n)
+	}
+	if left.Retained() > 5000 {
+		t.Errorf("sketch retains %d values, expected a bounded size", left.Retained())
+	}
+	// The values are a permutation of 0..n-1, so a value is its own rank
+	for _, q := range []float64{0.01, 0.05, 0.25, 0.5, 0.75, 0.95, 0.99} {
+		got := left.Quantile(q)
+		if rankError := math.Abs(got/float64(n-1) - q); rankError > config.Epsilon {
+			t.Errorf("Quantile(%v) = %v has rank error %.4f, expected at most %v", q, got, rankError, config.Epsilon)
+		}
+	}
+	if left.Min() != 0 || left.Max() != n-1 {
+		t.Errorf("Min/Max = %v/%v, expected exact values 0/%d", left.Min(), left.Max(), n-1)
+	}
+}
+
+func TestDistinctCount(t *testing.T) {
+	small := sketch.NewDistinct()
+	for i := 0; i < 10000; i++ {
+		small.Add(float64(i % 700))
+	}
+	if count, exact := small.Count(); count != 700 || !exact {
+		t.Errorf("Count = %d (exact %v), expected exactly 700", count, exact)
+	}
+
+	// Two halves, each below the exact limit, whose union is above it
+	left, right := sketch.NewDistinct(), sketch.NewDistinct()
+	for i := 0; i < 12000; i++ {
+		left.Add(float64(i))
+		right.Add(float64(i + 8000))
+	}
+	left.Merge(right)
+	count, exact := left.Count()
+	if exact || math.Abs(float64(count)-20000)/20000 > 0.03 {
+		t.Errorf("Count = %d (exact %v), expected an estimate close to 20000", count, exact)
+	}
+
+	large := sketch.NewDistinct()
+	rng := rand.New(rand.NewPCG(7, 7))
+	for i := 0; i < 500000; i++ {
+		large.Add(rng.NormFloat64())
+	}
+	if count, _ := large.Count(); math.Abs(float64(count)-500000)/500000 > 0.03 {
+		t.Errorf("Count = %d, expected about 500000", count)
+	}
+}
+
+func TestSpaceSavingHeavyHitters(t *testing.T) {
+	// 30% of the stream is 1 and 20% is 2; the rest is spread over 10000 values
+	rng := rand.New(rand.NewPCG(3, 3))
+	left, right := sketch.NewSpaceSaving(64), sketch.NewSpaceSaving(64)
+	counts := map[float64]int64{}
+	for i := 0; i < 100000; i++ {
+		v := float64(10 + rng.IntN(10000))
+		switch r := rng.Float64(); {
+		case r < 0.3:
+			v = 1
+		case r < 0.5:
+			v = 2
+		}
+		counts[v]++
+		if i%2 == 0 {
+			left.Add(v)
+		} else {
+			right.Add(v)
+		}
+	}
+	left.Merge(right)
+
+	top := left.Top(2)
+	if len(top) != 2 || top[0].Value != 1 || top[1].Value != 2 {
+		t.Fatalf("Top(2) = %+v, expected values 1 and 2", top)
+	}
+	for _, v := range top {
+		if v.Count < counts[v.Value] || v.Count-v.Error > counts[v.Value] {
+			t.Errorf("count of %v in [%d, %d], true count %d", v.Value, v.Count-v.Error, v.Count, counts[v.Value])
+		}
+	}
+	if left.Count() != 100000 {
+		t.Errorf("Count = %d, expected 100000", left.Count())
+	}
+
+	// Exact while there are fewer distinct values than counters
+	exact := sketch.NewSpaceSaving(8)
+	for _, v := range []float64{3, 1, 3, 2, 3, 1} {
+		exact.Add(v)
+	}
+	expected := []sketch.FrequentValue{{Value: 3, Count: 3}, {Value: 1, Count: 2}, {Value: 2, Count: 1}}
+	if got := exact.Top(5); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Top(5) = %+v, expected %+v", got, expected)
+	}
+}
+
+func TestBinnedEntropy(t *testing.T) {
+	if h := stats.BinnedEntropy([]float64{5, 5, 5}, 32); h != 0 {
+		t.Errorf("entropy of a constant = %v, expected 0", h)
+	}
+	// Two equally frequent values land in the first and last bin
+	if h := stats.BinnedEntropy([]float64{0, 1, 0, 1}, 32); !closeTo(h, 1) {
+		t.Errorf("entropy of two values = %v, expected 1 bit", h)
+	}
+	uniform := make([]float64, 3200)
+	for i := range uniform {
+		uniform[i] = float64(i)
+	}
+	if h := stats.BinnedEntropy(uniform, 32); !closeTo(h, 5) {
+		t.Errorf("entropy of a uniform column = %v, expected 5 bits", h)
+	}
+}
@@ -0,0 +1,185 @@
+package test
+
+import (
+	"bufio"
+	"encoding/csv"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"bdgp2025/src/db_interface"
+	"bdgp2025/src/utils/sketch"
+)
+
+// testdata/engine_stats.golden is the output of `python3 data/engine_stats.py`, run from the
+// repository root. The script reports population variance and Tukey-hinge quartiles, so the
+// test converts the variance and checks quartiles by rank instead of by value.
+
+type goldenStats map[string]map[string]float64 // Column -> statistic name -> value
+
+func readGoldenStats(t *testing.T, path string) map[string]goldenStats {
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("Failed to open golden file: %v", err)
+	}
+	defer file.Close()
+
+	golden := make(map[string]goldenStats)
+	var condition, column string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "=== Engine Condition "):
+			condition = strings.TrimSuffix(strings.TrimPrefix(line, "=== Engine Condition "), " ===")
+			golden[condition] = make(goldenStats)
+		case strings.HasPrefix(line, "  "):
+			name, value, _ := strings.Cut(strings.TrimSpace(line), ": ")
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				t.Fatalf("Malformed golden line %q: %v", line, err)
+			}
+			golden[condition][column][name] = parsed
+		case strings.HasSuffix(line, ":"):
+			column = strings.TrimSuffix(line, ":")
+			golden[condition][column] = make(map[string]float64)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return golden
+}
+
+// readEngineColumns groups every column of engine_data.csv by the Engine Condition column
+func readEngineColumns(t *testing.T, path string) ([]string, map[string][][]float64) {
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("Failed to open CSV file: %v", err)
+	}
+	defer file.Close()
+
+	rows, err := csv.NewReader(file).ReadAll()
+	if err != nil {
+		t.Fatalf("Failed to read CSV file: %v", err)
+	}
+	header := rows[0]
+	conditionIndex := len(header) - 1
+	columns := make(map[string][][]float64)
+	for _, row := range rows[1:] {
+		condition := row[conditionIndex]
+		if columns[condition] == nil {
+			columns[condition] = make([][]float64, conditionIndex)
+		}
+		for i := 0; i < conditionIndex; i++ {
+			value, err := strconv.ParseFloat(row[i], 64)
+			if err != nil {
+				t.Fatalf("Failed to parse %q: %v", row[i], err)
+			}
+			columns[condition][i] = append(columns[condition][i], value)
+		}
+	}
+	return header[:conditionIndex], columns
+}
+
+func closeTo(got float64, expected float64) bool {
+	return math.Abs(got-expected) <= 1e-6+1e-9*math.Abs(expected)
+}
+
+func TestColumnAccumulatorMatchesGolden(t *testing.T) {
+	golden := readGoldenStats(t, "testdata/engine_stats.golden")
+	header, columns := readEngineColumns(t, "../data/engine_data.csv")
+	const epsilon = 0.01 // Default rank error bound of the quantile sketch
+
+	for condition, values := range columns {
+		for i, name := range header {
+			expected, ok := golden[condition][name]
+			if !ok {
+				t.Fatalf("Golden file has no entry for condition %s, column %s", condition, name)
+			}
+
+			acc := db_interface.NewColumnAccumulator(sketch.DefaultConfig())
+			distinct := make(map[float64]bool)
+			for _, v := range values[i] {
+				acc.Add(v)
+				distinct[v] = true
+			}
+			summary := acc.Summary()
+
+			n := expected["Count"]
+			if float64(summary.Count) != n || summary.NullCount != 0 {
+				t.Errorf("condition %s, %s: Count = %d (nulls %d), expected %v", condition, name, summary.Count, summary.NullCount, n)
+				continue
+			}
+			if summary.DistinctCount != int64(len(distinct)) {
+				t.Errorf("condition %s, %s: DistinctCount = %d, expected %d", condition, name, summary.DistinctCount, len(distinct))
+			}
+
+			sampleVariance := expected["Variance"] * n / (n - 1)
+			checks := []struct {
+				stat     string
+				got      float64
+				expected float64
+			}{
+				{"Sum", summary.Sum, expected["Sum"]},
+				{"Mean", summary.Mean, expected["Mean"]},
+				{"Variance", summary.Variance, sampleVariance},
+				{"StdDev", summary.StdDev, math.Sqrt(sampleVariance)},
+				{"Min", summary.Min, expected["Min"]},
+				{"Max", summary.Max, expected["Max"]},
+				{"Skewness", summary.Skewness, expected["Skewness"]},
+				{"Kurtosis", summary.Kurtosis, expected["Kurtosis"]},
+			}
+			for _, c := range checks {
+				if !closeTo(c.got, c.expected) {
+					t.Errorf("condition %s, %s: %s = %.6f, expected %.6f", condition, name, c.stat, c.got, c.expected)
+				}
+			}
+
+			sorted := append([]float64(nil), values[i]...)
+			sort.Float64s(sorted)
+			quartiles := []struct {
+				stat string
+				q    float64
+				got  float64
+			}{
+				{"Q1", 0.25, summary.Q1},
+				{"Median", 0.5, summary.Median},
+				{"Q3", 0.75, summary.Q3},
+			}
+			for _, c := range quartiles {
+				// With ties a value covers a range of ranks; the target rank must fall inside it, give or take epsilon
+				below := float64(sort.SearchFloat64s(sorted, c.got)) / n
+				upTo := float64(sort.Search(len(sorted), func(j int) bool { return sorted[j] > c.got })) / n
+				if c.q < below-epsilon || c.q > upTo+epsilon {
+					t.Errorf("condition %s, %s: %s = %.6f covers ranks [%.4f, %.4f], expected %.2f ± %v (golden %.6f)",
+						condition, name, c.stat, c.got, below, upTo, c.q, epsilon, expected[c.stat])
+				}
+			}
+			if !closeTo(summary.IQR, summary.Q3-summary.Q1) {
+				t.Errorf("condition %s, %s: IQR = %.6f, expected Q3-Q1 = %.6f", condition, name, summary.IQR, summary.Q3-summary.Q1)
+			}
+		}
+	}
+}
+
+func TestColumnAccumulatorNulls(t *testing.T) {
+	acc := db_interface.NewColumnAccumulator(sketch.DefaultConfig())
+	for _, v := range []float64{2, 4, 4, 4, 5, 5, 7, 9} {
+		acc.Add(v)
+	}
+	acc.AddNull()
+	acc.Add(math.NaN())
+
+	summary := acc.Summary()
+	if summary.Count != 8 || summary.NullCount != 2 || summary.DistinctCount != 5 {
+		t.Errorf("Count/NullCount/DistinctCount = %d/%d/%d, expected 8/2/5", summary.Count, summary.NullCount, summary.DistinctCount)
+	}
+	// Population variance of this sample is exactly 4, so the sample variance is 32/7
+	if !closeTo(summary.Mean, 5) || !closeTo(summary.Variance, 32.0/7) {
+		t.Errorf("Mean/Variance = %v/%v, expected 5/%v", summary.Mean, summary.Variance, 32.0/7)
+	}
+}
@@ -0,0 +1,75 @@
+package test
+
+import (
+	"math"
+	"math/rand/v2"
+	"testing"
+
+	"bdgp2025/src/utils/xcorr"
+)
+
+func TestCrossCorrelation(t *testing.T) {
+	// y repeats x five rows later with some noise, so the peak is at lag +5
+	rng := rand.New(rand.NewPCG(3, 5))
+	n, delay := 1000, 5
+	x := make([]float64, n)
+	y := make([]float64, n)
+	for i := range x {
+		x[i] = rng.NormFloat64()
+	}
+	for i := range y {
+		y[i] = 0.5 * rng.NormFloat64()
+		if i >= delay {
+			y[i] += x[i-delay]
+		}
+	}
+
+	result, err := xcorr.Compute(x, y, 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.PeakLag != delay {
+		t.Errorf("peak lag = %d, expected %d", result.PeakLag, delay)
+	}
+	if len(result.Lags) != 41 || result.Lags[0] != -20 || result.Lags[40] != 20 {
+		t.Errorf("lags run from %d to %d in %d steps, expected -20 to 20 in 41", result.Lags[0], result.Lags[len(result.Lags)-1], len(result.Lags))
+	}
+
+	// The FFT must agree with the direct sum at every lag
+	mean := func(v []float64) float64 {
+		sum := 0.0
+		for _, value := range v {
+			sum += value
+		}
+		return sum / float64(len(v))
+	}
+	mx, my := mean(x), mean(y)
+	var sxx, syy float64
+	for i := range x {
+		sxx += (x[i] - mx) * (x[i] - mx)
+		syy += (y[i] - my) * (y[i] - my)
+	}
+	for i, lag := range result.Lags {
+		sum := 0.0
+		for t := 0; t < n; t++ {
+			if t+lag >= 0 && t+lag < n {
+				sum += (x[t] - mx) * (y[t+lag] - my)
+			}
+		}
+		if expected := sum / math.Sqrt(sxx*syy); math.Abs(result.Coefficients[i]-expected) > 1e-9 {
+			t.Errorf("coefficient at lag %d = %v, expected %v", lag, result.Coefficients[i], expected)
+		}
+	}
+
+	// The lags are capped by the series length
+	short, err := xcorr.Compute([]float64{1, 2, 4}, []float64{2, 1, 0}, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if short.MaxLag != 2 || !closeTo(short.Coefficients[2], -0.9819805) {
+		t.Errorf("short series: max lag %d, lag 0 coefficient %v, expected 2 and -0.9819805", short.MaxLag, short.Coefficients[2])
+	}
+	if _, err := xcorr.Compute([]float64{1, 1, 1}, []float64{1, 2, 3}, 1); err == nil {
+		t.Error("Compute should reject a constant series")
+	}
+}